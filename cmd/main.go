@@ -2,14 +2,27 @@ package main
 
 import (
 	"api-server/internal/api"
+	"api-server/internal/audit"
+	"api-server/internal/events"
+	"api-server/internal/graphqlapi"
+	"api-server/internal/grpcapi"
+	"api-server/internal/handlers"
+	"api-server/internal/idempotency"
+	"api-server/internal/logging"
+	"api-server/internal/metrics"
 	"api-server/internal/otel"
+	"api-server/internal/processing"
+	"api-server/internal/purge"
+	"api-server/internal/requestid"
+	"api-server/internal/tracestatus"
+	"api-server/internal/webhook"
 	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"runtime"
-	"time"
 
 	"github.com/XSAM/otelsql"
 	"github.com/gorilla/mux"
@@ -22,72 +35,32 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
 )
 
-var (
-	requestCounter = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "api_server_requests_total",
-			Help: "Total number of requests to the API server by endpoint",
-		},
-		[]string{"endpoint", "method"},
-	)
-	requestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "api_server_request_duration_seconds",
-			Help:    "Duration of API server requests in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"endpoint", "method"},
-	)
-	goGoroutines = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "api_server_go_goroutines",
-			Help: "Number of goroutines in the Go runtime",
-		},
-	)
-	goMemory = prometheus.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "api_server_go_memory_bytes",
-			Help: "Amount of memory allocated by the Go runtime in bytes",
-		},
-	)
-)
+// version is set at build time with -ldflags "-X main.version=<tag>".
+var version = "dev"
 
-func init() {
-	// Register Prometheus metrics
-	prometheus.MustRegister(requestCounter)
-	prometheus.MustRegister(requestDuration)
-	prometheus.MustRegister(goGoroutines)
-	prometheus.MustRegister(goMemory)
+func main() {
+	envErr := godotenv.Load()
 
-	// Start a goroutine to update Go runtime metrics periodically
-	go func() {
-		for {
-			var memStats runtime.MemStats
-			runtime.ReadMemStats(&memStats)
-			goGoroutines.Set(float64(runtime.NumGoroutine()))
-			goMemory.Set(float64(memStats.Alloc))
-			time.Sleep(30 * time.Second) // Update every 30 seconds
-		}
-	}()
-}
+	ctx := context.Background()
+	logger, closeLogger := setupLogger(ctx)
+	defer closeLogger()
+	slog.SetDefault(logger)
 
-func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Warning: Error loading .env file, relying on environment variables:", err)
+	if envErr != nil {
+		logger.Warn("error loading .env file, relying on environment variables", "error", envErr)
 	}
 
-	fmt.Println("Starting API server...")
+	logger.Info("starting API server", "version", version)
 
 	// Initialize OpenTelemetry
-	ctx := context.Background()
-	shutdown, err := otel.SetupOTelSDK(ctx)
+	shutdown, err := otel.SetupOTelSDK(ctx, version)
 	if err != nil {
-		log.Fatalf("Failed to initialize OpenTelemetry: %v", err)
+		logger.Error("failed to initialize OpenTelemetry", "error", err)
+		os.Exit(1)
 	}
 	defer func() {
 		if err := shutdown(ctx); err != nil {
-			log.Printf("Error shutting down OpenTelemetry: %v", err)
+			logger.Error("error shutting down OpenTelemetry", "error", err)
 		}
 	}()
 
@@ -103,7 +76,7 @@ func main() {
 	// Construct the connection string
 	connStr := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable options=-csearch_path=api,public",
 		dbUser, dbPassword, dbHost, dbPort, dbName)
-	fmt.Println("🔌 DB Connection String:", connStr)
+	logger.Info("connecting to database", "host", dbHost, "port", dbPort, "db_name", dbName, "db_user", dbUser)
 	// db, err := sql.Open("postgres", connStr)
 	// if err != nil {
 	// 	log.Fatalf("Failed to open database connection: %v", err)
@@ -118,16 +91,19 @@ func main() {
 		),
 	)
 	if err != nil {
-		log.Fatalf("Failed to open database connection: %v", err)
+		logger.Error("failed to open database connection", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
-	// Register database driver for OpenTelemetry metrics
+	// Register connection pool metrics with the global meter provider so they
+	// are exported with the rest of the OpenTelemetry metrics
 	err = otelsql.RegisterDBStatsMetrics(db,
 		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
 	)
 	if err != nil {
-		log.Fatalf("Failed to register database metrics: %v", err)
+		logger.Error("failed to register database metrics", "error", err)
+		os.Exit(1)
 	}
 
 	// // Test database connection
@@ -135,38 +111,192 @@ func main() {
 	// if err := db.Ping(); err != nil {
 	// 	log.Fatalf("Failed to ping database: %v", err)
 	// }
-	fmt.Println("Database connection successful")
+	logger.Info("database connection successful")
 
 	// Set up router with middleware for metrics
 	router := mux.NewRouter()
+	router.Use(requestid.Middleware)
 	router.Use(otelmux.Middleware("api-server"))
 
-	// Middleware to record request metrics
-	router.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			endpoint := r.URL.Path
-			method := r.Method
+	// Middleware to record request metrics by route template
+	router.Use(metrics.Middleware)
 
-			// Increment request counter
-			requestCounter.WithLabelValues(endpoint, method).Inc()
+	// Expose Prometheus metrics endpoint first, merging the default registry
+	// with metrics recorded through OpenTelemetry
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, otel.PrometheusRegistry}
+	router.Handle("/metrics", promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})).Methods("GET")
 
-			// Call the next handler
-			next.ServeHTTP(w, r)
+	// Add application routes
+	idemCfg, err := idempotency.LoadConfig()
+	if err != nil {
+		logger.Error("invalid idempotency configuration", "error", err)
+		os.Exit(1)
+	}
+	proxies, err := audit.LoadTrustedProxies()
+	if err != nil {
+		logger.Error("invalid trusted proxy configuration", "error", err)
+		os.Exit(1)
+	}
+	audit.SetTrustedProxies(proxies)
+	gqlCfg, err := graphqlapi.LoadConfig()
+	if err != nil {
+		logger.Error("invalid GraphQL configuration", "error", err)
+		os.Exit(1)
+	}
+	// Follow trace status changes made by any replica
+	hub := tracestatus.NewHub(connStr, logger)
+	go hub.Run(ctx)
+	api.SetupRoutes(router, db, idemCfg, hub, gqlCfg, logger)
 
-			// Record request duration
-			duration := time.Since(start).Seconds()
-			requestDuration.WithLabelValues(endpoint, method).Observe(duration)
-		})
-	})
+	// Permanently remove soft-deleted records once their retention expires
+	startPurgeJob(ctx, db, logger)
 
-	// Expose Prometheus metrics endpoint first
-	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+	// Scan and parse uploaded traces
+	startTraceProcessor(ctx, db, logger)
 
-	// Add application routes
-	appRouter := api.SetupRoutes(db)
-	router.PathPrefix("/").Handler(appRouter)
+	// Send queued webhook deliveries
+	startWebhookDispatcher(ctx, db, logger)
+
+	// Publish domain events from the outbox to the message broker
+	startEventRelay(ctx, db, logger)
+
+	// Serve the gRPC API for internal services
+	startGRPCServer(ctx, db, logger)
 
 	// Start server
-	log.Fatal(http.ListenAndServe(":8080", router))
+	logger.Info("listening", "addr", ":8080")
+	if err := http.ListenAndServe(":8080", router); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// setupLogger builds the application logger from LOG_LEVEL and LOG_SINK,
+// falling back to JSON on stdout if the configured sink is unavailable.
+func setupLogger(ctx context.Context) (*slog.Logger, func() error) {
+	cfg, err := logging.LoadConfig()
+	if err != nil {
+		logger := logging.NewJSON(os.Stdout, slog.LevelInfo)
+		logger.Warn("invalid logging configuration, using defaults", "error", err)
+		return logger, func() error { return nil }
+	}
+
+	logger, closeFn, err := logging.New(ctx, cfg)
+	if err != nil {
+		logger = logging.NewJSON(os.Stdout, cfg.Level)
+		logger.Warn("log sink unavailable, falling back to stdout", "sink", cfg.Sink, "error", err)
+		return logger, func() error { return nil }
+	}
+	return logger, closeFn
+}
+
+// startPurgeJob runs the purge job in the background according to
+// PURGE_RETENTION and PURGE_INTERVAL.
+func startPurgeJob(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	cfg, err := purge.LoadConfig()
+	if err != nil {
+		logger.Error("invalid purge configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Interval == 0 {
+		logger.Info("purge job disabled")
+		return
+	}
+
+	storageCfg := handlers.LoadConfig()
+	client, err := handlers.NewStorageClient(ctx, storageCfg)
+	if err != nil {
+		logger.Error("failed to create storage client for purge job", "error", err)
+		os.Exit(1)
+	}
+	go purge.New(db, cfg, client, storageCfg.BucketName, logger).Run(ctx)
+}
+
+// startTraceProcessor processes uploaded traces in the background according
+// to TRACE_PROCESS_INTERVAL.
+func startTraceProcessor(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	cfg, err := processing.LoadConfig()
+	if err != nil {
+		logger.Error("invalid trace processing configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Interval == 0 {
+		logger.Warn("trace processor disabled, uploaded traces will not be processed")
+		return
+	}
+
+	client, err := handlers.NewStorageClient(ctx, handlers.LoadConfig())
+	if err != nil {
+		logger.Error("failed to create storage client for trace processor", "error", err)
+		os.Exit(1)
+	}
+	go processing.New(db, cfg, client, logger).Run(ctx)
+}
+
+// startGRPCServer serves the gRPC API in the background on GRPC_ADDR.
+func startGRPCServer(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	cfg, err := grpcapi.LoadConfig()
+	if err != nil {
+		logger.Error("invalid gRPC configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Addr == "" {
+		logger.Info("gRPC API disabled")
+		return
+	}
+
+	storageCfg := handlers.LoadConfig()
+	client, err := handlers.NewStorageClient(ctx, storageCfg)
+	if err != nil {
+		logger.Error("failed to create storage client for gRPC API", "error", err)
+		os.Exit(1)
+	}
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		logger.Error("failed to listen for gRPC", "addr", cfg.Addr, "error", err)
+		os.Exit(1)
+	}
+	srv := grpcapi.New(db, client, storageCfg.BucketName, logger)
+	go func() {
+		logger.Info("listening for gRPC", "addr", cfg.Addr)
+		if err := srv.Serve(lis); err != nil {
+			logger.Error("gRPC server stopped", "error", err)
+			os.Exit(1)
+		}
+	}()
+}
+
+// startWebhookDispatcher sends webhook deliveries in the background according
+// to WEBHOOK_POLL_INTERVAL, WEBHOOK_TIMEOUT and WEBHOOK_MAX_ATTEMPTS.
+func startWebhookDispatcher(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	cfg, err := webhook.LoadConfig()
+	if err != nil {
+		logger.Error("invalid webhook configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Interval == 0 {
+		logger.Info("webhook dispatcher disabled")
+		return
+	}
+	go webhook.New(db, cfg, logger).Run(ctx)
+}
+
+// startEventRelay publishes the event outbox in the background to the broker
+// selected by EVENTS_BROKER.
+func startEventRelay(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	cfg, err := events.LoadConfig()
+	if err != nil {
+		logger.Error("invalid events configuration", "error", err)
+		os.Exit(1)
+	}
+	pub, err := events.NewPublisher(cfg)
+	if err != nil {
+		logger.Error("failed to connect to the events broker", "broker", cfg.Broker, "error", err)
+		os.Exit(1)
+	}
+	if pub == nil {
+		logger.Info("event publishing disabled")
+		return
+	}
+	go events.NewRelay(db, pub, cfg, logger).Run(ctx)
 }
@@ -0,0 +1,26 @@
+package actor
+
+import "context"
+
+// Anonymous is the actor recorded for unauthenticated requests, such as
+// self-service sign-up.
+const Anonymous = "anonymous"
+
+// System is the actor recorded for work done by background jobs, such as
+// trace processing.
+const System = "system"
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated username.
+func NewContext(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, contextKey{}, username)
+}
+
+// FromContext returns the authenticated username stored in ctx, or Anonymous.
+func FromContext(ctx context.Context) string {
+	if username, ok := ctx.Value(contextKey{}).(string); ok && username != "" {
+		return username
+	}
+	return Anonymous
+}
@@ -1,52 +1,107 @@
 package api
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
 
+	"api-server/internal/actor"
+	"api-server/internal/graphqlapi"
 	"api-server/internal/handlers"
+	"api-server/internal/idempotency"
+	"api-server/internal/metrics"
+	"api-server/internal/problem"
+	"api-server/internal/tracestatus"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 )
 
-func BasicAuth(db *sql.DB) func(http.Handler) http.Handler {
+func BasicAuth(db *sql.DB, logger *slog.Logger) func(http.Handler) http.Handler {
 	// Ensure the user table exists in the api schema
 	err := ensureUsersTable(db)
 	if err != nil {
-		log.Printf("Warning: Could not ensure api.user table exists: %v", err)
+		logger.Warn("could not ensure api.user table exists", "error", err)
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			username, password, ok := r.BasicAuth()
 			if !ok {
+				metrics.AuthFailures.WithLabelValues(metrics.AuthMissingCredentials).Inc()
 				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				problem.Error(w, r, http.StatusUnauthorized, "valid credentials are required")
 				return
 			}
 
-			var storedPassword string
-			// Query the api.user table instead of users
-			err := db.QueryRow("SELECT password FROM api.user WHERE username = $1", username).Scan(&storedPassword)
-			switch {
-			case err == sql.ErrNoRows:
-				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			case err != nil:
-				log.Printf("Database error during auth: %v", err)
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			reason, err := Authenticate(r.Context(), db, username, password)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "database error during auth", "error", err)
+				problem.Error(w, r, http.StatusInternalServerError, "An unexpected error occurred.")
 				return
 			}
-
-			if password != storedPassword {
+			if reason != "" {
+				metrics.AuthFailures.WithLabelValues(reason).Inc()
 				w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				problem.Error(w, r, http.StatusUnauthorized, "valid credentials are required")
 				return
 			}
 
+			next.ServeHTTP(w, r.WithContext(actor.NewContext(r.Context(), username)))
+		})
+	}
+}
+
+// Authenticate checks username and password against the api.user table. It
+// returns the metrics.AuthFailures reason they are rejected for, or "" if
+// they are accepted.
+func Authenticate(ctx context.Context, db *sql.DB, username, password string) (string, error) {
+	var storedPassword string
+	err := db.QueryRowContext(ctx, "SELECT password FROM api.user WHERE username = $1 AND deleted_at IS NULL", username).Scan(&storedPassword)
+	switch {
+	case err == sql.ErrNoRows:
+		return metrics.AuthUnknownUser, nil
+	case err != nil:
+		return "", err
+	}
+	if password != storedPassword {
+		return metrics.AuthBadPassword, nil
+	}
+	return "", nil
+}
+
+// adminUsernames returns the usernames listed in ADMIN_USERNAMES
+// (comma-separated, default admin@example.com).
+func adminUsernames() map[string]bool {
+	admins := map[string]bool{}
+	list := os.Getenv("ADMIN_USERNAMES")
+	if list == "" {
+		list = "admin@example.com"
+	}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			admins[name] = true
+		}
+	}
+	return admins
+}
+
+// RequireAdmin limits a route to the usernames listed in ADMIN_USERNAMES
+// (comma-separated, default admin@example.com). It must run after BasicAuth.
+func RequireAdmin(logger *slog.Logger) func(http.Handler) http.Handler {
+	admins := adminUsernames()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username := actor.FromContext(r.Context())
+			if !admins[username] {
+				logger.WarnContext(r.Context(), "non-admin denied", "actor", username, "path", r.URL.Path)
+				problem.Error(w, r, http.StatusForbidden, "this endpoint is restricted to administrators")
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -59,16 +114,24 @@ func ensureUsersTable(db *sql.DB) error {
 			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
 			first_name VARCHAR(255) NOT NULL,
 			last_name VARCHAR(255) NOT NULL,
-			username VARCHAR(255) UNIQUE NOT NULL,
+			username VARCHAR(255) NOT NULL,
 			password VARCHAR(255) NOT NULL,
 			account_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-			account_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			account_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			version INTEGER NOT NULL DEFAULT 1,
+			deleted_at TIMESTAMP
 		)
 	`)
 	if err != nil {
 		return err
 	}
 
+	// Usernames are unique among live users only, as in V2.1__add_soft_delete.sql.
+	_, err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS user_username_live_idx ON api.user (username) WHERE deleted_at IS NULL")
+	if err != nil {
+		return err
+	}
+
 	// Optional: Insert a default admin user for testing
 	_, err = db.Exec(`
 		INSERT INTO api.user (username, first_name, last_name, password)
@@ -78,61 +141,155 @@ func ensureUsersTable(db *sql.DB) error {
 	return err
 }
 
-func SetupRoutes(db *sql.DB) *mux.Router {
-	router := mux.NewRouter()
-
+// SetupRoutes registers the application routes on router. Protected routes
+// live on a subrouter so that middleware installed on router still sees the
+// matched route template. idem configures Idempotency-Key support for
+// protected POST routes; hub feeds the trace status streams; gql limits
+// GraphQL queries.
+func SetupRoutes(router *mux.Router, db *sql.DB, idem idempotency.Config, hub *tracestatus.Hub, gql graphqlapi.Config, logger *slog.Logger) {
 	// Health check endpoint (no BasicAuth)
 	router.HandleFunc("/health", HealthCheckHandler(db)).Methods("GET")
 
 	// User POST endpoint for creating new users (no BasicAuth)
-	userHandler := handlers.NewUserHandler(db)
+	userHandler := handlers.NewUserHandler(db, logger)
 	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
 
 	// Create a subrouter for protected routes with BasicAuth
-	authRouter := mux.NewRouter()
-	authRouter.Use(BasicAuth(db))
+	authRouter := router.PathPrefix("/").Subrouter()
+	authRouter.Use(BasicAuth(db, logger))
+	authRouter.Use(idempotency.Middleware(db, idem, logger))
 
 	// Instructor Routes
-	ir := handlers.NewInstructorHandler(db)
+	ir := handlers.NewInstructorHandler(db, logger)
 	authRouter.HandleFunc("/instructors", ir.GetInstructors).Methods("GET")
 	authRouter.HandleFunc("/instructors/{id}", ir.GetInstructorByID).Methods("GET")
 	authRouter.HandleFunc("/instructors", ir.CreateInstructor).Methods("POST")
+	authRouter.HandleFunc("/instructors:import", ir.ImportInstructors).Methods("POST")
+	authRouter.HandleFunc("/instructors:export", ir.ExportInstructors).Methods("GET")
 	authRouter.HandleFunc("/instructors/{id}", ir.UpdateInstructor).Methods("PUT")
+	authRouter.HandleFunc("/instructors/{id}", ir.PatchInstructor).Methods("PATCH")
 	authRouter.HandleFunc("/instructors/{id}", ir.DeleteInstructor).Methods("DELETE")
+	authRouter.HandleFunc("/instructors/{id}/restore", ir.RestoreInstructor).Methods("POST")
+
+	// Term Routes
+	termHandler := handlers.NewTermHandler(db, logger)
+	authRouter.HandleFunc("/terms", termHandler.GetTerms).Methods("GET")
+	authRouter.HandleFunc("/terms/{id}", termHandler.GetTermByID).Methods("GET")
+	authRouter.HandleFunc("/terms", termHandler.CreateTerm).Methods("POST")
+	authRouter.HandleFunc("/terms/{id}", termHandler.UpdateTerm).Methods("PUT")
+	authRouter.HandleFunc("/terms/{id}", termHandler.PatchTerm).Methods("PATCH")
+	authRouter.HandleFunc("/terms/{id}", termHandler.DeleteTerm).Methods("DELETE")
+	authRouter.HandleFunc("/terms/{id}/restore", termHandler.RestoreTerm).Methods("POST")
 
 	// Course Routes
-	courseHandler := handlers.NewCourseHandler(db)
+	courseHandler := handlers.NewCourseHandler(db, logger)
 	authRouter.HandleFunc("/courses", courseHandler.GetCourses).Methods("GET")
 	authRouter.HandleFunc("/courses/{id}", courseHandler.GetCourseByID).Methods("GET")
 	authRouter.HandleFunc("/courses", courseHandler.CreateCourse).Methods("POST")
+	authRouter.HandleFunc("/courses:import", courseHandler.ImportCourses).Methods("POST")
+	authRouter.HandleFunc("/courses:export", courseHandler.ExportCourses).Methods("GET")
 	authRouter.HandleFunc("/courses/{id}", courseHandler.UpdateCourse).Methods("PUT")
+	authRouter.HandleFunc("/courses/{id}", courseHandler.PatchCourse).Methods("PATCH")
 	authRouter.HandleFunc("/courses/{id}", courseHandler.DeleteCourse).Methods("DELETE")
+	authRouter.HandleFunc("/courses/{id}/restore", courseHandler.RestoreCourse).Methods("POST")
+
+	// Course Instructor Routes
+	courseInstructorHandler := handlers.NewCourseInstructorHandler(db, logger)
+	authRouter.HandleFunc("/courses/{id}/instructors", courseInstructorHandler.GetCourseInstructors).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/instructors", courseInstructorHandler.CreateCourseInstructor).Methods("POST")
+	authRouter.HandleFunc("/courses/{id}/instructors/{assignmentID}", courseInstructorHandler.GetCourseInstructorByID).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/instructors/{assignmentID}", courseInstructorHandler.PatchCourseInstructor).Methods("PATCH")
+	authRouter.HandleFunc("/courses/{id}/instructors/{assignmentID}", courseInstructorHandler.DeleteCourseInstructor).Methods("DELETE")
+	authRouter.HandleFunc("/instructors/{id}/courses", courseInstructorHandler.GetInstructorCourses).Methods("GET")
+
+	// Prerequisite Routes
+	prerequisiteHandler := handlers.NewPrerequisiteHandler(db, logger)
+	authRouter.HandleFunc("/courses/{id}/prerequisites", prerequisiteHandler.GetPrerequisites).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/prerequisites", prerequisiteHandler.CreatePrerequisite).Methods("POST")
+	authRouter.HandleFunc("/courses/{id}/prerequisites/{code}", prerequisiteHandler.DeletePrerequisite).Methods("DELETE")
+	authRouter.HandleFunc("/courses/{id}/eligibility", prerequisiteHandler.GetEligibility).Methods("GET")
+
+	// Schedule Routes
+	scheduleHandler := handlers.NewScheduleHandler(db, logger)
+	authRouter.HandleFunc("/courses/{id}/schedule", scheduleHandler.GetCourseMeetings).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/schedule", scheduleHandler.CreateMeeting).Methods("POST")
+	authRouter.HandleFunc("/courses/{id}/schedule.ics", scheduleHandler.GetCourseCalendar).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/schedule/{meetingID}", scheduleHandler.GetMeetingByID).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/schedule/{meetingID}", scheduleHandler.PatchMeeting).Methods("PATCH")
+	authRouter.HandleFunc("/courses/{id}/schedule/{meetingID}", scheduleHandler.DeleteMeeting).Methods("DELETE")
+	authRouter.HandleFunc("/instructors/{id}/schedule.ics", scheduleHandler.GetInstructorCalendar).Methods("GET")
+	authRouter.HandleFunc("/users/{id}/schedule.ics", scheduleHandler.GetStudentCalendar).Methods("GET")
+
+	// Enrollment Routes
+	enrollmentHandler := handlers.NewEnrollmentHandler(db, logger)
+	authRouter.HandleFunc("/courses/{id}/enrollments", enrollmentHandler.GetCourseEnrollments).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/enrollments", enrollmentHandler.CreateEnrollment).Methods("POST")
+	authRouter.HandleFunc("/courses/{id}/enrollments/{enrollmentID}", enrollmentHandler.GetEnrollmentByID).Methods("GET")
+	authRouter.HandleFunc("/courses/{id}/enrollments/{enrollmentID}", enrollmentHandler.PatchEnrollment).Methods("PATCH")
+	authRouter.HandleFunc("/courses/{id}/enrollments/{enrollmentID}", enrollmentHandler.DeleteEnrollment).Methods("DELETE")
+	authRouter.HandleFunc("/users/{id}/enrollments", enrollmentHandler.GetUserEnrollments).Methods("GET")
+	authRouter.HandleFunc("/instructors/{id}/roster", enrollmentHandler.GetInstructorRoster).Methods("GET")
 
 	// User Routes (excluding POST which is defined above without auth)
 	authRouter.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
 	authRouter.HandleFunc("/users/{id}", userHandler.GetUserByID).Methods("GET")
 	authRouter.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PUT")
+	authRouter.HandleFunc("/users/{id}", userHandler.PatchUser).Methods("PATCH")
 	authRouter.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
+	authRouter.HandleFunc("/users/{id}/restore", userHandler.RestoreUser).Methods("POST")
 
 	// Trace Routes
-	traceHandler := handlers.NewTraceHandler(db)
+	traceHandler := handlers.NewTraceHandler(db, logger)
 	authRouter.HandleFunc("/traces", traceHandler.GetTraces).Methods("GET")
 	authRouter.HandleFunc("/traces/{id}", traceHandler.GetTraceByID).Methods("GET")
 	authRouter.HandleFunc("/traces", traceHandler.CreateTrace).Methods("POST")
+	authRouter.HandleFunc("/traces:export", traceHandler.ExportTraces).Methods("GET")
 	authRouter.HandleFunc("/traces/{id}", traceHandler.UpdateTrace).Methods("PUT")
+	authRouter.HandleFunc("/traces/{id}", traceHandler.PatchTrace).Methods("PATCH")
 	authRouter.HandleFunc("/traces/{id}", traceHandler.DeleteTrace).Methods("DELETE")
+	authRouter.HandleFunc("/traces/{id}/restore", traceHandler.RestoreTrace).Methods("POST")
+
+	// Trace Status Streams
+	traceStatusHandler := handlers.NewTraceStatusHandler(db, hub, logger)
+	authRouter.HandleFunc("/traces/{id}/events", traceStatusHandler.StreamTrace).Methods("GET")
+	authRouter.HandleFunc("/events", traceStatusHandler.StreamUserTraces).Methods("GET")
+
+	// Search Routes
+	searchHandler := handlers.NewSearchHandler(db, logger)
+	authRouter.HandleFunc("/search", searchHandler.Search).Methods("GET")
+
+	// GraphQL Route
+	admins := adminUsernames()
+	graphqlHandler := handlers.NewGraphQLHandler(db, gql, func(username string) bool { return admins[username] }, logger)
+	authRouter.HandleFunc("/graphql", graphqlHandler.Query).Methods("GET", "POST")
 
-	// Mount the authRouter under the main router
-	router.PathPrefix("/").Handler(authRouter)
+	// Audit Routes (administrators only)
+	auditHandler := handlers.NewAuditHandler(db, logger)
+	adminRouter := authRouter.PathPrefix("/audit").Subrouter()
+	adminRouter.Use(RequireAdmin(logger))
+	adminRouter.HandleFunc("", auditHandler.GetAuditEvents).Methods("GET")
+	adminRouter.HandleFunc("/export", auditHandler.ExportAuditEvents).Methods("GET")
 
-	return router
+	// Webhook Routes (administrators only)
+	webhookHandler := handlers.NewWebhookHandler(db, logger)
+	webhookRouter := authRouter.PathPrefix("/webhooks").Subrouter()
+	webhookRouter.Use(RequireAdmin(logger))
+	webhookRouter.HandleFunc("", webhookHandler.GetWebhooks).Methods("GET")
+	webhookRouter.HandleFunc("", webhookHandler.CreateWebhook).Methods("POST")
+	webhookRouter.HandleFunc("/{id}", webhookHandler.GetWebhookByID).Methods("GET")
+	webhookRouter.HandleFunc("/{id}", webhookHandler.PatchWebhook).Methods("PATCH")
+	webhookRouter.HandleFunc("/{id}", webhookHandler.DeleteWebhook).Methods("DELETE")
+	webhookRouter.HandleFunc("/{id}/restore", webhookHandler.RestoreWebhook).Methods("POST")
+	webhookRouter.HandleFunc("/{id}/deliveries", webhookHandler.GetDeliveries).Methods("GET")
+	webhookRouter.HandleFunc("/{id}/deliveries/{deliveryID}", webhookHandler.GetDelivery).Methods("GET")
+	webhookRouter.HandleFunc("/{id}/deliveries/{deliveryID}/redeliver", webhookHandler.Redeliver).Methods("POST")
 }
 
 // HealthCheckHandler returns the health status of the application
 func HealthCheckHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := db.Ping(); err != nil {
-			http.Error(w, "Database connection failed", http.StatusServiceUnavailable)
+			problem.Error(w, r, http.StatusServiceUnavailable, "database connection failed")
 			return
 		}
 		w.WriteHeader(http.StatusOK)
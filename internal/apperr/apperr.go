@@ -0,0 +1,101 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel kinds shared by the repository, service and handler layers. Use
+// errors.Is to test for them; handlers map them to HTTP status codes.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrStale      = errors.New("precondition failed")
+)
+
+// Error pairs a sentinel kind with a message that is safe to show to API
+// clients. Cause is kept for logging and is never included in responses.
+type Error struct {
+	Kind       error
+	Message    string
+	Cause      error
+	Dependents []Dependent
+	Violations []FieldViolation
+}
+
+// FieldViolation describes one invalid field in a request body. Field uses
+// the JSON name of the field.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Dependent counts the records of one resource type that reference the
+// resource a client tried to delete.
+type Dependent struct {
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Kind, e.Cause}
+	}
+	return []error{e.Kind}
+}
+
+// NotFound reports that the resource with the given id does not exist.
+func NotFound(resource string, id any) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf("%s %v not found", resource, id)}
+}
+
+// Conflict reports that the request conflicts with the current state of a
+// resource, for example a duplicate unique key.
+func Conflict(message string, cause error) error {
+	return &Error{Kind: ErrConflict, Message: message, Cause: cause}
+}
+
+// InUse reports that the resource cannot be deleted while other records
+// reference it.
+func InUse(resource string, id any, dependents []Dependent, cause error) error {
+	return &Error{
+		Kind:       ErrConflict,
+		Message:    fmt.Sprintf("%s %v is still referenced by other records", resource, id),
+		Cause:      cause,
+		Dependents: dependents,
+	}
+}
+
+// Stale reports that the resource changed since the version the client sent
+// in If-Match.
+func Stale(resource string, id any) error {
+	return &Error{Kind: ErrStale, Message: fmt.Sprintf("%s %v has been modified; fetch it again and retry", resource, id)}
+}
+
+// Validation reports that the request is semantically invalid.
+func Validation(message string, cause error) error {
+	return &Error{Kind: ErrValidation, Message: message, Cause: cause}
+}
+
+// Invalid reports every field that failed validation.
+func Invalid(violations []FieldViolation) error {
+	return &Error{Kind: ErrValidation, Message: "request contains invalid fields", Violations: violations}
+}
+
+// Message returns the client-safe message carried by err, or "" if err is not
+// an *Error.
+func Message(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	"api-server/internal/actor"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+	"api-server/internal/requestid"
+
+	"github.com/google/uuid"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// redacted replaces the values of secret fields in recorded changes.
+const redacted = "[REDACTED]"
+
+// secretFields are JSON fields whose values never reach the audit log, nor
+// anything published through Fields. A change to them is still recorded.
+var secretFields = map[string]bool{"password": true, "secret": true}
+
+// Recorder appends events to the audit log.
+type Recorder struct {
+	ar *repository.AuditRepository
+}
+
+func NewRecorder(db *sql.DB) *Recorder {
+	return &Recorder{ar: repository.NewAuditRepository(db)}
+}
+
+// WithTx returns a Recorder that appends to the log in tx, so that an event
+// is committed with the mutation it records or not at all.
+func (rec *Recorder) WithTx(tx *sql.Tx) *Recorder {
+	return &Recorder{ar: rec.ar.WithTx(tx)}
+}
+
+// Record appends the event for a mutation of resource id on behalf of the
+// actor of ctx, from the address set with WithSourceIP, and returns it.
+// before and after are the resource as returned by the API, nil where it
+// did not exist.
+func (rec *Recorder) Record(ctx context.Context, resource string, id uuid.UUID, action string, before, after any) (*model.AuditEvent, error) {
+	event := &model.AuditEvent{
+		Actor:      actor.FromContext(ctx),
+		Resource:   resource,
+		ResourceID: id,
+		Action:     action,
+		Changes:    Diff(before, after),
+		RequestID:  requestid.FromContext(ctx),
+		SourceIP:   sourceIPFromContext(ctx),
+	}
+	if err := rec.ar.AppendAuditEvent(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Fields returns the JSON fields of v without its secret fields, which is
+// what may be published of a resource beyond the audit log. It returns nil
+// for a nil v.
+func Fields(v any) map[string]any {
+	data := fields(v)
+	for name := range data {
+		if secretFields[name] {
+			delete(data, name)
+		}
+	}
+	return data
+}
+
+// Diff compares the JSON representations of before and after and returns the
+// fields that differ. Either side may be nil.
+func Diff(before, after any) map[string]model.AuditChange {
+	b, a := fields(before), fields(after)
+	changes := map[string]model.AuditChange{}
+	for name, bv := range b {
+		if av, ok := a[name]; !ok || !reflect.DeepEqual(bv, av) {
+			changes[name] = change(name, bv, a[name])
+		}
+	}
+	for name, av := range a {
+		if _, ok := b[name]; !ok {
+			changes[name] = change(name, nil, av)
+		}
+	}
+	return changes
+}
+
+func change(name string, before, after any) model.AuditChange {
+	if secretFields[name] {
+		if before != nil {
+			before = redacted
+		}
+		if after != nil {
+			after = redacted
+		}
+	}
+	return model.AuditChange{Before: before, After: after}
+}
+
+func fields(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	json.Unmarshal(data, &m)
+	return m
+}
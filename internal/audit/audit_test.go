@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/model"
+	"api-server/internal/requestid"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+type user struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestRecordAppendsInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	id := uuid.New()
+	changes, _ := json.Marshal(map[string]model.AuditChange{
+		"username": {Before: "ada", After: "ada.l"},
+		"password": {Before: redacted, After: redacted},
+	})
+	occurred := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO api.audit_log").
+		WithArgs("registrar", "user", id, ActionUpdate, changes, "req-1", "203.0.113.7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "occurred_at"}).AddRow(7, occurred))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := actor.NewContext(context.Background(), "registrar")
+	ctx = requestid.NewContext(WithSourceIP(ctx, "203.0.113.7"), "req-1")
+	event, err := NewRecorder(db).WithTx(tx).Record(ctx, "user", id, ActionUpdate,
+		&user{Username: "ada", Password: "old password"}, &user{Username: "ada.l", Password: "new password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != 7 || !event.OccurredAt.Equal(occurred) {
+		t.Errorf("event has id %d, occurred_at %v; want the stored 7, %v", event.ID, event.OccurredAt, occurred)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecordReturnsAppendError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO api.audit_log").WillReturnError(errors.New("audit_log is full"))
+
+	_, err = NewRecorder(db).Record(context.Background(), "user", uuid.New(), ActionDelete, &user{Username: "ada"}, nil)
+	if err == nil {
+		t.Fatal("Record succeeded although the event could not be appended")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFieldsDropsSecrets(t *testing.T) {
+	got := Fields(&user{Username: "ada", Password: "hunter22"})
+	if want := map[string]any{"username": "ada"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields = %v, want %v", got, want)
+	}
+	if got := Fields(nil); got != nil {
+		t.Errorf("Fields(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+type sourceIPKey struct{}
+
+// WithSourceIP returns a copy of ctx carrying the client address ip.
+func WithSourceIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, sourceIPKey{}, ip)
+}
+
+func sourceIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(sourceIPKey{}).(string)
+	return ip
+}
+
+var trustedProxies atomic.Pointer[[]netip.Prefix]
+
+// LoadTrustedProxies reads TRUSTED_PROXIES, a comma-separated list of the
+// addresses or CIDR ranges of proxies in front of the server, such as
+// 10.0.0.0/8. By default no proxy is trusted and X-Forwarded-For is ignored.
+func LoadTrustedProxies() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, v := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(v); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP address or CIDR range", v)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// SetTrustedProxies sets the proxies whose X-Forwarded-For SourceIP and
+// ClientIP believe.
+func SetTrustedProxies(proxies []netip.Prefix) {
+	trustedProxies.Store(&proxies)
+}
+
+func trusted(addr netip.Addr) bool {
+	proxies := trustedProxies.Load()
+	if proxies == nil {
+		return false
+	}
+	for _, p := range *proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceIP returns the client address of r; see ClientIP.
+func SourceIP(r *http.Request) string {
+	return ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+}
+
+// ClientIP returns the client address of a request that came from remote, a
+// host or host:port, carrying the X-Forwarded-For values forwarded. Each
+// proxy appends the address it received the request from, so the hops are
+// walked from the right while they are trusted proxies and the first
+// untrusted one is the client. Anything left of it may be forged.
+func ClientIP(remote string, forwarded []string) string {
+	host := remote
+	if h, _, err := net.SplitHostPort(remote); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !trusted(addr.Unmap()) {
+		return host
+	}
+	var hops []string
+	for _, v := range forwarded {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A proxy we trust forwarded garbage; it is the last address we know.
+			break
+		}
+		host = hop.Unmap().String()
+		if !trusted(hop.Unmap()) {
+			break
+		}
+	}
+	return host
+}
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	SetTrustedProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	defer SetTrustedProxies(nil)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"untrusted peer's header is ignored", "203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:443", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"forged hops left of the client", "10.0.0.2:443", []string{"192.0.2.9, 203.0.113.7"}, "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:443", []string{"203.0.113.7, 10.1.1.1", "10.2.2.2"}, "203.0.113.7"},
+		{"only trusted hops", "10.0.0.2:443", []string{"10.1.1.1"}, "10.1.1.1"},
+		{"malformed hop", "10.0.0.2:443", []string{"203.0.113.7, not-an-ip"}, "10.0.0.2"},
+		{"trusted proxy without header", "10.0.0.2:443", nil, "10.0.0.2"},
+		{"IPv4-mapped IPv6", "[::ffff:10.0.0.2]:443", []string{"203.0.113.7"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClientIP(tt.remote, tt.forwarded); got != tt.want {
+				t.Errorf("ClientIP(%q, %q) = %q, want %q", tt.remote, tt.forwarded, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceIPIgnoresForwardedForByDefault(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/course", nil)
+	r.RemoteAddr = "10.0.0.2:443"
+	r.Header.Set("X-Forwarded-For", "192.0.2.9")
+	if got := SourceIP(r); got != "10.0.0.2" {
+		t.Errorf("SourceIP = %q, want the peer address 10.0.0.2", got)
+	}
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1")
+	proxies, err := LoadTrustedProxies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	if len(proxies) != len(want) || proxies[0] != want[0] || proxies[1] != want[1] {
+		t.Errorf("LoadTrustedProxies = %v, want %v", proxies, want)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/33")
+	if _, err := LoadTrustedProxies(); err == nil {
+		t.Error("LoadTrustedProxies accepted an invalid range")
+	}
+}
@@ -0,0 +1,62 @@
+// Package events publishes domain events to a message broker.
+//
+// Events are CloudEvents 1.0 in the structured JSON format. Their types are
+// <resource>.<created|updated|deleted|restored> for changes to any resource,
+// plus trace.uploaded and trace.parsed. They are written to the
+// api.event_outbox table in the transaction of the change they describe, and
+// a Relay publishes them in order once the broker acknowledges them, so
+// events written while the broker is down are published when it is back. Delivery is at least once: an event is
+// published again if the relay stops before recording that it was sent.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/model"
+	"api-server/internal/requestid"
+
+	"github.com/google/uuid"
+)
+
+// Source is the CloudEvents source of every event.
+const Source = "/api-server"
+
+// ContentType is the media type of events in the structured JSON format.
+const ContentType = "application/cloudevents+json"
+
+// Publisher sends events to a message broker. Publish returns once the
+// broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, event *model.CloudEvent) error
+	Close() error
+}
+
+// New returns an event of eventType about the resource with ID subject,
+// carrying data as JSON and attributed to the actor and request of ctx.
+func New(ctx context.Context, eventType, subject string, data any) (*model.CloudEvent, error) {
+	return NewWithID(ctx, uuid.New(), eventType, subject, data)
+}
+
+// NewWithID is New for an event whose ID is already known, such as one also
+// delivered to webhooks.
+func NewWithID(ctx context.Context, id uuid.UUID, eventType, subject string, data any) (*model.CloudEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              id.String(),
+		Source:          Source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            payload,
+		Actor:           actor.FromContext(ctx),
+		RequestID:       requestid.FromContext(ctx),
+	}, nil
+}
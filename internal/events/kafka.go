@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"api-server/internal/model"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Kafka publishes events to one topic, keyed by subject so that the events
+// of a resource stay in order on one partition.
+type Kafka struct {
+	client *kgo.Client
+}
+
+// NewKafka returns a publisher to topic on the cluster reachable through
+// the bootstrap brokers. Brokers are not contacted until the first publish.
+func NewKafka(brokers []string, topic string) (*Kafka, error) {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ClientID("api-server"),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Kafka{client: client}, nil
+}
+
+// Publish writes event and waits for every in-sync replica to have it.
+func (k *Kafka) Publish(ctx context.Context, event *model.CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	record := &kgo.Record{
+		Key:       []byte(event.Subject),
+		Value:     data,
+		Headers:   []kgo.RecordHeader{{Key: "content-type", Value: []byte(ContentType)}},
+		Timestamp: event.Time,
+	}
+	return k.client.ProduceSync(ctx, record).FirstErr()
+}
+
+func (k *Kafka) Close() error {
+	k.client.Close()
+	return nil
+}
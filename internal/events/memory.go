@@ -0,0 +1,34 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"api-server/internal/model"
+)
+
+// Memory is a Publisher that keeps events in memory, for tests.
+type Memory struct {
+	mu     sync.Mutex
+	events []model.CloudEvent
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(_ context.Context, event *model.CloudEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, *event)
+	return nil
+}
+
+// Events returns the events published so far, oldest first.
+func (m *Memory) Events() []model.CloudEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]model.CloudEvent(nil), m.events...)
+}
+
+func (m *Memory) Close() error { return nil }
@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"api-server/internal/model"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS publishes events on the subject <prefix>.<type>. The event ID is sent
+// as Nats-Msg-Id, so that a JetStream stream capturing the subjects drops
+// events the relay publishes twice.
+type NATS struct {
+	nc     *nats.Conn
+	prefix string
+}
+
+// NewNATS connects to the NATS server at url. A server that is down is
+// retried in the background; until it is reachable Publish fails.
+func NewNATS(url, prefix string) (*NATS, error) {
+	nc, err := nats.Connect(url,
+		nats.Name("api-server"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		// Fail publishes while disconnected rather than buffer them, so
+		// that the outbox keeps events until the server has them.
+		nats.ReconnectBufSize(-1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &NATS{nc: nc, prefix: prefix}, nil
+}
+
+// Publish sends event and waits for the server to process it.
+func (n *NATS) Publish(ctx context.Context, event *model.CloudEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := &nats.Msg{
+		Subject: n.prefix + "." + event.Type,
+		Data:    data,
+		Header:  nats.Header{"Content-Type": {ContentType}, nats.MsgIdHdr: {event.ID}},
+	}
+	if err := n.nc.PublishMsg(msg); err != nil {
+		return err
+	}
+	return n.nc.FlushWithContext(ctx)
+}
+
+func (n *NATS) Close() error {
+	return n.nc.Drain()
+}
@@ -0,0 +1,149 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"api-server/internal/metrics"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+)
+
+// Brokers selectable with EVENTS_BROKER.
+const (
+	BrokerNATS  = "nats"
+	BrokerKafka = "kafka"
+)
+
+const (
+	// batchSize is the number of events published per outbox transaction.
+	batchSize = 100
+	// publishTimeout bounds the wait for the broker to accept one event.
+	publishTimeout = 10 * time.Second
+)
+
+// Config selects the broker events are published to. An empty Broker
+// disables publishing; events then stay in the outbox until they are
+// purged.
+type Config struct {
+	Broker       string
+	NATSURL      string
+	NATSSubject  string
+	KafkaBrokers []string
+	KafkaTopic   string
+	Interval     time.Duration
+}
+
+// LoadConfig reads EVENTS_BROKER (nats, kafka or empty), NATS_URL (default
+// nats://127.0.0.1:4222), EVENTS_NATS_SUBJECT (default api.events),
+// KAFKA_BROKERS (comma-separated host:port, required for kafka),
+// EVENTS_KAFKA_TOPIC (default api.events) and EVENTS_RELAY_INTERVAL (default
+// 1s).
+func LoadConfig() (Config, error) {
+	cfg := Config{
+		Broker:      strings.TrimSpace(os.Getenv("EVENTS_BROKER")),
+		NATSURL:     cmp.Or(strings.TrimSpace(os.Getenv("NATS_URL")), "nats://127.0.0.1:4222"),
+		NATSSubject: cmp.Or(strings.TrimSpace(os.Getenv("EVENTS_NATS_SUBJECT")), "api.events"),
+		KafkaTopic:  cmp.Or(strings.TrimSpace(os.Getenv("EVENTS_KAFKA_TOPIC")), "api.events"),
+		Interval:    time.Second,
+	}
+	for _, addr := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.KafkaBrokers = append(cfg.KafkaBrokers, addr)
+		}
+	}
+	switch cfg.Broker {
+	case "", BrokerNATS:
+	case BrokerKafka:
+		if len(cfg.KafkaBrokers) == 0 {
+			return Config{}, fmt.Errorf("KAFKA_BROKERS is required when EVENTS_BROKER is kafka")
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid EVENTS_BROKER %q: must be nats, kafka or empty", cfg.Broker)
+	}
+	if v := strings.TrimSpace(os.Getenv("EVENTS_RELAY_INTERVAL")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Config{}, fmt.Errorf("invalid EVENTS_RELAY_INTERVAL %q: must be a positive duration such as 1s", v)
+		}
+		cfg.Interval = d
+	}
+	return cfg, nil
+}
+
+// NewPublisher returns the publisher cfg selects, or nil if publishing is
+// disabled.
+func NewPublisher(cfg Config) (Publisher, error) {
+	switch cfg.Broker {
+	case BrokerNATS:
+		return NewNATS(cfg.NATSURL, cfg.NATSSubject)
+	case BrokerKafka:
+		return NewKafka(cfg.KafkaBrokers, cfg.KafkaTopic)
+	}
+	return nil, nil
+}
+
+// Relay publishes the events in the outbox. Replicas may run one each; one
+// of them at a time publishes.
+type Relay struct {
+	or      *repository.OutboxRepository
+	pub     Publisher
+	cfg     Config
+	logger  *slog.Logger
+	failing bool
+}
+
+// NewRelay returns a relay of the outbox in db to pub.
+func NewRelay(db *sql.DB, pub Publisher, cfg Config, logger *slog.Logger) *Relay {
+	return &Relay{or: repository.NewOutboxRepository(db), pub: pub, cfg: cfg, logger: logger.With("job", "events", "broker", cfg.Broker)}
+}
+
+// Run publishes the outbox every Interval until ctx is cancelled.
+func (rl *Relay) Run(ctx context.Context) {
+	rl.logger.Info("event relay started", "interval", rl.cfg.Interval.String())
+
+	ticker := time.NewTicker(rl.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		err := rl.Drain(ctx)
+		// Report an outage once rather than on every tick.
+		switch {
+		case err != nil && !rl.failing:
+			rl.logger.ErrorContext(ctx, "event publishing failed, will retry", "error", err)
+		case err == nil && rl.failing:
+			rl.logger.InfoContext(ctx, "event publishing recovered")
+		}
+		rl.failing = err != nil
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes the outbox until it is empty or an event fails.
+func (rl *Relay) Drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := rl.or.PublishOutbox(ctx, batchSize, func(event *model.CloudEvent) error {
+			pctx, cancel := context.WithTimeout(ctx, publishTimeout)
+			defer cancel()
+			return rl.pub.Publish(pctx, event)
+		})
+		metrics.EventsPublished.WithLabelValues("published").Add(float64(n))
+		if err != nil {
+			metrics.EventsPublished.WithLabelValues("failed").Inc()
+			return err
+		}
+		if n < batchSize {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"api-server/internal/logging"
+	"api-server/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// failing is a Publisher that rejects the event with ID id and accepts the
+// rest into a Memory.
+type failing struct {
+	*Memory
+	id string
+}
+
+func (f *failing) Publish(ctx context.Context, event *model.CloudEvent) error {
+	if event.ID == f.id {
+		return errors.New("broker unavailable")
+	}
+	return f.Memory.Publish(ctx, event)
+}
+
+// outboxRows returns the outbox rows of events, numbered from 1.
+func outboxRows(t *testing.T, events ...*model.CloudEvent) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "payload"})
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.AddRow(int64(i+1), payload)
+	}
+	return rows
+}
+
+func newEvent(t *testing.T, eventType, subject string) *model.CloudEvent {
+	event, err := New(context.Background(), eventType, subject, map[string]any{"name": subject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestRelayPublishesOutboxInOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	created, updated := newEvent(t, "course.created", "course-1"), newEvent(t, "course.updated", "course-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery("SELECT id, payload FROM api.event_outbox").WithArgs(batchSize).WillReturnRows(outboxRows(t, created, updated))
+	mock.ExpectExec("UPDATE api.event_outbox SET published_at").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	pub := NewMemory()
+	relay := NewRelay(db, pub, Config{Broker: "memory"}, logging.Discard())
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := pub.Events()
+	if len(got) != 2 || got[0].ID != created.ID || got[1].ID != updated.ID {
+		t.Errorf("published %v, want %s then %s", got, created.ID, updated.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelayStopsAtRejectedEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	first, second, third := newEvent(t, "course.created", "course-1"), newEvent(t, "course.updated", "course-1"), newEvent(t, "course.deleted", "course-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery("SELECT id, payload FROM api.event_outbox").WithArgs(batchSize).WillReturnRows(outboxRows(t, first, second, third))
+	mock.ExpectExec("UPDATE api.event_outbox SET attempts = attempts \\+ 1").WithArgs("broker unavailable", int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE api.event_outbox SET published_at").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	pub := &failing{Memory: NewMemory(), id: second.ID}
+	relay := NewRelay(db, pub, Config{Broker: "memory"}, logging.Discard())
+	if err := relay.Drain(context.Background()); err == nil {
+		t.Fatal("Drain succeeded although the broker rejected an event")
+	}
+	// The third event must wait for the second rather than overtake it.
+	if got := pub.Events(); len(got) != 1 || got[0].ID != first.ID {
+		t.Errorf("published %v, want only %s", got, first.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelaySkipsWhileAnotherReplicaPublishes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	pub := NewMemory()
+	relay := NewRelay(db, pub, Config{Broker: "memory"}, logging.Discard())
+	if err := relay.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := pub.Events(); len(got) != 0 {
+		t.Errorf("published %d events without the relay lock", len(got))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
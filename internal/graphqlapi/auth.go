@@ -0,0 +1,122 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"api-server/internal/actor"
+	"api-server/internal/apperr"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+// errForbidden is returned for fields the caller may not read. The rest of
+// the result is still returned.
+var errForbidden = &queryError{message: "you are not allowed to read this field", code: "FORBIDDEN"}
+
+// queryError is an error reported in the errors of a response, with a code
+// in its extensions.
+type queryError struct {
+	message string
+	code    string
+}
+
+func (e *queryError) Error() string { return e.message }
+
+func (e *queryError) Extensions() map[string]any { return map[string]any{"code": e.code} }
+
+// unexpectedError is a failure that is not the client's. It is logged and
+// reported without detail.
+type unexpectedError struct {
+	err error
+}
+
+func (e *unexpectedError) Error() string { return e.err.Error() }
+
+func (e *unexpectedError) Unwrap() error { return e.err }
+
+// unexpected wraps a non-nil err as an unexpectedError.
+func unexpected(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &unexpectedError{err: err}
+}
+
+// viewer is the authenticated caller of a request.
+type viewer struct {
+	username string
+	admin    bool
+	ur       *repository.UserRepository
+
+	once sync.Once
+	id   uuid.UUID
+	err  error
+}
+
+// userID returns the caller's user ID, looked up once per request.
+func (v *viewer) userID() (uuid.UUID, error) {
+	v.once.Do(func() {
+		v.id, v.err = v.ur.GetUserIDByUsername(v.username)
+		if errors.Is(v.err, apperr.ErrNotFound) {
+			// Deleted since authenticating; owns nothing.
+			v.id, v.err = uuid.Nil, nil
+		}
+	})
+	return v.id, v.err
+}
+
+// owns reports whether the caller is an administrator or the user with id.
+func (v *viewer) owns(id uuid.UUID) (bool, error) {
+	if v.admin {
+		return true, nil
+	}
+	me, err := v.userID()
+	return err == nil && me != uuid.Nil && me == id, err
+}
+
+// request is the state of one GraphQL request, kept in its context.
+type request struct {
+	viewer  *viewer
+	loaders *loaders
+}
+
+type contextKey struct{}
+
+func newRequest(ctx context.Context, r *repos, isAdmin func(string) bool) context.Context {
+	username := actor.FromContext(ctx)
+	v := &viewer{username: username, admin: isAdmin(username), ur: r.ur}
+	return context.WithValue(ctx, contextKey{}, &request{viewer: v, loaders: newLoaders(r)})
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(contextKey{}).(*request)
+}
+
+// adminOnly limits a field to administrators.
+func adminOnly(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if !requestFrom(p.Context).viewer.admin {
+			return nil, errForbidden
+		}
+		return resolve(p)
+	}
+}
+
+// ownerOnly limits a field to administrators and the user that owner
+// returns for the object the field is read from.
+func ownerOnly(owner func(source any) uuid.UUID, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		ok, err := requestFrom(p.Context).viewer.owns(owner(p.Source))
+		if err != nil {
+			return nil, unexpected(err)
+		}
+		if !ok {
+			return nil, errForbidden
+		}
+		return resolve(p)
+	}
+}
@@ -0,0 +1,91 @@
+package graphqlapi
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+var userRowColumns = []string{"id", "first_name", "last_name", "username", "password", "account_created", "account_updated", "version"}
+
+func expectUserByID(mock sqlmock.Sqlmock, id uuid.UUID, username string) {
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM api.user WHERE id = $1")).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(userRowColumns).AddRow(id, "Ada", "Lovelace", username, "s3cret", now, now, 1))
+}
+
+// expectViewer expects the caller's user ID to be looked up once, returning
+// id or err.
+func expectViewer(mock sqlmock.Sqlmock, username string, id uuid.UUID, err error) {
+	q := mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM api.user WHERE username = $1")).WithArgs(username)
+	if err != nil {
+		q.WillReturnError(err)
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+func TestOwnerOnlyFields(t *testing.T) {
+	ada, other := uuid.New(), uuid.New()
+	query := `{ user(id: "` + ada.String() + `") { firstName username } }`
+	tests := []struct {
+		name     string
+		caller   string
+		admin    bool
+		viewer   func(sqlmock.Sqlmock)
+		username any
+		codes    []any
+		message  string
+	}{
+		{"self", "ada@example.com", false, func(m sqlmock.Sqlmock) { expectViewer(m, "ada@example.com", ada, nil) }, "ada@example.com", nil, ""},
+		{"administrator", "admin@example.com", true, nil, "ada@example.com", nil, ""},
+		{"other user", "bob@example.com", false, func(m sqlmock.Sqlmock) { expectViewer(m, "bob@example.com", other, nil) }, nil, []any{"FORBIDDEN"}, errForbidden.message},
+		{"deleted caller", "bob@example.com", false, func(m sqlmock.Sqlmock) { expectViewer(m, "bob@example.com", uuid.Nil, sql.ErrNoRows) }, nil, []any{"FORBIDDEN"}, errForbidden.message},
+		{"lookup fails", "bob@example.com", false, func(m sqlmock.Sqlmock) {
+			expectViewer(m, "bob@example.com", uuid.Nil, errors.New("connection refused"))
+		}, nil, []any{nil}, "An unexpected error occurred."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := execute(t, tt.caller, tt.admin, Config{MaxDepth: 8, MaxComplexity: 5000}, query, func(m sqlmock.Sqlmock) {
+				expectUserByID(m, ada, "ada@example.com")
+				if tt.viewer != nil {
+					tt.viewer(m)
+				}
+			})
+			// The rest of the user is returned either way.
+			want := map[string]any{"user": map[string]any{"firstName": "Ada", "username": tt.username}}
+			if !reflect.DeepEqual(res.Data, want) {
+				t.Errorf("data = %v, want %v", res.Data, want)
+			}
+			if codes := errorCodes(res); !reflect.DeepEqual(codes, tt.codes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.codes)
+			}
+			if len(res.Errors) > 0 {
+				if res.Errors[0].Message != tt.message {
+					t.Errorf("message = %q, want %q", res.Errors[0].Message, tt.message)
+				}
+				if path := res.Errors[0].Path; !reflect.DeepEqual(path, []any{"user", "username"}) {
+					t.Errorf("error path = %v, want user.username", path)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminOnlyFields(t *testing.T) {
+	res := execute(t, "bob@example.com", false, Config{MaxDepth: 8, MaxComplexity: 5000}, `{ users { id } traces { id } }`, nil)
+	if want := map[string]any{"users": nil, "traces": nil}; !reflect.DeepEqual(res.Data, want) {
+		t.Errorf("data = %v, want %v", res.Data, want)
+	}
+	if codes := errorCodes(res); !reflect.DeepEqual(codes, []any{"FORBIDDEN", "FORBIDDEN"}) {
+		t.Errorf("error codes = %v, want two FORBIDDEN", codes)
+	}
+}
@@ -0,0 +1,174 @@
+// Package graphqlapi answers GraphQL queries over courses, instructors,
+// users and traces, so that a client can read a course with its
+// instructors, owner and the owner's traces in one request.
+//
+// Related objects are batched per request: all the owners of a list of
+// courses, for example, are read with one query. Queries are rejected
+// before they run if they are nested deeper or are estimated to be more
+// complex than Config allows. Callers must be authenticated; some fields
+// are further limited to their owner and administrators, see newSchema.
+package graphqlapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"api-server/internal/repository"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Config limits the queries that are run.
+type Config struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// LoadConfig reads GRAPHQL_MAX_DEPTH (default 8), the deepest nesting of
+// fields, and GRAPHQL_MAX_COMPLEXITY (default 5000), the highest estimated
+// number of fields in a result, where every list is assumed to hold 10
+// items.
+func LoadConfig() (Config, error) {
+	cfg := Config{MaxDepth: 8, MaxComplexity: 5000}
+	for _, v := range []struct {
+		name string
+		dst  *int
+	}{
+		{"GRAPHQL_MAX_DEPTH", &cfg.MaxDepth},
+		{"GRAPHQL_MAX_COMPLEXITY", &cfg.MaxComplexity},
+	} {
+		s := strings.TrimSpace(os.Getenv(v.name))
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid %s %q: must be a positive integer", v.name, s)
+		}
+		*v.dst = n
+	}
+	return cfg, nil
+}
+
+// Request is a GraphQL request as clients send it.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	// Extensions is accepted for compatibility with clients and ignored.
+	Extensions map[string]any `json:"extensions"`
+}
+
+// Executor runs GraphQL queries against the database.
+type Executor struct {
+	schema  graphql.Schema
+	repos   *repos
+	cfg     Config
+	isAdmin func(username string) bool
+	logger  *slog.Logger
+}
+
+// New returns an executor reading from db. isAdmin reports whether an
+// authenticated username belongs to an administrator.
+func New(db *sql.DB, cfg Config, isAdmin func(username string) bool, logger *slog.Logger) *Executor {
+	r := &repos{
+		cr:  repository.NewCourseRepository(db),
+		cir: repository.NewCourseInstructorRepository(db),
+		ir:  repository.NewInstructorRepository(db),
+		ur:  repository.NewUserRepository(db),
+		tr:  repository.NewTraceRepository(db),
+	}
+	schema, err := newSchema(r)
+	if err != nil {
+		panic(fmt.Sprintf("graphqlapi: invalid schema: %v", err))
+	}
+	return &Executor{schema: schema, repos: r, cfg: cfg, isAdmin: isAdmin, logger: logger.With("component", "graphql")}
+}
+
+// Execute runs req on behalf of the actor in ctx. Errors are reported in
+// the result; failures that are not the client's are logged and reported
+// without detail.
+func (e *Executor) Execute(ctx context.Context, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if vr := graphql.ValidateDocument(&e.schema, doc, nil); !vr.IsValid {
+		return &graphql.Result{Errors: vr.Errors}
+	}
+	op, err := operation(doc, req.OperationName)
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if err := checkLimits(&e.schema, e.cfg, doc, op); err != nil {
+		e.logger.InfoContext(ctx, "query rejected", "operation", req.OperationName, "reason", err)
+		// A located error keeps the code; FormatErrors drops the extensions
+		// of plain errors.
+		rejected := gqlerrors.NewLocatedError(&queryError{message: err.Error(), code: "QUERY_TOO_COMPLEX"}, []ast.Node{op})
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(rejected)}
+	}
+
+	res := graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       newRequest(ctx, e.repos, e.isAdmin),
+	})
+	for i, fe := range res.Errors {
+		res.Errors[i] = e.redact(ctx, fe)
+	}
+	return res
+}
+
+// redact hides the cause of an unexpected error from the client.
+func (e *Executor) redact(ctx context.Context, fe gqlerrors.FormattedError) gqlerrors.FormattedError {
+	err := fe.OriginalError()
+	// gqlerrors.Error does not implement Unwrap.
+	if located, ok := err.(*gqlerrors.Error); ok {
+		err = located.OriginalError
+	}
+	var ue *unexpectedError
+	if !errors.As(err, &ue) {
+		return fe
+	}
+	e.logger.ErrorContext(ctx, "query failed", "path", fe.Path, "error", ue.err)
+	fe.Message = "An unexpected error occurred."
+	return fe
+}
+
+// operation returns the operation of doc to run.
+func operation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil, errors.New("operationName is required when the query contains several operations")
+			}
+			found = op
+		} else if op.Name != nil && op.Name.Value == name {
+			return op, nil
+		}
+	}
+	if found == nil {
+		if name != "" {
+			return nil, fmt.Errorf("unknown operation %q", name)
+		}
+		return nil, errors.New("the query contains no operation")
+	}
+	return found, nil
+}
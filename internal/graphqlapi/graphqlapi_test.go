@@ -0,0 +1,50 @@
+package graphqlapi
+
+import (
+	"context"
+	"testing"
+
+	"api-server/internal/actor"
+	"api-server/internal/logging"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/graphql-go/graphql"
+)
+
+// execute runs query as username, who is an administrator if admin is set,
+// against the database mock sets up.
+func execute(t *testing.T, username string, admin bool, cfg Config, query string, expect func(sqlmock.Sqlmock)) *graphql.Result {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if expect != nil {
+		expect(mock)
+	}
+
+	e := New(db, cfg, func(string) bool { return admin }, logging.Discard())
+	res := e.Execute(actor.NewContext(context.Background(), username), Request{Query: query})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	return res
+}
+
+// errorCodes returns the extension code of each error in res.
+func errorCodes(res *graphql.Result) []any {
+	var codes []any
+	for _, e := range res.Errors {
+		codes = append(codes, e.Extensions["code"])
+	}
+	return codes
+}
+
+func TestExecuteRejectsExpensiveQuery(t *testing.T) {
+	// Rejected before any resolver reads the database.
+	res := execute(t, "ada", true, Config{MaxDepth: 8, MaxComplexity: 100}, `{ courses { instructors { instructor { name } } } }`, nil)
+	if res.Data != nil || len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != "QUERY_TOO_COMPLEX" {
+		t.Errorf("result = %+v, want a QUERY_TOO_COMPLEX error", res)
+	}
+}
@@ -0,0 +1,117 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// listFactor is the number of items a list field is assumed to return when
+// estimating the cost of a query.
+const listFactor = 10
+
+// cost is the estimated size of a query's result.
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// analyzer estimates the cost of a validated operation. Every field counts
+// one and the fields selected under a list count listFactor times.
+// Introspection fields read only the schema and are free.
+type analyzer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+}
+
+// checkLimits returns an error if the query op in doc is deeper or more
+// complex than cfg allows.
+func checkLimits(schema *graphql.Schema, cfg Config, doc *ast.Document, op *ast.OperationDefinition) error {
+	a := &analyzer{schema: schema, fragments: map[string]*ast.FragmentDefinition{}}
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[frag.Name.Value] = frag
+		}
+	}
+	c := a.selectionSet(schema.QueryType(), op.SelectionSet)
+	if c.depth > cfg.MaxDepth {
+		return fmt.Errorf("query is nested %d levels deep; the limit is %d", c.depth, cfg.MaxDepth)
+	}
+	if c.complexity > cfg.MaxComplexity {
+		return fmt.Errorf("query complexity is %d; the limit is %d", c.complexity, cfg.MaxComplexity)
+	}
+	return nil
+}
+
+func (a *analyzer) selectionSet(parent *graphql.Object, set *ast.SelectionSet) cost {
+	var total cost
+	if set == nil {
+		return total
+	}
+	for _, sel := range set.Selections {
+		var c cost
+		switch sel := sel.(type) {
+		case *ast.Field:
+			c = a.field(parent, sel)
+		case *ast.InlineFragment:
+			c = a.selectionSet(a.typeCondition(parent, sel.TypeCondition), sel.SelectionSet)
+		case *ast.FragmentSpread:
+			if frag, ok := a.fragments[sel.Name.Value]; ok {
+				c = a.selectionSet(a.typeCondition(parent, frag.TypeCondition), frag.SelectionSet)
+			}
+		}
+		total.depth = max(total.depth, c.depth)
+		total.complexity += c.complexity
+	}
+	return total
+}
+
+func (a *analyzer) field(parent *graphql.Object, f *ast.Field) cost {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return cost{}
+	}
+	var (
+		child *graphql.Object
+		list  bool
+	)
+	if parent != nil {
+		if def, ok := parent.Fields()[f.Name.Value]; ok {
+			child, list = unwrap(def.Type)
+		}
+	}
+	c := a.selectionSet(child, f.SelectionSet)
+	if list {
+		c.complexity *= listFactor
+	}
+	return cost{depth: c.depth + 1, complexity: c.complexity + 1}
+}
+
+// typeCondition returns the object type a fragment applies to, or parent
+// if it has no condition.
+func (a *analyzer) typeCondition(parent *graphql.Object, cond *ast.Named) *graphql.Object {
+	if cond == nil {
+		return parent
+	}
+	obj, _ := a.schema.Type(cond.Name.Value).(*graphql.Object)
+	return obj
+}
+
+// unwrap returns the object type t holds, if any, and whether t is a list.
+func unwrap(t graphql.Output) (*graphql.Object, bool) {
+	list := false
+	for {
+		switch tt := t.(type) {
+		case *graphql.NonNull:
+			t = tt.OfType
+		case *graphql.List:
+			list = true
+			t = tt.OfType
+		case *graphql.Object:
+			return tt, list
+		default:
+			return nil, list
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package graphqlapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestCheckLimits(t *testing.T) {
+	schema, err := newSchema(&repos{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		query      string
+		depth      int
+		complexity int
+	}{
+		{"scalar", `{ me { firstName } }`, 2, 2},
+		// The list's fields count ten times: 1 + 10*(1+1).
+		{"list", `{ courses { code name } }`, 2, 21},
+		// 1 + 10*(1 + 1 + 10*(1+1)).
+		{"nested lists", `{ courses { code instructors { role id } } }`, 3, 221},
+		{"fragment", `{ course(id: "1") { ...f } } fragment f on Course { owner { firstName } }`, 3, 3},
+		{"inline fragment", `{ trace(id: "1") { ... on Trace { user { traces { fileName } } } } }`, 4, 13},
+		{"introspection is free", `{ __schema { types { name fields { name } } } me { id } }`, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			op := doc.Definitions[0].(*ast.OperationDefinition)
+			if err := checkLimits(&schema, Config{MaxDepth: tt.depth, MaxComplexity: tt.complexity}, doc, op); err != nil {
+				t.Errorf("query within limits rejected: %v", err)
+			}
+			err = checkLimits(&schema, Config{MaxDepth: tt.depth - 1, MaxComplexity: tt.complexity}, doc, op)
+			if err == nil || !strings.Contains(err.Error(), "levels deep") {
+				t.Errorf("query deeper than the limit: %v", err)
+			}
+			err = checkLimits(&schema, Config{MaxDepth: tt.depth, MaxComplexity: tt.complexity - 1}, doc, op)
+			if err == nil || !strings.Contains(err.Error(), "complexity") {
+				t.Errorf("query more complex than the limit: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// loader batches the keys requested while one level of a query is resolved
+// and fetches them with a single call when the first of them is needed.
+// Resolvers return thunks, which graphql-go calls breadth first, so every
+// key at a level is requested before any is fetched. Results are cached for
+// the rest of the request.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending map[K]struct{}
+	done    map[K]loaded[V]
+}
+
+type loaded[V any] struct {
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, pending: map[K]struct{}{}, done: map[K]loaded[V]{}}
+}
+
+// load requests key and returns a thunk that returns its value, the zero
+// value if there is none.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if _, ok := l.done[key]; !ok {
+		l.pending[key] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if r, ok := l.done[key]; ok {
+			return r.value, r.err
+		}
+		keys := make([]K, 0, len(l.pending))
+		for k := range l.pending {
+			keys = append(keys, k)
+		}
+		clear(l.pending)
+		values, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			l.done[k] = loaded[V]{value: values[k], err: err}
+		}
+		r := l.done[key]
+		return r.value, r.err
+	}
+}
+
+// loaders holds the loaders of one request, each fetching through a
+// repository.
+type loaders struct {
+	users             *loader[uuid.UUID, *model.User]
+	instructors       *loader[uuid.UUID, *model.Instructor]
+	courseInstructors *loader[uuid.UUID, []model.CourseInstructor]
+	userTraces        *loader[uuid.UUID, []model.Trace]
+}
+
+func newLoaders(r *repos) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*model.User, error) {
+			users, err := r.ur.GetUsersByIDs(ctx, ids)
+			return byID(users, err, func(u *model.User) uuid.UUID { return u.ID })
+		}),
+		instructors: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*model.Instructor, error) {
+			instructors, err := r.ir.GetInstructorsByIDs(ctx, ids)
+			return byID(instructors, err, func(i *model.Instructor) uuid.UUID { return i.ID })
+		}),
+		courseInstructors: newLoader(func(ctx context.Context, courseIDs []uuid.UUID) (map[uuid.UUID][]model.CourseInstructor, error) {
+			assignments, err := r.cir.GetCourseInstructorsByCourseIDs(ctx, courseIDs)
+			return groupBy(assignments, err, func(ci *model.CourseInstructor) uuid.UUID { return ci.CourseID })
+		}),
+		userTraces: newLoader(func(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]model.Trace, error) {
+			traces, err := r.tr.GetTracesByUserIDs(ctx, userIDs)
+			return groupBy(traces, err, func(t *model.Trace) uuid.UUID { return t.UserID })
+		}),
+	}
+}
+
+// repos are the repositories the resolvers read from.
+type repos struct {
+	cr  *repository.CourseRepository
+	cir *repository.CourseInstructorRepository
+	ir  *repository.InstructorRepository
+	ur  *repository.UserRepository
+	tr  *repository.TraceRepository
+}
+
+func byID[T any](rows []T, err error, key func(*T) uuid.UUID) (map[uuid.UUID]*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[uuid.UUID]*T, len(rows))
+	for i := range rows {
+		m[key(&rows[i])] = &rows[i]
+	}
+	return m, nil
+}
+
+func groupBy[T any](rows []T, err error, key func(*T) uuid.UUID) (map[uuid.UUID][]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	m := map[uuid.UUID][]T{}
+	for i := range rows {
+		k := key(&rows[i])
+		m[k] = append(m[k], rows[i])
+	}
+	return m, nil
+}
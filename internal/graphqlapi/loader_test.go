@@ -0,0 +1,103 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// countingLoader returns a loader of the squares of ints, failing for
+// negative ones, and the batches it fetched.
+func countingLoader() (*loader[int, int], *[][]int) {
+	var batches [][]int
+	l := newLoader(func(_ context.Context, keys []int) (map[int]int, error) {
+		slices.Sort(keys)
+		batches = append(batches, keys)
+		if keys[0] < 0 {
+			return nil, errors.New("negative key")
+		}
+		values := map[int]int{}
+		for _, k := range keys {
+			if k != 0 {
+				values[k] = k * k
+			}
+		}
+		return values, nil
+	})
+	return l, &batches
+}
+
+func TestLoaderBatches(t *testing.T) {
+	ctx := context.Background()
+	l, batches := countingLoader()
+	two, three, zero := l.load(ctx, 2), l.load(ctx, 3), l.load(ctx, 0)
+	again := l.load(ctx, 2)
+
+	for _, tt := range []struct {
+		thunk func() (int, error)
+		want  int
+	}{{three, 9}, {two, 4}, {again, 4}, {zero, 0}} {
+		if got, err := tt.thunk(); err != nil || got != tt.want {
+			t.Errorf("thunk = %d, %v; want %d", got, err, tt.want)
+		}
+	}
+	// Keys requested after a fetch form a new batch; cached keys are not
+	// fetched again.
+	if got, err := l.load(ctx, 4)(); err != nil || got != 16 {
+		t.Errorf("load(4) = %d, %v; want 16", got, err)
+	}
+	if got, err := l.load(ctx, 3)(); err != nil || got != 9 {
+		t.Errorf("cached load(3) = %d, %v; want 9", got, err)
+	}
+	if want := [][]int{{0, 2, 3}, {4}}; !reflect.DeepEqual(*batches, want) {
+		t.Errorf("batches = %v, want %v", *batches, want)
+	}
+}
+
+func TestLoaderSharesError(t *testing.T) {
+	ctx := context.Background()
+	l, batches := countingLoader()
+	a, b := l.load(ctx, -1), l.load(ctx, 5)
+	if _, err := a(); err == nil {
+		t.Error("failed fetch returned no error")
+	}
+	if _, err := b(); err == nil {
+		t.Error("key batched with a failed fetch returned no error")
+	}
+	if len(*batches) != 1 {
+		t.Errorf("fetched %d batches, want 1", len(*batches))
+	}
+}
+
+func TestExecuteBatchesRelatedObjects(t *testing.T) {
+	ada, bob := uuid.New(), uuid.New()
+	now := time.Now()
+	res := execute(t, "admin@example.com", true, Config{MaxDepth: 8, MaxComplexity: 5000}, `{ users { firstName traces { fileName } } }`, func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("FROM api.user WHERE deleted_at IS NULL")).
+			WillReturnRows(sqlmock.NewRows(userRowColumns).
+				AddRow(ada, "Ada", "Lovelace", "ada@example.com", "s3cret", now, now, 1).
+				AddRow(bob, "Bob", "Kahn", "bob@example.com", "s3cret", now, now, 1))
+		// The traces of both users are read with one query.
+		mock.ExpectQuery(regexp.QuoteMeta("FROM api.trace WHERE user_id = ANY($1::uuid[])")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "file_name", "date_created", "bucket_path", "status", "status_error", "version"}).
+				AddRow(uuid.New(), ada, "notes.pdf", now, "traces/notes.pdf", "ready", "", 1).
+				AddRow(uuid.New(), ada, "slides.pdf", now, "traces/slides.pdf", "ready", "", 1))
+	})
+	if len(res.Errors) > 0 {
+		t.Fatalf("errors: %v", res.Errors)
+	}
+	want := map[string]any{"users": []any{
+		map[string]any{"firstName": "Ada", "traces": []any{map[string]any{"fileName": "notes.pdf"}, map[string]any{"fileName": "slides.pdf"}}},
+		map[string]any{"firstName": "Bob", "traces": []any{}},
+	}}
+	if !reflect.DeepEqual(res.Data, want) {
+		t.Errorf("data = %v, want %v", res.Data, want)
+	}
+}
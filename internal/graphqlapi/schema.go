@@ -0,0 +1,299 @@
+package graphqlapi
+
+import (
+	"errors"
+	"slices"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+// newSchema builds the schema over the models in r. Related objects are
+// read through the request's loaders, so that a list of courses with their
+// owners costs one query for the courses and one for all the owners.
+//
+// Usernames and a user's traces are visible only to that user and to
+// administrators, as are the storage path and failure reason of a trace.
+// Only administrators may list every user or trace.
+func newSchema(r *repos) (graphql.Schema, error) {
+	traceType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Trace",
+		Description: "A PDF uploaded by a user and the state of its processing.",
+		Fields: graphql.Fields{
+			"id":          idField(func(t *model.Trace) uuid.UUID { return t.ID }),
+			"fileName":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"dateCreated": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"status":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "One of uploaded, scanning, parsing, ready and failed."},
+			"statusError": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Why processing failed. Visible to the owner and administrators.",
+				Resolve: ownerOnly(traceOwner, func(p graphql.ResolveParams) (any, error) {
+					if reason := p.Source.(*model.Trace).StatusError; reason != "" {
+						return reason, nil
+					}
+					return nil, nil
+				}),
+			},
+			"bucketPath": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Where the file is stored. Visible to the owner and administrators.",
+				Resolve:     ownerOnly(traceOwner, func(p graphql.ResolveParams) (any, error) { return p.Source.(*model.Trace).BucketPath, nil }),
+			},
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":             idField(func(u *model.User) uuid.UUID { return u.ID }),
+			"firstName":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"lastName":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"accountCreated": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"accountUpdated": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"username": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The user's email address. Visible to the user and administrators.",
+				Resolve:     ownerOnly(userSelf, func(p graphql.ResolveParams) (any, error) { return p.Source.(*model.User).Username, nil }),
+			},
+			"traces": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(traceType)),
+				Description: "The user's traces, newest first. Visible to the user and administrators.",
+				Resolve: ownerOnly(userSelf, func(p graphql.ResolveParams) (any, error) {
+					return list(requestFrom(p.Context).loaders.userTraces.load(p.Context, p.Source.(*model.User).ID)), nil
+				}),
+			},
+		},
+	})
+
+	traceType.AddFieldConfig("user", &graphql.Field{
+		Type:        userType,
+		Description: "The user who uploaded the trace.",
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return object(requestFrom(p.Context).loaders.users.load(p.Context, p.Source.(*model.Trace).UserID)), nil
+		},
+	})
+
+	instructorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Instructor",
+		Fields: graphql.Fields{
+			"id":          idField(func(i *model.Instructor) uuid.UUID { return i.ID }),
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"dateCreated": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"user": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return object(requestFrom(p.Context).loaders.users.load(p.Context, p.Source.(*model.Instructor).UserID)), nil
+				},
+			},
+		},
+	})
+
+	assignmentType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CourseInstructor",
+		Description: "The assignment of an instructor to a course.",
+		Fields: graphql.Fields{
+			"id":        idField(func(ci *model.CourseInstructor) uuid.UUID { return ci.ID }),
+			"role":      &graphql.Field{Type: graphql.NewNonNull(graphql.String), Description: "One of lead, co-instructor and ta."},
+			"startDate": &graphql.Field{Type: graphql.String},
+			"endDate":   &graphql.Field{Type: graphql.String},
+			"instructor": &graphql.Field{
+				Type: instructorType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return object(requestFrom(p.Context).loaders.instructors.load(p.Context, p.Source.(*model.CourseInstructor).InstructorID)), nil
+				},
+			},
+		},
+	})
+
+	courseType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Course",
+		Description: "A course offering in a term.",
+		Fields: graphql.Fields{
+			"id":              idField(func(c *model.Course) uuid.UUID { return c.ID }),
+			"code":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"section":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"semesterTerm":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"semesterYear":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"manufacturer":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"creditHours":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"capacity":        &graphql.Field{Type: graphql.Int, Description: "Seats for enrolled students; null means unlimited."},
+			"dateAdded":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"dateLastUpdated": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"owner": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return object(requestFrom(p.Context).loaders.users.load(p.Context, p.Source.(*model.Course).OwnerUserID)), nil
+				},
+			},
+			"instructors": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(assignmentType))),
+				Description: "The course's instructors, lead first.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return list(requestFrom(p.Context).loaders.courseInstructors.load(p.Context, p.Source.(*model.Course).ID)), nil
+				},
+			},
+		},
+	})
+
+	idArg := graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}}
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:        userType,
+				Description: "The authenticated user.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, err := requestFrom(p.Context).viewer.userID()
+					if err != nil || id == uuid.Nil {
+						return nil, unexpected(err)
+					}
+					return object(requestFrom(p.Context).loaders.users.load(p.Context, id)), nil
+				},
+			},
+			"course": &graphql.Field{
+				Type: courseType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return byIDArg(p, r.cr.GetCourseByID)
+				},
+			},
+			"courses": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(courseType))),
+				Description: "Live courses, optionally only those of one term or of terms with a status.",
+				Args: graphql.FieldConfigArgument{
+					"termId":     &graphql.ArgumentConfig{Type: graphql.ID},
+					"termStatus": &graphql.ArgumentConfig{Type: graphql.String, Description: "One of upcoming, current and archived."},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					var filter model.CourseFilter
+					if v, ok := p.Args["termId"].(string); ok {
+						id, err := uuid.Parse(v)
+						if err != nil {
+							return nil, invalidArg("termId must be a valid UUID")
+						}
+						filter.TermID = id
+					}
+					filter.TermStatus, _ = p.Args["termStatus"].(string)
+					if filter.TermStatus != "" && !slices.Contains([]string{model.TermUpcoming, model.TermCurrent, model.TermArchived}, filter.TermStatus) {
+						return nil, invalidArg("termStatus must be one of upcoming, current, archived")
+					}
+					return pointers(r.cr.GetAllCourses(filter))
+				},
+			},
+			"instructor": &graphql.Field{
+				Type: instructorType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return byIDArg(p, r.ir.GetInstructorByID)
+				},
+			},
+			"instructors": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(instructorType))),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return pointers(r.ir.GetAllInstructors())
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return byIDArg(p, r.ur.GetUserByID)
+				},
+			},
+			"users": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(userType)),
+				Description: "Every live user. Administrators only.",
+				Resolve: adminOnly(func(p graphql.ResolveParams) (any, error) {
+					return pointers(r.ur.GetAllUsers())
+				}),
+			},
+			"trace": &graphql.Field{
+				Type: traceType,
+				Args: idArg,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return byIDArg(p, r.tr.GetTraceByID)
+				},
+			},
+			"traces": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(traceType)),
+				Description: "Every live trace. Administrators only; others use me.traces.",
+				Resolve: adminOnly(func(p graphql.ResolveParams) (any, error) {
+					return pointers(r.tr.GetAllTraces())
+				}),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func traceOwner(source any) uuid.UUID { return source.(*model.Trace).UserID }
+
+func userSelf(source any) uuid.UUID { return source.(*model.User).ID }
+
+// idField resolves an ID from the model T.
+func idField[T any](id func(*T) uuid.UUID) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.ID),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return id(p.Source.(*T)).String(), nil
+		},
+	}
+}
+
+// byIDArg returns the object with the id argument, or null if there is
+// none.
+func byIDArg[T any](p graphql.ResolveParams, get func(uuid.UUID) (*T, error)) (any, error) {
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return nil, invalidArg("id must be a valid UUID")
+	}
+	v, err := get(id)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	return v, nil
+}
+
+// pointers returns rows as pointers, which the field resolvers expect.
+func pointers[T any](rows []T, err error) (any, error) {
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	ptrs := make([]*T, len(rows))
+	for i := range rows {
+		ptrs[i] = &rows[i]
+	}
+	return ptrs, nil
+}
+
+// object adapts a loader thunk to graphql-go, keeping a missing object
+// null.
+func object[T any](thunk func() (*T, error)) func() (any, error) {
+	return func() (any, error) {
+		v, err := thunk()
+		if err != nil || v == nil {
+			return nil, unexpected(err)
+		}
+		return v, nil
+	}
+}
+
+// list adapts a loader thunk of rows to graphql-go.
+func list[T any](thunk func() ([]T, error)) func() (any, error) {
+	return func() (any, error) {
+		return pointers(thunk())
+	}
+}
+
+func invalidArg(message string) error {
+	return &queryError{message: message, code: "BAD_USER_INPUT"}
+}
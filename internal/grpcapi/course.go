@@ -0,0 +1,152 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type courseServer struct {
+	pb.UnimplementedCourseServiceServer
+	cs     *service.CourseService
+	logger *slog.Logger
+}
+
+func (s *courseServer) ListCourses(ctx context.Context, req *pb.ListCoursesRequest) (*pb.ListCoursesResponse, error) {
+	filter, err := courseFilter(req)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_ListCourses_FullMethodName, err)
+	}
+	courses, err := s.cs.GetAllCourses(filter)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_ListCourses_FullMethodName, err)
+	}
+	resp := &pb.ListCoursesResponse{Courses: make([]*pb.Course, len(courses))}
+	for i := range courses {
+		resp.Courses[i] = coursePB(&courses[i])
+	}
+	return resp, nil
+}
+
+func (s *courseServer) GetCourse(ctx context.Context, req *pb.GetCourseRequest) (*pb.Course, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_GetCourse_FullMethodName, err)
+	}
+	course, err := s.cs.GetCourseByID(id)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_GetCourse_FullMethodName, err)
+	}
+	return coursePB(course), nil
+}
+
+func (s *courseServer) CreateCourse(ctx context.Context, req *pb.CreateCourseRequest) (*pb.Course, error) {
+	course, err := courseModel(req.GetCourse())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_CreateCourse_FullMethodName, err)
+	}
+	after, err := s.cs.CreateCourse(ctx, course)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_CreateCourse_FullMethodName, err)
+	}
+	return coursePB(stored(after, course)), nil
+}
+
+func (s *courseServer) UpdateCourse(ctx context.Context, req *pb.UpdateCourseRequest) (*pb.Course, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_UpdateCourse_FullMethodName, err)
+	}
+	course, err := courseModel(req.GetCourse())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_UpdateCourse_FullMethodName, err)
+	}
+	after, err := s.cs.UpdateCourse(ctx, id, course, ifMatch(req.ExpectedVersion))
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_UpdateCourse_FullMethodName, err)
+	}
+	return coursePB(stored(after, course)), nil
+}
+
+func (s *courseServer) DeleteCourse(ctx context.Context, req *pb.DeleteCourseRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_DeleteCourse_FullMethodName, err)
+	}
+	if err := s.cs.DeleteCourse(ctx, id, ifMatch(req.ExpectedVersion)); err != nil {
+		return nil, toStatus(ctx, s.logger, pb.CourseService_DeleteCourse_FullMethodName, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// courseFilter checks a listing request as the REST API checks its query
+// parameters.
+func courseFilter(req *pb.ListCoursesRequest) (model.CourseFilter, error) {
+	var filter model.CourseFilter
+	if req.GetTermId() != "" {
+		ids, err := parseIDs(map[string]string{"term_id": req.GetTermId()})
+		if err != nil {
+			return filter, err
+		}
+		filter.TermID = ids["term_id"]
+	}
+	filter.TermStatus = req.GetTermStatus()
+	termStatuses := []string{model.TermUpcoming, model.TermCurrent, model.TermArchived}
+	if filter.TermStatus != "" && !slices.Contains(termStatuses, filter.TermStatus) {
+		return filter, apperr.Invalid([]apperr.FieldViolation{{Field: "term_status", Message: "must be one of upcoming, current, archived"}})
+	}
+	return filter, nil
+}
+
+func courseModel(c *pb.Course) (*model.Course, error) {
+	ids, err := parseIDs(map[string]string{"owner_user_id": c.GetOwnerUserId(), "term_id": c.GetTermId()})
+	if err != nil {
+		return nil, err
+	}
+	course := &model.Course{
+		Code:         c.GetCode(),
+		Name:         c.GetName(),
+		Section:      c.GetSection(),
+		Description:  c.GetDescription(),
+		Manufacturer: c.GetManufacturer(),
+		CreditHours:  int(c.GetCreditHours()),
+		OwnerUserID:  ids["owner_user_id"],
+		TermID:       ids["term_id"],
+	}
+	if c.Capacity != nil {
+		capacity := int(c.GetCapacity())
+		course.Capacity = &capacity
+	}
+	return course, nil
+}
+
+func coursePB(c *model.Course) *pb.Course {
+	course := &pb.Course{
+		Id:              c.ID.String(),
+		Code:            c.Code,
+		Name:            c.Name,
+		Section:         c.Section,
+		Description:     c.Description,
+		SemesterTerm:    c.SemesterTerm,
+		Manufacturer:    c.Manufacturer,
+		CreditHours:     int32(c.CreditHours),
+		SemesterYear:    int32(c.SemesterYear),
+		DateAdded:       c.DateAdded,
+		DateLastUpdated: c.DateLastUpdated,
+		OwnerUserId:     c.OwnerUserID.String(),
+		TermId:          c.TermID.String(),
+		Version:         c.Version,
+	}
+	if c.Capacity != nil {
+		capacity := int32(*c.Capacity)
+		course.Capacity = &capacity
+	}
+	return course
+}
@@ -0,0 +1,104 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"api-server/internal/apperr"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps err to a gRPC status the way problem.WriteError maps it to
+// an HTTP status. Invalid fields are listed in a BadRequest detail.
+func toStatus(ctx context.Context, logger *slog.Logger, method string, err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, apperr.ErrConflict):
+		code = codes.FailedPrecondition
+	case errors.Is(err, apperr.ErrValidation):
+		code = codes.InvalidArgument
+	case errors.Is(err, apperr.ErrStale):
+		code = codes.Aborted
+	case errors.Is(err, service.ErrStorage):
+		logger.ErrorContext(ctx, "call failed", "method", method, "error", err)
+		return status.Error(codes.Unavailable, "Failed to store the uploaded file.")
+	}
+
+	if code == codes.Internal {
+		logger.ErrorContext(ctx, "call failed", "method", method, "error", err)
+		return status.Error(code, "An unexpected error occurred.")
+	}
+
+	logger.DebugContext(ctx, "call rejected", "method", method, "code", code.String(), "error", err)
+	st := status.New(code, apperr.Message(err))
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) && len(appErr.Violations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range appErr.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Message})
+		}
+		if detailed, derr := st.WithDetails(br); derr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+// parseIDs parses the UUID fields of a request, named by their proto field
+// names, and reports every malformed one at once. Empty fields parse as
+// uuid.Nil so that validation can report them as missing.
+func parseIDs(fields map[string]string) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID, len(fields))
+	var violations []apperr.FieldViolation
+	for name, v := range fields {
+		if v == "" {
+			ids[name] = uuid.Nil
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			violations = append(violations, apperr.FieldViolation{Field: name, Message: "must be a valid UUID"})
+			continue
+		}
+		ids[name] = id
+	}
+	if len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+	return ids, nil
+}
+
+// parseID parses the id of the resource a request addresses.
+func parseID(v string) (uuid.UUID, error) {
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return uuid.Nil, apperr.Invalid([]apperr.FieldViolation{{Field: "id", Message: "must be a valid UUID"}})
+	}
+	return id, nil
+}
+
+// ifMatch turns an expected version into the If-Match versions the service
+// layer takes; nil matches any version.
+func ifMatch(expected *int64) []int64 {
+	if expected == nil {
+		return nil
+	}
+	return []int64{*expected}
+}
+
+// stored returns the resource as re-read after a write, or as written if it
+// could not be re-read.
+func stored[T any](after, written *T) *T {
+	if after != nil {
+		return after
+	}
+	return written
+}
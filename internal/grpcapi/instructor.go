@@ -0,0 +1,99 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+
+	"api-server/internal/model"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type instructorServer struct {
+	pb.UnimplementedInstructorServiceServer
+	is     *service.InstructorService
+	logger *slog.Logger
+}
+
+func (s *instructorServer) ListInstructors(ctx context.Context, _ *pb.ListInstructorsRequest) (*pb.ListInstructorsResponse, error) {
+	instructors, err := s.is.GetAllInstructors()
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_ListInstructors_FullMethodName, err)
+	}
+	resp := &pb.ListInstructorsResponse{Instructors: make([]*pb.Instructor, len(instructors))}
+	for i := range instructors {
+		resp.Instructors[i] = instructorPB(&instructors[i])
+	}
+	return resp, nil
+}
+
+func (s *instructorServer) GetInstructor(ctx context.Context, req *pb.GetInstructorRequest) (*pb.Instructor, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_GetInstructor_FullMethodName, err)
+	}
+	instructor, err := s.is.GetInstructorByID(id)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_GetInstructor_FullMethodName, err)
+	}
+	return instructorPB(instructor), nil
+}
+
+func (s *instructorServer) CreateInstructor(ctx context.Context, req *pb.CreateInstructorRequest) (*pb.Instructor, error) {
+	instructor, err := instructorModel(req.GetInstructor())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_CreateInstructor_FullMethodName, err)
+	}
+	after, err := s.is.CreateInstructor(ctx, instructor)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_CreateInstructor_FullMethodName, err)
+	}
+	return instructorPB(stored(after, instructor)), nil
+}
+
+func (s *instructorServer) UpdateInstructor(ctx context.Context, req *pb.UpdateInstructorRequest) (*pb.Instructor, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_UpdateInstructor_FullMethodName, err)
+	}
+	instructor, err := instructorModel(req.GetInstructor())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_UpdateInstructor_FullMethodName, err)
+	}
+	after, err := s.is.UpdateInstructor(ctx, id, instructor, ifMatch(req.ExpectedVersion))
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_UpdateInstructor_FullMethodName, err)
+	}
+	return instructorPB(stored(after, instructor)), nil
+}
+
+func (s *instructorServer) DeleteInstructor(ctx context.Context, req *pb.DeleteInstructorRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_DeleteInstructor_FullMethodName, err)
+	}
+	if err := s.is.DeleteInstructor(ctx, id, ifMatch(req.ExpectedVersion)); err != nil {
+		return nil, toStatus(ctx, s.logger, pb.InstructorService_DeleteInstructor_FullMethodName, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func instructorModel(in *pb.Instructor) (*model.Instructor, error) {
+	ids, err := parseIDs(map[string]string{"user_id": in.GetUserId()})
+	if err != nil {
+		return nil, err
+	}
+	return &model.Instructor{UserID: ids["user_id"], Name: in.GetName()}, nil
+}
+
+func instructorPB(in *model.Instructor) *pb.Instructor {
+	return &pb.Instructor{
+		Id:          in.ID.String(),
+		UserId:      in.UserID.String(),
+		Name:        in.Name,
+		DateCreated: in.DateCreated,
+		Version:     in.Version,
+	}
+}
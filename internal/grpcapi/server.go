@@ -0,0 +1,200 @@
+// Package grpcapi serves the course, instructor, user and trace APIs over
+// gRPC for internal services. The services are defined in
+// proto/apiserver/v1 and share the service layer, credentials, request IDs,
+// metrics and tracing with the REST API.
+//
+// Calls authenticate with HTTP basic credentials in the authorization
+// metadata, except CreateUser which, as in the REST API, needs none. A
+// request ID is taken from x-request-id metadata or generated, and returned
+// in the x-request-id header.
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/api"
+	"api-server/internal/audit"
+	"api-server/internal/metrics"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/requestid"
+	"api-server/internal/service"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key carrying the request ID.
+const requestIDKey = "x-request-id"
+
+// publicMethods may be called without credentials.
+var publicMethods = map[string]bool{
+	pb.UserService_CreateUser_FullMethodName: true,
+}
+
+// Config sets where the gRPC API listens. An empty Addr disables it.
+type Config struct {
+	Addr string
+}
+
+// LoadConfig reads GRPC_ADDR (default :9090). Set it to "off" to disable
+// the gRPC API.
+func LoadConfig() (Config, error) {
+	addr, ok := os.LookupEnv("GRPC_ADDR")
+	addr = strings.TrimSpace(addr)
+	switch {
+	case !ok || addr == "":
+		return Config{Addr: ":9090"}, nil
+	case addr == "off":
+		return Config{}, nil
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return Config{}, fmt.Errorf("invalid GRPC_ADDR %q: must be host:port, :port or off", addr)
+	}
+	return Config{Addr: addr}, nil
+}
+
+// New returns a gRPC server for the resources in db, storing uploaded
+// traces in bucket with client.
+func New(db *sql.DB, client *storage.Client, bucket string, logger *slog.Logger) *grpc.Server {
+	logger = logger.With("component", "grpc")
+	i := &interceptors{db: db, logger: logger}
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(i.unary),
+		grpc.ChainStreamInterceptor(i.stream),
+	)
+	pb.RegisterCourseServiceServer(srv, &courseServer{cs: service.NewCourseService(db, logger), logger: logger})
+	pb.RegisterInstructorServiceServer(srv, &instructorServer{is: service.NewInstructorService(db, logger), logger: logger})
+	pb.RegisterUserServiceServer(srv, &userServer{us: service.NewUserService(db, logger), logger: logger})
+	pb.RegisterTraceServiceServer(srv, &traceServer{ts: service.NewTraceService(db, client, bucket, logger), logger: logger})
+	return srv
+}
+
+// interceptors do for every call what the REST middleware does for every
+// request: assign a request ID, record metrics and authenticate the caller.
+type interceptors struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func (i *interceptors) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx = i.withRequestID(ctx, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) })
+	defer i.observe(ctx, info.FullMethod, time.Now(), &err)
+	if ctx, err = i.authenticate(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *interceptors) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx := i.withRequestID(ss.Context(), ss.SetHeader)
+	defer i.observe(ctx, info.FullMethod, time.Now(), &err)
+	if ctx, err = i.authenticate(ctx, info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// withRequestID returns ctx carrying the caller's request ID, or a new one,
+// and sends it back with setHeader.
+func (i *interceptors) withRequestID(ctx context.Context, setHeader func(metadata.MD) error) context.Context {
+	var id string
+	if v := metadata.ValueFromIncomingContext(ctx, requestIDKey); len(v) > 0 && requestid.Valid(v[0]) {
+		id = v[0]
+	} else {
+		id = uuid.NewString()
+	}
+	if err := setHeader(metadata.Pairs(requestIDKey, id)); err != nil {
+		i.logger.WarnContext(ctx, "failed to send request ID", "error", err)
+	}
+	return requestid.NewContext(ctx, id)
+}
+
+// observe records a finished call, turning a panic into an Internal error
+// since, unlike net/http, gRPC does not recover handlers.
+func (i *interceptors) observe(ctx context.Context, method string, start time.Time, err *error) {
+	if p := recover(); p != nil {
+		i.logger.ErrorContext(ctx, "panic serving gRPC call", "method", method, "panic", p, "stack", string(debug.Stack()))
+		*err = status.Error(codes.Internal, "An unexpected error occurred.")
+	}
+	code := status.Code(*err).String()
+	metrics.RequestCounter.WithLabelValues(method, "GRPC", code).Inc()
+	metrics.RequestDuration.WithLabelValues(method, "GRPC", code).Observe(time.Since(start).Seconds())
+}
+
+// authenticate checks the caller's basic credentials like api.BasicAuth and
+// returns ctx carrying the caller as actor and their address for auditing.
+func (i *interceptors) authenticate(ctx context.Context, method string) (context.Context, error) {
+	ctx = audit.WithSourceIP(ctx, sourceIP(ctx))
+	if publicMethods[method] {
+		return ctx, nil
+	}
+	username, password, ok := basicAuth(ctx)
+	if !ok {
+		metrics.AuthFailures.WithLabelValues(metrics.AuthMissingCredentials).Inc()
+		return nil, status.Error(codes.Unauthenticated, "valid credentials are required")
+	}
+	reason, err := api.Authenticate(ctx, i.db, username, password)
+	if err != nil {
+		i.logger.ErrorContext(ctx, "database error during auth", "error", err)
+		return nil, status.Error(codes.Internal, "An unexpected error occurred.")
+	}
+	if reason != "" {
+		metrics.AuthFailures.WithLabelValues(reason).Inc()
+		return nil, status.Error(codes.Unauthenticated, "valid credentials are required")
+	}
+	return actor.NewContext(ctx, username), nil
+}
+
+// basicAuth returns the credentials in the authorization metadata of ctx.
+func basicAuth(ctx context.Context) (username, password string, ok bool) {
+	v := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(v) == 0 {
+		return "", "", false
+	}
+	scheme, encoded, ok := strings.Cut(v[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// sourceIP returns the caller's address, honoring x-forwarded-for from
+// trusted proxies as audit.SourceIP does.
+func sourceIP(ctx context.Context) string {
+	var remote string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr.String()
+	}
+	return audit.ClientIP(remote, metadata.ValueFromIncomingContext(ctx, "x-forwarded-for"))
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
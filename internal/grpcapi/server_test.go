@@ -0,0 +1,153 @@
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"api-server/internal/actor"
+	"api-server/internal/logging"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/requestid"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func basic(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// expectPassword expects the password of ada to be looked up and returns
+// stored, or err if it is not nil.
+func expectPassword(mock sqlmock.Sqlmock, stored string, err error) {
+	q := mock.ExpectQuery("SELECT password FROM api.user").WithArgs("ada")
+	if err != nil {
+		q.WillReturnError(err)
+		return
+	}
+	q.WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(stored))
+}
+
+func TestUnaryAuthenticates(t *testing.T) {
+	getCourse := &grpc.UnaryServerInfo{FullMethod: pb.CourseService_GetCourse_FullMethodName}
+	createUser := &grpc.UnaryServerInfo{FullMethod: pb.UserService_CreateUser_FullMethodName}
+	tests := []struct {
+		name          string
+		info          *grpc.UnaryServerInfo
+		authorization string
+		expect        func(sqlmock.Sqlmock)
+		code          codes.Code
+		actor         string
+	}{
+		{"valid credentials", getCourse, basic("ada", "s3cret"), func(m sqlmock.Sqlmock) { expectPassword(m, "s3cret", nil) }, codes.OK, "ada"},
+		{"public method", createUser, "", nil, codes.OK, actor.Anonymous},
+		{"missing credentials", getCourse, "", nil, codes.Unauthenticated, ""},
+		{"bearer token", getCourse, "Bearer abc", nil, codes.Unauthenticated, ""},
+		{"malformed credentials", getCourse, "Basic !!!", nil, codes.Unauthenticated, ""},
+		{"unknown user", getCourse, basic("ada", "s3cret"), func(m sqlmock.Sqlmock) { expectPassword(m, "", sql.ErrNoRows) }, codes.Unauthenticated, ""},
+		{"wrong password", getCourse, basic("ada", "guess"), func(m sqlmock.Sqlmock) { expectPassword(m, "s3cret", nil) }, codes.Unauthenticated, ""},
+		{"database down", getCourse, basic("ada", "s3cret"), func(m sqlmock.Sqlmock) { expectPassword(m, "", errors.New("connection refused")) }, codes.Internal, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			md := metadata.Pairs(requestIDKey, "req-1")
+			if tt.authorization != "" {
+				md.Set("authorization", tt.authorization)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+			called := false
+			handler := func(ctx context.Context, req any) (any, error) {
+				called = true
+				if got := actor.FromContext(ctx); got != tt.actor {
+					t.Errorf("actor = %q, want %q", got, tt.actor)
+				}
+				if got := requestid.FromContext(ctx); got != "req-1" {
+					t.Errorf("request ID = %q, want the caller's", got)
+				}
+				return "ok", nil
+			}
+
+			i := &interceptors{db: db, logger: logging.Discard()}
+			_, err = i.unary(ctx, nil, tt.info, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("code = %v, want %v", code, tt.code)
+			}
+			if called != (tt.code == codes.OK) {
+				t.Errorf("handler called = %t", called)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUnaryRecoversPanic(t *testing.T) {
+	i := &interceptors{logger: logging.Discard()}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.UserService_CreateUser_FullMethodName}
+	_, err := i.unary(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		panic("nil map")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("panicking handler returned %v, want Internal", err)
+	}
+}
+
+// testStream is a server stream that records the header it is sent.
+type testStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *testStream) Context() context.Context { return s.ctx }
+
+func (s *testStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamAuthenticates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	expectPassword(mock, "s3cret", nil)
+
+	md := metadata.Pairs("authorization", basic("ada", "s3cret"))
+	ss := &testStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	i := &interceptors{db: db, logger: logging.Discard()}
+	info := &grpc.StreamServerInfo{FullMethod: pb.TraceService_UploadTrace_FullMethodName}
+	err = i.stream(nil, ss, info, func(srv any, stream grpc.ServerStream) error {
+		if got := actor.FromContext(stream.Context()); got != "ada" {
+			t.Errorf("actor = %q, want ada", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a request ID from the caller, a new one is sent back.
+	if ids := ss.header.Get(requestIDKey); len(ids) != 1 || !requestid.Valid(ids[0]) {
+		t.Errorf("request ID header = %v, want a generated ID", ids)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
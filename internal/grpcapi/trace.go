@@ -0,0 +1,160 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"api-server/internal/metrics"
+	"api-server/internal/model"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/service"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type traceServer struct {
+	pb.UnimplementedTraceServiceServer
+	ts     *service.TraceService
+	logger *slog.Logger
+}
+
+func (s *traceServer) ListTraces(ctx context.Context, _ *pb.ListTracesRequest) (*pb.ListTracesResponse, error) {
+	traces, err := s.ts.GetAllTraces()
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_ListTraces_FullMethodName, err)
+	}
+	resp := &pb.ListTracesResponse{Traces: make([]*pb.Trace, len(traces))}
+	for i := range traces {
+		resp.Traces[i] = tracePB(&traces[i])
+	}
+	return resp, nil
+}
+
+func (s *traceServer) GetTrace(ctx context.Context, req *pb.GetTraceRequest) (*pb.Trace, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_GetTrace_FullMethodName, err)
+	}
+	trace, err := s.ts.GetTraceByID(id)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_GetTrace_FullMethodName, err)
+	}
+	return tracePB(trace), nil
+}
+
+// UploadTrace streams the chunks it receives straight to the bucket, so a
+// file is never held in memory whole.
+func (s *traceServer) UploadTrace(stream pb.TraceService_UploadTraceServer) error {
+	ctx := stream.Context()
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	md := first.GetMetadata()
+	if md == nil {
+		metrics.TraceUploads.WithLabelValues(metrics.UploadRejected).Inc()
+		return status.Error(codes.InvalidArgument, "the first message must carry the trace metadata")
+	}
+	ids, err := parseIDs(map[string]string{"user_id": md.GetUserId()})
+	if err != nil {
+		metrics.TraceUploads.WithLabelValues(metrics.UploadRejected).Inc()
+		return toStatus(ctx, s.logger, pb.TraceService_UploadTrace_FullMethodName, err)
+	}
+	contentType := md.GetContentType()
+	if contentType == "" {
+		contentType = "application/pdf"
+	}
+
+	trace := &model.Trace{UserID: ids["user_id"], FileName: md.GetFileName()}
+	chunks := &chunkReader{stream: stream}
+	err = s.ts.UploadTrace(ctx, trace, contentType, chunks)
+	if chunks.err != nil && !errors.Is(chunks.err, io.EOF) {
+		// The client broke off or misbehaved; report that, not the
+		// storage failure it caused.
+		return chunks.err
+	}
+	if err != nil {
+		return toStatus(ctx, s.logger, pb.TraceService_UploadTrace_FullMethodName, err)
+	}
+	return stream.SendAndClose(tracePB(trace))
+}
+
+func (s *traceServer) UpdateTrace(ctx context.Context, req *pb.UpdateTraceRequest) (*pb.Trace, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_UpdateTrace_FullMethodName, err)
+	}
+	trace, err := traceModel(req.GetTrace())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_UpdateTrace_FullMethodName, err)
+	}
+	after, err := s.ts.UpdateTrace(ctx, id, trace, ifMatch(req.ExpectedVersion))
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_UpdateTrace_FullMethodName, err)
+	}
+	return tracePB(stored(after, trace)), nil
+}
+
+func (s *traceServer) DeleteTrace(ctx context.Context, req *pb.DeleteTraceRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_DeleteTrace_FullMethodName, err)
+	}
+	if err := s.ts.DeleteTrace(ctx, id, ifMatch(req.ExpectedVersion)); err != nil {
+		return nil, toStatus(ctx, s.logger, pb.TraceService_DeleteTrace_FullMethodName, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// chunkReader reads the file chunks of an upload stream. err holds the
+// error that ended the stream, io.EOF once the client is done.
+type chunkReader struct {
+	stream pb.TraceService_UploadTraceServer
+	buf    []byte
+	err    error
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		req, err := r.stream.Recv()
+		switch {
+		case err != nil:
+			r.err = err
+		case req.GetMetadata() != nil:
+			r.err = status.Error(codes.InvalidArgument, "only the first message may carry trace metadata")
+		default:
+			r.buf = req.GetChunk()
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func traceModel(t *pb.Trace) (*model.Trace, error) {
+	ids, err := parseIDs(map[string]string{"user_id": t.GetUserId()})
+	if err != nil {
+		return nil, err
+	}
+	return &model.Trace{UserID: ids["user_id"], FileName: t.GetFileName(), BucketPath: t.GetBucketPath()}, nil
+}
+
+func tracePB(t *model.Trace) *pb.Trace {
+	return &pb.Trace{
+		Id:          t.ID.String(),
+		UserId:      t.UserID.String(),
+		FileName:    t.FileName,
+		DateCreated: t.DateCreated,
+		BucketPath:  t.BucketPath,
+		Status:      t.Status,
+		StatusError: t.StatusError,
+		Version:     t.Version,
+	}
+}
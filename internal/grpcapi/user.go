@@ -0,0 +1,97 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+
+	"api-server/internal/model"
+	pb "api-server/internal/pb/apiserverv1"
+	"api-server/internal/service"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	us     *service.UserService
+	logger *slog.Logger
+}
+
+func (s *userServer) ListUsers(ctx context.Context, _ *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	users, err := s.us.GetAllUsers()
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_ListUsers_FullMethodName, err)
+	}
+	resp := &pb.ListUsersResponse{Users: make([]*pb.User, len(users))}
+	for i := range users {
+		resp.Users[i] = userPB(&users[i])
+	}
+	return resp, nil
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_GetUser_FullMethodName, err)
+	}
+	user, err := s.us.GetUserByID(id)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_GetUser_FullMethodName, err)
+	}
+	return userPB(user), nil
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user := userModel(req.GetUser(), req.GetPassword())
+	after, err := s.us.CreateUser(ctx, user)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_CreateUser_FullMethodName, err)
+	}
+	return userPB(stored(after, user)), nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_UpdateUser_FullMethodName, err)
+	}
+	user := userModel(req.GetUser(), req.GetPassword())
+	after, err := s.us.UpdateUser(ctx, id, user, ifMatch(req.ExpectedVersion))
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_UpdateUser_FullMethodName, err)
+	}
+	return userPB(stored(after, user)), nil
+}
+
+func (s *userServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_DeleteUser_FullMethodName, err)
+	}
+	if err := s.us.DeleteUser(ctx, id, ifMatch(req.ExpectedVersion)); err != nil {
+		return nil, toStatus(ctx, s.logger, pb.UserService_DeleteUser_FullMethodName, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func userModel(u *pb.User, password string) *model.User {
+	return &model.User{
+		FirstName: u.GetFirstName(),
+		LastName:  u.GetLastName(),
+		Username:  u.GetUsername(),
+		Password:  password,
+	}
+}
+
+// userPB converts u, leaving out its password.
+func userPB(u *model.User) *pb.User {
+	return &pb.User{
+		Id:             u.ID.String(),
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Username:       u.Username,
+		AccountCreated: u.AccountCreated,
+		AccountUpdated: u.AccountUpdated,
+		Version:        u.Version,
+	}
+}
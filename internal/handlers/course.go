@@ -1,109 +1,204 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
+	"log/slog"
 	"net/http"
+	"slices"
 
 	"api-server/internal/model"
-	"api-server/internal/repository"
+	"api-server/internal/problem"
+	"api-server/internal/service"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 )
 
 type CourseHandler struct {
-	cr *repository.CourseRepository
+	cs     *service.CourseService
+	logger *slog.Logger
 }
 
-func NewCourseHandler(db *sql.DB) *CourseHandler {
-	return &CourseHandler{cr: repository.NewCourseRepository(db)}
+func NewCourseHandler(db *sql.DB, logger *slog.Logger) *CourseHandler {
+	return &CourseHandler{cs: service.NewCourseService(db, logger), logger: logger.With("handler", "course")}
 }
 
 func (ch *CourseHandler) GetCourses(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	courses, err := ch.cr.GetAllCourses()
+	filter, ok := courseFilter(w, r)
+	if !ok {
+		return
+	}
+	courses, err := ch.cs.GetAllCourses(filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ch.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(courses)
+	writeCollection(w, r, courses)
+}
+
+// courseFilter reads the term_id and term_status query parameters, so that
+// clients can list, for example, the courses of the current term.
+func courseFilter(w http.ResponseWriter, r *http.Request) (model.CourseFilter, bool) {
+	q := r.URL.Query()
+	var filter model.CourseFilter
+	if v := q.Get("term_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "term_id must be a valid UUID")
+			return filter, false
+		}
+		filter.TermID = id
+	}
+	if filter.TermStatus = q.Get("term_status"); filter.TermStatus != "" && !slices.Contains(termStatuses, filter.TermStatus) {
+		problem.Error(w, r, http.StatusBadRequest, "term_status must be one of upcoming, current, archived")
+		return filter, false
+	}
+	return filter, true
 }
 
 func (ch *CourseHandler) GetCourseByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
-	course, err := ch.cr.GetCourseByID(id)
+	course, err := ch.cs.GetCourseByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		problem.WriteError(w, r, ch.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(course)
+	writeTagged(w, r, etag(course.Version), course)
 }
 
 func (ch *CourseHandler) CreateCourse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var course model.Course
-	err := json.NewDecoder(r.Body).Decode(&course)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &course) {
 		return
 	}
-	err = ch.cr.CreateCourse(&course)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if _, err := ch.cs.CreateCourse(serviceContext(r), &course); err != nil {
+		problem.WriteError(w, r, ch.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(course.Version))
 	w.WriteHeader(http.StatusCreated)
 }
 
 func (ch *CourseHandler) UpdateCourse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 	var course model.Course
-	err = json.NewDecoder(r.Body).Decode(&course)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &course) {
+		return
+	}
+	if _, err := ch.cs.UpdateCourse(serviceContext(r), id, &course, ifm); err != nil {
+		problem.WriteError(w, r, ch.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(course.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CourseHandler) PatchCourse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
 		return
 	}
-	err = ch.cr.UpdateCourse(id, &course)
+	course, err := ch.cs.GetCourseByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ch.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, ch.logger, course, patch) {
 		return
 	}
+	if _, err := ch.cs.PatchCourse(serviceContext(r), id, course, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, ch.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(course.Version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (ch *CourseHandler) DeleteCourse(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
-	err = ch.cr.DeleteCourse(id)
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := ch.cs.DeleteCourse(serviceContext(r), id, ifm); err != nil {
+		problem.WriteError(w, r, ch.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ch *CourseHandler) RestoreCourse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	version, err := ch.cs.RestoreCourse(serviceContext(r), id, ifm)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ch.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ImportCourses creates or updates courses in bulk, matching existing
+// offerings on code, term and section. Updates promote waitlisted students
+// like UpdateCourse.
+func (ch *CourseHandler) ImportCourses(w http.ResponseWriter, r *http.Request) {
+	importer[model.Course]{
+		checkRefs: ch.cs.CheckReferences,
+		apply:     ch.cs.ImportCourses,
+		logger:    ch.logger,
+	}.serve(w, r)
+}
+
+// ExportCourses streams the courses matching the list filters as JSON Lines,
+// CSV or Parquet.
+func (ch *CourseHandler) ExportCourses(w http.ResponseWriter, r *http.Request) {
+	filter, ok := courseFilter(w, r)
+	if !ok {
+		return
+	}
+	exportRows(w, r, ch.logger, "courses", func(ctx context.Context, fn func(*model.Course) error) error {
+		return ch.cs.StreamCourses(ctx, filter, fn)
+	})
+}
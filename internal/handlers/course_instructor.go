@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+)
+
+type CourseInstructorHandler struct {
+	cis    *service.CourseInstructorService
+	logger *slog.Logger
+}
+
+func NewCourseInstructorHandler(db *sql.DB, logger *slog.Logger) *CourseInstructorHandler {
+	return &CourseInstructorHandler{cis: service.NewCourseInstructorService(db, logger), logger: logger.With("handler", "course_instructor")}
+}
+
+// assignmentPath parses the course and assignment IDs of
+// /courses/{id}/instructors/{assignmentID}.
+func assignmentPath(w http.ResponseWriter, r *http.Request) (courseID, id uuid.UUID, ok bool) {
+	if courseID, ok = pathID(w, r); !ok {
+		return
+	}
+	id, ok = pathUUID(w, r, "assignmentID")
+	return
+}
+
+func (cih *CourseInstructorHandler) GetCourseInstructors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	instructors, err := cih.cis.GetCourseInstructors(courseID)
+	if err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	writeCollection(w, r, instructors)
+}
+
+func (cih *CourseInstructorHandler) GetCourseInstructorByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := assignmentPath(w, r)
+	if !ok {
+		return
+	}
+	ci, err := cih.cis.GetCourseInstructorByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	writeTagged(w, r, etag(ci.Version), ci)
+}
+
+// GetInstructorCourses lists the courses an instructor is assigned to and
+// their role in each.
+func (cih *CourseInstructorHandler) GetInstructorCourses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	instructorID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	courses, err := cih.cis.GetInstructorCourses(instructorID)
+	if err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	writeCollection(w, r, courses)
+}
+
+// CreateCourseInstructor assigns an instructor to the course and responds
+// with the new assignment.
+func (cih *CourseInstructorHandler) CreateCourseInstructor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var ci model.CourseInstructor
+	if !decodeJSON(w, r, &ci) {
+		return
+	}
+	ci.CourseID = courseID
+	created, err := cih.cis.CreateCourseInstructor(serviceContext(r), &ci)
+	if err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(ci.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// PatchCourseInstructor changes the role or dates of an assignment. To move
+// an assignment to another instructor, delete it and create a new one.
+func (cih *CourseInstructorHandler) PatchCourseInstructor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := assignmentPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	ci, err := cih.cis.GetCourseInstructorByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, cih.logger, ci, patch) {
+		return
+	}
+	if _, err := cih.cis.PatchCourseInstructor(serviceContext(r), courseID, id, ci, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(ci.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cih *CourseInstructorHandler) DeleteCourseInstructor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := assignmentPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := cih.cis.DeleteCourseInstructor(serviceContext(r), courseID, id, ifm); err != nil {
+		problem.WriteError(w, r, cih.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// enrollmentStatuses are the values accepted by the status query parameter.
+var enrollmentStatuses = []string{model.EnrollmentEnrolled, model.EnrollmentWaitlisted, model.EnrollmentDropped, model.EnrollmentCompleted}
+
+type EnrollmentHandler struct {
+	es     *service.EnrollmentService
+	logger *slog.Logger
+}
+
+func NewEnrollmentHandler(db *sql.DB, logger *slog.Logger) *EnrollmentHandler {
+	return &EnrollmentHandler{es: service.NewEnrollmentService(db, logger), logger: logger.With("handler", "enrollment")}
+}
+
+// enrollmentPath parses the course and enrollment IDs of
+// /courses/{id}/enrollments/{enrollmentID}.
+func enrollmentPath(w http.ResponseWriter, r *http.Request) (courseID, id uuid.UUID, ok bool) {
+	if courseID, ok = pathID(w, r); !ok {
+		return
+	}
+	id, ok = pathUUID(w, r, "enrollmentID")
+	return
+}
+
+func (eh *EnrollmentHandler) GetCourseEnrollments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(enrollmentStatuses, status) {
+		problem.Error(w, r, http.StatusBadRequest, "status must be one of enrolled, waitlisted, dropped, completed")
+		return
+	}
+	enrollments, err := eh.es.GetCourseEnrollments(courseID, status)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	writeCollection(w, r, enrollments)
+}
+
+func (eh *EnrollmentHandler) GetEnrollmentByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := enrollmentPath(w, r)
+	if !ok {
+		return
+	}
+	enrollment, err := eh.es.GetEnrollmentByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	writeTagged(w, r, etag(enrollment.Version), enrollment)
+}
+
+// CreateEnrollment enrolls a student in a course, placing them on the
+// waitlist when the course is at capacity.
+func (eh *EnrollmentHandler) CreateEnrollment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var req model.EnrollmentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	enrollment, err := eh.es.Enroll(serviceContext(r), courseID, &req)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(enrollment.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// PatchEnrollment changes the status of an enrollment. Status is the only
+// field clients may change.
+func (eh *EnrollmentHandler) PatchEnrollment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := enrollmentPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	enrollment, err := eh.es.GetEnrollmentByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, eh.logger, enrollment, patch) {
+		return
+	}
+	after, err := eh.es.PatchEnrollment(serviceContext(r), courseID, id, enrollment, patchFields(patch), ifm)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	if after != nil {
+		w.Header().Set("ETag", etag(after.Version))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (eh *EnrollmentHandler) DeleteEnrollment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := enrollmentPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := eh.es.DeleteEnrollment(serviceContext(r), courseID, id, ifm); err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserEnrollments is the student view: every course a user is enrolled
+// in, waitlisted for, has dropped or completed.
+func (eh *EnrollmentHandler) GetUserEnrollments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	enrollments, err := eh.es.GetUserEnrollments(userID)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	writeCollection(w, r, enrollments)
+}
+
+// GetInstructorRoster is the instructor view: the enrolled and waitlisted
+// students of each course the instructor teaches.
+func (eh *EnrollmentHandler) GetInstructorRoster(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	instructorID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	rosters, err := eh.es.GetInstructorRoster(instructorID)
+	if err != nil {
+		problem.WriteError(w, r, eh.logger, err)
+		return
+	}
+	writeCollection(w, r, rosters)
+}
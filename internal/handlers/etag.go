@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"api-server/internal/problem"
+)
+
+// etag returns the strong entity tag for a row version. Repositories bump
+// the version whenever the representation changes, including fields derived
+// from other rows such as a course's semester or an enrollment's waitlist
+// position.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatch parses the If-Match header into the row versions a write may
+// replace. A missing header or "*" returns nil, meaning any version. If no
+// listed tag can match, it writes a 412 problem and returns false.
+func ifMatch(w http.ResponseWriter, r *http.Request) ([]int64, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil, true
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+		// If-Match uses the strong comparison, so weak tags never match.
+		unquoted, ok := strings.CutPrefix(tag, `"`)
+		if !ok {
+			continue
+		}
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseInt(unquoted, 10, 64); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		problem.Error(w, r, http.StatusPreconditionFailed, "If-Match does not match the current version")
+		return nil, false
+	}
+	return versions, true
+}
+
+// noneMatch reports whether the If-None-Match header lists tag, using the
+// weak comparison as RFC 9110 requires for GET.
+func noneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTagged encodes v as the response with the given ETag, or answers 304
+// Not Modified if the client already holds that representation.
+func writeTagged(w http.ResponseWriter, r *http.Request, tag string, v any) {
+	w.Header().Set("ETag", tag)
+	if noneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeCollection encodes a list with a weak ETag derived from its body, so
+// pollers can use If-None-Match without the list having a version of its own.
+func writeCollection(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		problem.Error(w, r, http.StatusInternalServerError, "An unexpected error occurred.")
+		return
+	}
+	sum := sha256.Sum256(body)
+	tag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", tag)
+	if noneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"api-server/internal/apperr"
+	"api-server/internal/logging"
+	"api-server/internal/problem"
+
+	"github.com/google/uuid"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		versions []int64
+		ok       bool
+	}{
+		{"absent", "", nil, true},
+		{"one tag", `"3"`, []int64{3}, true},
+		{"several tags", `"3", "4"`, []int64{3, 4}, true},
+		{"any", "*", nil, true},
+		{"any among tags", `"3", *`, nil, true},
+		{"weak tag never matches", `W/"3"`, nil, false},
+		{"weak tag is skipped", `W/"3", "4"`, []int64{4}, true},
+		{"unquoted", "3", nil, false},
+		{"not a version", `"abc"`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/v1/course/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			w := httptest.NewRecorder()
+			versions, ok := ifMatch(w, r)
+			if ok != tt.ok || !reflect.DeepEqual(versions, tt.versions) {
+				t.Errorf("ifMatch = %v, %t; want %v, %t", versions, ok, tt.versions, tt.ok)
+			}
+			if !ok && w.Code != http.StatusPreconditionFailed {
+				t.Errorf("status = %d, want 412", w.Code)
+			}
+		})
+	}
+}
+
+func TestStaleVersionIsPreconditionFailed(t *testing.T) {
+	// Repositories report an If-Match version that is no longer current as
+	// stale.
+	r := httptest.NewRequest(http.MethodPut, "/v1/course/1", nil)
+	w := httptest.NewRecorder()
+	problem.WriteError(w, r, logging.Discard(), apperr.Stale("course", uuid.New()))
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("status = %d, want 412", w.Code)
+	}
+}
+
+func TestWriteTagged(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"absent", "", http.StatusOK},
+		{"same version", `"3"`, http.StatusNotModified},
+		{"weak comparison", `W/"3"`, http.StatusNotModified},
+		{"among tags", `"2", "3"`, http.StatusNotModified},
+		{"any", "*", http.StatusNotModified},
+		{"older version", `"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/course/1", nil)
+			if tt.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			w := httptest.NewRecorder()
+			writeTagged(w, r, etag(3), map[string]string{"name": "Cloud"})
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("ETag"); got != `"3"` {
+				t.Errorf("ETag = %s, want \"3\"", got)
+			}
+			if empty := w.Body.Len() == 0; empty != (tt.status == http.StatusNotModified) {
+				t.Errorf("body = %q for status %d", w.Body, w.Code)
+			}
+		})
+	}
+}
+
+func TestWriteCollection(t *testing.T) {
+	list := []string{"CSYE 6225", "CSYE 7125"}
+	w := httptest.NewRecorder()
+	writeCollection(w, httptest.NewRequest(http.MethodGet, "/v1/course", nil), list)
+	tag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || len(tag) < 4 || tag[:2] != "W/" {
+		t.Fatalf("first response is %d with ETag %q, want 200 with a weak tag", w.Code, tag)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/course", nil)
+	r.Header.Set("If-None-Match", tag)
+	w = httptest.NewRecorder()
+	writeCollection(w, r, list)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("unchanged list answered %d, want 304", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	writeCollection(w, r, list[:1])
+	if w.Code != http.StatusOK {
+		t.Errorf("changed list answered %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-server/internal/problem"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetContentType is the media type of Parquet files.
+const parquetContentType = "application/vnd.apache.parquet"
+
+// parquetRowGroupSize is the number of rows buffered before a row group is
+// written.
+const parquetRowGroupSize = 10000
+
+// exportFormat is a file format offered by the export endpoints.
+type exportFormat struct {
+	name        string
+	contentType string
+	open        func(io.Writer, []exportColumn) exportWriter
+}
+
+// exportFormats lists the export formats; the first is the default.
+var exportFormats = []exportFormat{
+	{name: "jsonl", contentType: "application/x-ndjson", open: openJSONLines},
+	{name: "csv", contentType: "text/csv", open: openCSV},
+	{name: "parquet", contentType: parquetContentType, open: openParquet},
+}
+
+// exportWriter writes the rows of an export. row is the resource itself and
+// values its columns in order, as returned by exportColumn.value.
+type exportWriter interface {
+	write(row any, values []any) error
+	close() error
+}
+
+// exportType is the type of an export column.
+type exportType int
+
+const (
+	exportString exportType = iota
+	exportInt64
+	exportDouble
+	exportBool
+	exportTimestamp
+)
+
+// exportColumn is a JSON field of an exported resource, typed for Parquet.
+type exportColumn struct {
+	name     string
+	typ      exportType
+	optional bool
+	index    int
+}
+
+var (
+	uuidType = reflect.TypeFor[uuid.UUID]()
+	timeType = reflect.TypeFor[time.Time]()
+)
+
+// exportColumns lists the JSON fields of struct type t. UUIDs are exported as
+// strings, pointers as optional columns and anything without a Parquet
+// equivalent as its JSON encoding.
+func exportColumns(t reflect.Type) []exportColumn {
+	var columns []exportColumn
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		col := exportColumn{name: name, typ: exportString, index: i}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			col.optional = true
+			ft = ft.Elem()
+		}
+		switch {
+		case ft == timeType:
+			col.typ = exportTimestamp
+		case ft.Kind() == reflect.Bool:
+			col.typ = exportBool
+		case ft.Kind() >= reflect.Int && ft.Kind() <= reflect.Uint64:
+			col.typ = exportInt64
+		case ft.Kind() == reflect.Float32 || ft.Kind() == reflect.Float64:
+			col.typ = exportDouble
+		}
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// value returns the column of struct v as a string, int64, float64, bool or
+// time.Time according to the column's type, or nil.
+func (c exportColumn) value(v reflect.Value) any {
+	f := v.Field(c.index)
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			return nil
+		}
+		f = f.Elem()
+	}
+	switch {
+	case c.typ == exportTimestamp:
+		return f.Interface().(time.Time)
+	case c.typ == exportBool:
+		return f.Bool()
+	case c.typ == exportDouble:
+		return f.Float()
+	case c.typ == exportInt64 && f.CanInt():
+		return f.Int()
+	case c.typ == exportInt64:
+		return int64(f.Uint())
+	case f.Kind() == reflect.String:
+		return f.String()
+	case f.Type() == uuidType:
+		return f.Interface().(uuid.UUID).String()
+	}
+	data, _ := json.Marshal(f.Interface())
+	return string(data)
+}
+
+// exportFormatFor picks the format named by the format query parameter, or
+// else the first acceptable one in the Accept header. On failure it writes
+// a 400 or 406 problem and returns false.
+func exportFormatFor(w http.ResponseWriter, r *http.Request) (exportFormat, bool) {
+	names := make([]string, len(exportFormats))
+	for i, f := range exportFormats {
+		names[i] = f.name
+	}
+	if v := r.URL.Query().Get("format"); v != "" {
+		for _, f := range exportFormats {
+			if f.name == v {
+				return f, true
+			}
+		}
+		problem.Error(w, r, http.StatusBadRequest, "format must be one of "+strings.Join(names, ", "))
+		return exportFormat{}, false
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return exportFormats[0], true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return exportFormats[0], true
+		}
+		for _, f := range exportFormats {
+			if f.contentType == mediaType {
+				return f, true
+			}
+		}
+	}
+	problem.Error(w, r, http.StatusNotAcceptable, "exports are available as "+strings.Join(names, ", ")+"; use Accept or the format query parameter")
+	return exportFormat{}, false
+}
+
+// exportRows streams the rows produced by stream to the client in the
+// requested format. The response starts with the first row, so an error
+// before it is still reported as a problem; later errors truncate the file.
+func exportRows[T any](w http.ResponseWriter, r *http.Request, logger *slog.Logger, resource string, stream func(context.Context, func(*T) error) error) {
+	format, ok := exportFormatFor(w, r)
+	if !ok {
+		return
+	}
+	columns := exportColumns(reflect.TypeFor[T]())
+	var out exportWriter
+	open := func() {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, resource, format.name))
+		out = format.open(w, columns)
+	}
+
+	var n int
+	values := make([]any, len(columns))
+	err := stream(r.Context(), func(row *T) error {
+		if out == nil {
+			open()
+		}
+		v := reflect.ValueOf(row).Elem()
+		for i, c := range columns {
+			values[i] = c.value(v)
+		}
+		n++
+		return out.write(row, values)
+	})
+	if err != nil {
+		if out == nil {
+			problem.WriteError(w, r, logger, err)
+			return
+		}
+		// The status line is already sent; the truncated body is all we can do.
+		logger.ErrorContext(r.Context(), "export interrupted", "resource", resource, "format", format.name, "rows_written", n, "error", err)
+		return
+	}
+	if out == nil {
+		open()
+	}
+	if err := out.close(); err != nil {
+		logger.ErrorContext(r.Context(), "export interrupted", "resource", resource, "format", format.name, "rows_written", n, "error", err)
+		return
+	}
+	logger.InfoContext(r.Context(), "export finished", "resource", resource, "format", format.name, "rows", n)
+}
+
+type jsonLinesWriter struct{ enc *json.Encoder }
+
+func openJSONLines(w io.Writer, _ []exportColumn) exportWriter {
+	return jsonLinesWriter{enc: json.NewEncoder(w)}
+}
+
+func (jw jsonLinesWriter) write(row any, _ []any) error { return jw.enc.Encode(row) }
+func (jw jsonLinesWriter) close() error                 { return nil }
+
+// csvWriter writes a header of JSON field names and one record per row.
+// Empty cells are null; timestamps are RFC 3339.
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func openCSV(w io.Writer, columns []exportColumn) exportWriter {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	for i, c := range columns {
+		cw.record[i] = c.name
+	}
+	cw.w.Write(cw.record)
+	return cw
+}
+
+func (cw *csvWriter) write(_ any, values []any) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			cw.record[i] = ""
+		case string:
+			cw.record[i] = v
+		case int64:
+			cw.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			cw.record[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			cw.record[i] = strconv.FormatBool(v)
+		case time.Time:
+			cw.record[i] = v.Format(time.RFC3339Nano)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// parquetWriter writes the columns as a Gzip-compressed Parquet file. The
+// file orders its columns by name; leaves maps each export column to its
+// leaf in the file.
+type parquetWriter struct {
+	w      *parquet.Writer
+	leaves []parquet.LeafColumn
+	row    parquet.Row
+}
+
+func openParquet(w io.Writer, columns []exportColumn) exportWriter {
+	group := parquet.Group{}
+	for _, c := range columns {
+		var node parquet.Node
+		switch c.typ {
+		case exportInt64:
+			node = parquet.Int(64)
+		case exportDouble:
+			node = parquet.Leaf(parquet.DoubleType)
+		case exportBool:
+			node = parquet.Leaf(parquet.BooleanType)
+		case exportTimestamp:
+			node = parquet.Timestamp(parquet.Microsecond)
+		default:
+			node = parquet.String()
+		}
+		if c.optional {
+			node = parquet.Optional(node)
+		}
+		group[c.name] = node
+	}
+	schema := parquet.NewSchema("row", group)
+	pw := &parquetWriter{
+		w:      parquet.NewWriter(w, schema, parquet.Compression(&parquet.Gzip), parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		leaves: make([]parquet.LeafColumn, len(columns)),
+		row:    make(parquet.Row, len(columns)),
+	}
+	for i, c := range columns {
+		pw.leaves[i], _ = schema.Lookup(c.name)
+	}
+	return pw
+}
+
+func (pw *parquetWriter) write(_ any, values []any) error {
+	for i, v := range values {
+		var value parquet.Value
+		switch v := v.(type) {
+		case string:
+			value = parquet.ByteArrayValue([]byte(v))
+		case int64:
+			value = parquet.Int64Value(v)
+		case float64:
+			value = parquet.DoubleValue(v)
+		case bool:
+			value = parquet.BooleanValue(v)
+		case time.Time:
+			value = parquet.Int64Value(v.UnixMicro())
+		}
+		leaf, definition := pw.leaves[i], 0
+		if v != nil {
+			definition = leaf.MaxDefinitionLevel
+		}
+		pw.row[leaf.ColumnIndex] = value.Level(0, definition, leaf.ColumnIndex)
+	}
+	_, err := pw.w.WriteRows([]parquet.Row{pw.row})
+	return err
+}
+
+func (pw *parquetWriter) close() error { return pw.w.Close() }
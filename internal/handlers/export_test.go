@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+)
+
+// exportRow has a field of every kind exportColumns maps.
+type exportRow struct {
+	ID          uuid.UUID `json:"id"`
+	Description *string   `json:"description"`
+	CreditHours *int      `json:"credit_hours"`
+	Score       float64   `json:"score"`
+	Active      *bool     `json:"active"`
+	Tags        []string  `json:"tags"`
+	DateCreated time.Time `json:"date_created"`
+	Password    string    `json:"-"`
+}
+
+// parquetRow is exportRow as read back from a Parquet file.
+type parquetRow struct {
+	ID          string    `parquet:"id"`
+	Description *string   `parquet:"description,optional"`
+	CreditHours *int64    `parquet:"credit_hours,optional"`
+	Score       float64   `parquet:"score"`
+	Active      *bool     `parquet:"active,optional"`
+	Tags        string    `parquet:"tags"`
+	DateCreated time.Time `parquet:"date_created,timestamp(microsecond)"`
+}
+
+// writeExport writes rows through the writer open returns, the way
+// exportRows does, and returns the file.
+func writeExport(t *testing.T, open func(io.Writer, []exportColumn) exportWriter, rows []exportRow) []byte {
+	t.Helper()
+	columns := exportColumns(reflect.TypeFor[exportRow]())
+	var buf bytes.Buffer
+	out := open(&buf, columns)
+	values := make([]any, len(columns))
+	for i := range rows {
+		v := reflect.ValueOf(&rows[i]).Elem()
+		for j, c := range columns {
+			values[j] = c.value(v)
+		}
+		if err := out.write(&rows[i], values); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportColumns(t *testing.T) {
+	var got []string
+	for _, c := range exportColumns(reflect.TypeFor[exportRow]()) {
+		got = append(got, fmt.Sprintf("%s:%d:%t", c.name, c.typ, c.optional))
+	}
+	want := []string{
+		fmt.Sprintf("id:%d:false", exportString),
+		fmt.Sprintf("description:%d:true", exportString),
+		fmt.Sprintf("credit_hours:%d:true", exportInt64),
+		fmt.Sprintf("score:%d:false", exportDouble),
+		fmt.Sprintf("active:%d:true", exportBool),
+		fmt.Sprintf("tags:%d:false", exportString),
+		fmt.Sprintf("date_created:%d:false", exportTimestamp),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exportColumns = %v, want %v", got, want)
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	// Enough rows for a second, partial row group.
+	n := parquetRowGroupSize + 13
+	base := time.Date(2025, 1, 6, 9, 30, 0, 123456000, time.UTC)
+	rows := make([]exportRow, n)
+	want := make([]parquetRow, n)
+	for i := range rows {
+		rows[i] = exportRow{
+			ID:          uuid.New(),
+			Score:       float64(i) / 4,
+			Tags:        []string{"cloud", fmt.Sprint(i)},
+			DateCreated: base.Add(time.Duration(i) * time.Minute),
+			Password:    "never exported",
+		}
+		want[i] = parquetRow{
+			ID:          rows[i].ID.String(),
+			Score:       rows[i].Score,
+			Tags:        fmt.Sprintf(`["cloud","%d"]`, i),
+			DateCreated: rows[i].DateCreated,
+		}
+		if i%3 != 0 {
+			d := fmt.Sprintf("Cloud computing, section %d — ünïcode", i)
+			rows[i].Description, want[i].Description = &d, &d
+		}
+		if i%5 != 0 {
+			h, h64 := i%7, int64(i%7)
+			rows[i].CreditHours, want[i].CreditHours = &h, &h64
+		}
+		if i%7 != 0 {
+			a := i%2 == 0
+			rows[i].Active, want[i].Active = &a, &a
+		}
+	}
+
+	data := writeExport(t, openParquet, rows)
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups := len(f.RowGroups()); groups != 2 {
+		t.Errorf("file has %d row groups, want 2", groups)
+	}
+	got, err := parquet.Read[parquetRow](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != n {
+		t.Fatalf("read %d rows, want %d", len(got), n)
+	}
+	for i := range want {
+		got[i].DateCreated = got[i].DateCreated.UTC()
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Fatalf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParquetEmpty(t *testing.T) {
+	data := writeExport(t, openParquet, nil)
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != 0 || len(f.Schema().Columns()) != 7 {
+		t.Errorf("file has %d rows and %d columns, want 0 and 7", f.NumRows(), len(f.Schema().Columns()))
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"api-server/internal/graphqlapi"
+	"api-server/internal/problem"
+)
+
+// maxGraphQLBytes caps the size of a GraphQL request body.
+const maxGraphQLBytes = 64 << 10
+
+type GraphQLHandler struct {
+	exec   *graphqlapi.Executor
+	logger *slog.Logger
+}
+
+// NewGraphQLHandler returns a handler for GraphQL queries limited by cfg.
+// isAdmin reports whether an authenticated username belongs to an
+// administrator.
+func NewGraphQLHandler(db *sql.DB, cfg graphqlapi.Config, isAdmin func(string) bool, logger *slog.Logger) *GraphQLHandler {
+	logger = logger.With("handler", "graphql")
+	return &GraphQLHandler{exec: graphqlapi.New(db, cfg, isAdmin, logger), logger: logger}
+}
+
+// Query runs a GraphQL query sent as a JSON body with POST, or in the
+// query, operationName and variables query parameters with GET. The result
+// is returned with 200 OK even if it holds errors, as GraphQL clients
+// expect.
+func (gh *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req graphqlapi.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				problem.Error(w, r, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if !decodeFrom(w, r, http.MaxBytesReader(w, r.Body, maxGraphQLBytes), &req) {
+		return
+	}
+	if req.Query == "" {
+		problem.Error(w, r, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	res := gh.exec.Execute(r.Context(), req)
+	gh.logger.DebugContext(r.Context(), "ran GraphQL query", "operation", req.OperationName, "errors", len(res.Errors))
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/repository"
+	"api-server/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// Limits on bulk imports.
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 5000
+)
+
+// Row statuses of an import report. On a dry run, or when an atomic import
+// is rolled back, created and updated describe what the row would have done.
+const (
+	importCreated = "created"
+	importUpdated = "updated"
+	importFailed  = "failed"
+)
+
+// importReport is the response to an import request.
+type importReport struct {
+	Mode    string      `json:"mode"`
+	DryRun  bool        `json:"dry_run"`
+	Applied bool        `json:"applied"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Failed  int         `json:"failed"`
+	Rows    []importRow `json:"rows"`
+}
+
+// importRow reports one row of an import. Line is the row's line number in
+// the request body.
+type importRow struct {
+	Line   int                     `json:"line"`
+	Status string                  `json:"status"`
+	ID     *uuid.UUID              `json:"id,omitempty"`
+	Detail string                  `json:"detail,omitempty"`
+	Errors []apperr.FieldViolation `json:"errors,omitempty"`
+}
+
+// importRecord is a decoded row of an import body. detail and violations
+// describe why the row cannot be imported.
+type importRecord[T any] struct {
+	line       int
+	value      T
+	detail     string
+	violations []apperr.FieldViolation
+}
+
+func (rec *importRecord[T]) failed() bool {
+	return rec.detail != "" || len(rec.violations) > 0
+}
+
+// importer imports rows of one resource type. checkRefs reports references
+// of a row that do not exist and apply upserts the valid rows, auditing
+// them once the import has committed.
+type importer[T any] struct {
+	checkRefs func(*T) ([]apperr.FieldViolation, error)
+	apply     func(context.Context, []T, model.ImportOptions) ([]repository.ImportResult[T], bool, error)
+	logger    *slog.Logger
+}
+
+// serve handles an import request. The body is CSV with a header row of JSON
+// field names, or JSON Lines with one object per line. The mode query
+// parameter selects atomic (the default) or best-effort, and dry_run=true
+// checks every row without writing. The response reports each row; an
+// atomic import that was rolled back because of failed rows is a 422.
+func (im importer[T]) serve(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := model.ImportOptions{Mode: model.ImportAtomic}
+	if v := q.Get("mode"); v != "" {
+		if v != model.ImportAtomic && v != model.ImportBestEffort {
+			problem.Error(w, r, http.StatusBadRequest, "mode must be atomic or best-effort")
+			return
+		}
+		opts.Mode = v
+	}
+	if v := q.Get("dry_run"); v != "" {
+		var err error
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	records, ok := readImport[T](w, r)
+	if !ok {
+		return
+	}
+
+	var (
+		values  []T
+		invalid bool
+	)
+	for i := range records {
+		rec := &records[i]
+		if rec.failed() {
+			invalid = true
+			continue
+		}
+		rec.violations = validation.Struct(&rec.value)
+		refViolations, err := im.checkRefs(&rec.value)
+		if err != nil {
+			problem.WriteError(w, r, im.logger, err)
+			return
+		}
+		if rec.violations = append(rec.violations, refViolations...); len(rec.violations) > 0 {
+			invalid = true
+			continue
+		}
+		values = append(values, rec.value)
+	}
+
+	// Rows that failed to decode or validate never reach the database. An
+	// atomic import with such rows still checks the others, but only as a
+	// dry run.
+	applyOpts := opts
+	if invalid && opts.Mode == model.ImportAtomic {
+		applyOpts.DryRun = true
+	}
+	var (
+		results []repository.ImportResult[T]
+		applied bool
+	)
+	if len(values) > 0 {
+		var err error
+		if results, applied, err = im.apply(serviceContext(r), values, applyOpts); err != nil {
+			problem.WriteError(w, r, im.logger, err)
+			return
+		}
+	}
+
+	report := importReport{Mode: opts.Mode, DryRun: opts.DryRun, Applied: applied, Total: len(records), Rows: make([]importRow, 0, len(records))}
+	next := 0
+	for i := range records {
+		rec := &records[i]
+		row := importRow{Line: rec.line, Status: importFailed, Detail: rec.detail, Errors: rec.violations}
+		if !rec.failed() {
+			res := results[next]
+			if res.Err != nil {
+				row.Detail = apperr.Message(res.Err)
+				if row.Detail == "" {
+					im.logger.ErrorContext(r.Context(), "import row failed", "line", rec.line, "error", res.Err)
+					row.Detail = "An unexpected error occurred."
+				}
+				var appErr *apperr.Error
+				if errors.As(res.Err, &appErr) {
+					row.Errors = appErr.Violations
+				}
+			} else {
+				row.ID = &res.ID
+				row.Status = importCreated
+				if res.Before != nil {
+					row.Status = importUpdated
+				}
+			}
+			next++
+		}
+		switch row.Status {
+		case importCreated:
+			report.Created++
+		case importUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	im.logger.InfoContext(r.Context(), "import finished", "mode", report.Mode, "dry_run", report.DryRun, "applied", report.Applied,
+		"created", report.Created, "updated", report.Updated, "failed", report.Failed)
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Failed > 0 && opts.Mode == model.ImportAtomic && !opts.DryRun {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// readImport decodes the rows of an import body according to its
+// Content-Type. Rows that cannot be decoded are returned with a detail. On
+// a problem with the body as a whole it writes a 4xx problem and returns
+// false.
+func readImport[T any](w http.ResponseWriter, r *http.Request) ([]importRecord[T], bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var read func(io.Reader) ([]importRecord[T], error)
+	switch mediaType {
+	case "text/csv":
+		read = readCSV[T]
+	case "application/x-ndjson", "application/jsonl":
+		read = readJSONLines[T]
+	default:
+		problem.Error(w, r, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return nil, false
+	}
+
+	records, err := read(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("import body must not exceed %d bytes", maxImportBytes))
+		return nil, false
+	case err != nil:
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	case len(records) == 0:
+		problem.Error(w, r, http.StatusBadRequest, "import contains no rows")
+		return nil, false
+	case len(records) > maxImportRows:
+		problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("import must not exceed %d rows", maxImportRows))
+		return nil, false
+	}
+	return records, true
+}
+
+// readJSONLines decodes one JSON object per non-blank line.
+func readJSONLines[T any](body io.Reader) ([]importRecord[T], error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(nil, 1<<20)
+	var records []importRecord[T]
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		rec := importRecord[T]{line: line}
+		rec.detail = decodeRecord(bytes.NewReader(scanner.Bytes()), &rec.value)
+		records = append(records, rec)
+		if len(records) > maxImportRows {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, errors.New("JSON Lines rows must not exceed 1 MiB")
+		}
+		return nil, err
+	}
+	return records, nil
+}
+
+// readCSV decodes rows whose header names the JSON fields of T. Empty cells
+// leave their field unset. Cells of numeric and boolean fields are passed to
+// the JSON decoder as literals, so that malformed numbers are reported like
+// wrongly typed JSON fields.
+func readCSV[T any](body io.Reader) ([]importRecord[T], error) {
+	literals := jsonLiterals(reflect.TypeFor[T]())
+	cr := csv.NewReader(body)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := literals[name]; !known {
+			return nil, fmt.Errorf("CSV header has unknown column %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("CSV header repeats column %q", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+
+	var records []importRecord[T]
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		rec := importRecord[T]{line: line}
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount):
+			rec.detail = fmt.Sprintf("row has %d fields, header has %d", len(row), len(header))
+		case err != nil:
+			return nil, err
+		default:
+			object := make(map[string]json.RawMessage, len(row))
+			for i, cell := range row {
+				if cell == "" {
+					continue
+				}
+				if literals[header[i]] && json.Valid([]byte(cell)) {
+					object[header[i]] = json.RawMessage(cell)
+				} else {
+					object[header[i]], _ = json.Marshal(cell)
+				}
+			}
+			data, _ := json.Marshal(object)
+			rec.detail = decodeRecord(bytes.NewReader(data), &rec.value)
+		}
+		records = append(records, rec)
+		if len(records) > maxImportRows {
+			break
+		}
+	}
+	return records, nil
+}
+
+// decodeRecord decodes one JSON object into v like decodeJSON and returns a
+// description of the problem, or "" on success.
+func decodeRecord(r io.Reader, v any) string {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeDetail(err, "row")
+	}
+	return ""
+}
+
+// jsonLiterals maps the JSON field names of struct type t to whether CSV
+// cells for them are JSON literals rather than strings.
+func jsonLiterals(t reflect.Type) map[string]bool {
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			fields[name] = true
+		default:
+			fields[name] = false
+		}
+	}
+	return fields
+}
@@ -2,105 +2,167 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"log/slog"
 	"net/http"
 
 	"api-server/internal/model"
-	"api-server/internal/repository"
-
-	"github.com/google/uuid"
-	"github.com/gorilla/mux"
+	"api-server/internal/problem"
+	"api-server/internal/service"
 )
 
 type InstructorHandler struct {
-	ir *repository.InstructorRepository
+	is     *service.InstructorService
+	logger *slog.Logger
 }
 
-func NewInstructorHandler(db *sql.DB) *InstructorHandler {
-	return &InstructorHandler{ir: repository.NewInstructorRepository(db)}
+func NewInstructorHandler(db *sql.DB, logger *slog.Logger) *InstructorHandler {
+	return &InstructorHandler{is: service.NewInstructorService(db, logger), logger: logger.With("handler", "instructor")}
 }
 
 func (ih *InstructorHandler) GetInstructors(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	instructors, err := ih.ir.GetAllInstructors()
+	instructors, err := ih.is.GetAllInstructors()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ih.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(instructors)
+	writeCollection(w, r, instructors)
 }
 
 func (ih *InstructorHandler) GetInstructorByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	id, err := uuid.Parse(vars["id"])
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
-	instructor, err := ih.ir.GetInstructorByID(id)
+	instructor, err := ih.is.GetInstructorByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		problem.WriteError(w, r, ih.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(instructor)
+	writeTagged(w, r, etag(instructor.Version), instructor)
 }
 
 func (ih *InstructorHandler) CreateInstructor(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var instructor model.Instructor
-	err := json.NewDecoder(r.Body).Decode(&instructor)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &instructor) {
 		return
 	}
-	err = ih.ir.CreateInstructor(&instructor)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if _, err := ih.is.CreateInstructor(serviceContext(r), &instructor); err != nil {
+		problem.WriteError(w, r, ih.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(instructor.Version))
 	w.WriteHeader(http.StatusCreated)
 }
 
 func (ih *InstructorHandler) UpdateInstructor(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	id, err := uuid.Parse(vars["id"])
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 	var instructor model.Instructor
-	err = json.NewDecoder(r.Body).Decode(&instructor)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &instructor) {
+		return
+	}
+	if _, err := ih.is.UpdateInstructor(serviceContext(r), id, &instructor, ifm); err != nil {
+		problem.WriteError(w, r, ih.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(instructor.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ih *InstructorHandler) PatchInstructor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
-	err = ih.ir.UpdateInstructor(id, &instructor)
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	instructor, err := ih.is.GetInstructorByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ih.logger, err)
 		return
 	}
+	if !applyMergePatch(w, r, ih.logger, instructor, patch) {
+		return
+	}
+	if _, err := ih.is.PatchInstructor(serviceContext(r), id, instructor, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, ih.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(instructor.Version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (ih *InstructorHandler) DeleteInstructor(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	id, err := uuid.Parse(vars["id"])
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
-	err = ih.ir.DeleteInstructor(id)
+	if err := ih.is.DeleteInstructor(serviceContext(r), id, ifm); err != nil {
+		problem.WriteError(w, r, ih.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ih *InstructorHandler) RestoreInstructor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	version, err := ih.is.RestoreInstructor(serviceContext(r), id, ifm)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, ih.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ImportInstructors creates or updates instructors in bulk, matching the
+// existing instructor of each user.
+func (ih *InstructorHandler) ImportInstructors(w http.ResponseWriter, r *http.Request) {
+	importer[model.Instructor]{
+		checkRefs: ih.is.CheckReferences,
+		apply:     ih.is.ImportInstructors,
+		logger:    ih.logger,
+	}.serve(w, r)
+}
+
+// ExportInstructors streams every instructor as JSON Lines, CSV or Parquet.
+func (ih *InstructorHandler) ExportInstructors(w http.ResponseWriter, r *http.Request) {
+	exportRows(w, r, ih.logger, "instructors", ih.is.StreamInstructors)
+}
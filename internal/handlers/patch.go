@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"api-server/internal/problem"
+)
+
+// MergePatchContentType is the media type of RFC 7396 JSON Merge Patch
+// documents. Plain application/json is accepted as well.
+const MergePatchContentType = "application/merge-patch+json"
+
+// decodeMergePatch reads a merge patch from the request body. The patch must
+// be a JSON object; its top-level keys are returned as the fields to update.
+// On failure it writes a 4xx problem and returns false.
+func decodeMergePatch(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+		problem.Error(w, r, http.StatusUnsupportedMediaType, "Content-Type must be "+MergePatchContentType)
+		return nil, false
+	}
+
+	var patch map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		problem.Error(w, r, http.StatusBadRequest, "merge patch must be a JSON object")
+		return nil, false
+	}
+	return patch, true
+}
+
+// applyMergePatch applies patch to the resource v points at, following
+// RFC 7396: members set to null are removed, which resets the field to its
+// zero value, and nested objects are merged recursively. The result is decoded
+// back into v with the same rules as a request body. On failure it writes a
+// 400 problem and returns false.
+func applyMergePatch(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any, patch map[string]any) bool {
+	current, err := json.Marshal(v)
+	if err != nil {
+		problem.WriteError(w, r, logger, err)
+		return false
+	}
+	var target map[string]any
+	if err := json.Unmarshal(current, &target); err != nil {
+		problem.WriteError(w, r, logger, err)
+		return false
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		problem.WriteError(w, r, logger, err)
+		return false
+	}
+	rv := reflect.ValueOf(v).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	return decodeFrom(w, r, bytes.NewReader(merged), v)
+}
+
+// mergePatch implements the MergePatch function of RFC 7396, section 2.
+func mergePatch(target any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
+
+// patchFields returns the top-level members of patch in sorted order. They
+// name the columns a PATCH request changes.
+func patchFields(patch map[string]any) []string {
+	fields := make([]string, 0, len(patch))
+	for name := range patch {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
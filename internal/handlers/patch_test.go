@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"api-server/internal/logging"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A, and a nested merge.
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"term":{"name":"Fall","year":2025}}`, `{"term":{"year":2026}}`, `{"term":{"name":"Fall","year":2026}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			var target, patch, want any
+			json.Unmarshal([]byte(tt.target), &target)
+			json.Unmarshal([]byte(tt.patch), &patch)
+			json.Unmarshal([]byte(tt.want), &want)
+			if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+				t.Errorf("mergePatch = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"merge patch", MergePatchContentType, `{"name":"Cloud"}`, 0},
+		{"plain JSON", "application/json; charset=utf-8", `{"name":"Cloud"}`, 0},
+		{"other media type", "text/plain", `{"name":"Cloud"}`, http.StatusUnsupportedMediaType},
+		{"array", MergePatchContentType, `[{"name":"Cloud"}]`, http.StatusBadRequest},
+		{"null", MergePatchContentType, `null`, http.StatusBadRequest},
+		{"string", MergePatchContentType, `"Cloud"`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/v1/course/1", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			patch, ok := decodeMergePatch(w, r)
+			if tt.status == 0 {
+				if !ok || !reflect.DeepEqual(patch, map[string]any{"name": "Cloud"}) {
+					t.Errorf("decodeMergePatch = %v, %t; want the patch", patch, ok)
+				}
+				return
+			}
+			if ok || w.Code != tt.status {
+				t.Errorf("decodeMergePatch answered %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	capacity := 30
+	tests := []struct {
+		name  string
+		patch string
+		want  decodeTarget
+		ok    bool
+	}{
+		{"set", `{"name":"Networks"}`, decodeTarget{Name: "Networks", Capacity: &capacity, Tags: []string{"aws"}}, true},
+		{"null resets", `{"capacity":null,"tags":null}`, decodeTarget{Name: "Cloud"}, true},
+		{"unknown field", `{"room":"Snell 108"}`, decodeTarget{}, false},
+		{"wrong type", `{"capacity":"thirty"}`, decodeTarget{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch map[string]any
+			json.Unmarshal([]byte(tt.patch), &patch)
+			current := capacity
+			v := decodeTarget{Name: "Cloud", Capacity: &current, Tags: []string{"aws"}}
+			r := httptest.NewRequest(http.MethodPatch, "/v1/course/1", nil)
+			w := httptest.NewRecorder()
+			ok := applyMergePatch(w, r, logging.Discard(), &v, patch)
+			if ok != tt.ok {
+				t.Fatalf("applyMergePatch = %t, want %t: %s", ok, tt.ok, w.Body)
+			}
+			if !ok {
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", w.Code)
+				}
+				return
+			}
+			if !reflect.DeepEqual(v, tt.want) {
+				t.Errorf("patched %+v, want %+v", v, tt.want)
+			}
+		})
+	}
+}
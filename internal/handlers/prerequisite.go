@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type PrerequisiteHandler struct {
+	ps     *service.PrerequisiteService
+	logger *slog.Logger
+}
+
+func NewPrerequisiteHandler(db *sql.DB, logger *slog.Logger) *PrerequisiteHandler {
+	return &PrerequisiteHandler{ps: service.NewPrerequisiteService(db, logger), logger: logger.With("handler", "prerequisite")}
+}
+
+// GetPrerequisites lists the direct requisites of a course, or with
+// ?transitive=true every course its prerequisites lead back to.
+func (ph *PrerequisiteHandler) GetPrerequisites(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	transitive := false
+	if v := r.URL.Query().Get("transitive"); v != "" {
+		var err error
+		if transitive, err = strconv.ParseBool(v); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "transitive must be true or false")
+			return
+		}
+	}
+	if transitive {
+		prerequisites, err := ph.ps.GetTransitivePrerequisites(courseID)
+		if err != nil {
+			problem.WriteError(w, r, ph.logger, err)
+			return
+		}
+		writeCollection(w, r, prerequisites)
+		return
+	}
+	requisites, err := ph.ps.GetPrerequisites(courseID)
+	if err != nil {
+		problem.WriteError(w, r, ph.logger, err)
+		return
+	}
+	writeCollection(w, r, requisites)
+}
+
+// CreatePrerequisite adds a prerequisite or corequisite to a course and
+// responds with it.
+func (ph *PrerequisiteHandler) CreatePrerequisite(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var req model.Requisite
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := ph.ps.AddPrerequisite(serviceContext(r), courseID, &req); err != nil {
+		problem.WriteError(w, r, ph.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(req)
+}
+
+func (ph *PrerequisiteHandler) DeletePrerequisite(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	code := mux.Vars(r)["code"]
+	if err := ph.ps.RemovePrerequisite(serviceContext(r), courseID, code); err != nil {
+		problem.WriteError(w, r, ph.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetEligibility tells whether the user in the user_id query parameter has
+// met a course's requisites.
+func (ph *PrerequisiteHandler) GetEligibility(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, "user_id must be a valid UUID")
+		return
+	}
+	eligibility, err := ph.ps.CheckEligibility(courseID, userID)
+	if err != nil {
+		problem.WriteError(w, r, ph.logger, err)
+		return
+	}
+	json.NewEncoder(w).Encode(eligibility)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// pathID parses the {id} route variable. On failure it writes a 400 problem
+// and returns false.
+func pathID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	return pathUUID(w, r, "id")
+}
+
+// pathUUID parses the named route variable like pathID.
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)[name])
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("%s must be a valid UUID", name))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// decodeJSON decodes the request body into v, rejecting fields v does not
+// declare. On failure it writes a 400 problem describing the offending field,
+// without Go type names, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	return decodeFrom(w, r, r.Body, v)
+}
+
+func decodeFrom(w http.ResponseWriter, r *http.Request, body io.Reader, v any) bool {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return true
+	}
+	problem.Error(w, r, http.StatusBadRequest, decodeDetail(err, "request body"))
+	return false
+}
+
+// decodeDetail describes a JSON decoding error of what, naming the offending
+// field without Go type names.
+func decodeDetail(err error, what string) string {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Sprintf("field %q has the wrong type", typeErr.Field)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields.
+		return strings.TrimPrefix(err.Error(), "json: ")
+	}
+	return what + " must be a valid JSON object"
+}
+
+// validateBody checks v against its validate tags and collects the violations
+// reported by checkRefs, so that clients see every problem at once. On failure
+// it writes a 422 problem listing them and returns false.
+func validateBody(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any, checkRefs func() ([]apperr.FieldViolation, error)) bool {
+	return validateFields(w, r, logger, v, nil, checkRefs)
+}
+
+// validatePatch is validateBody for a patched resource. Only violations of the
+// patched fields are reported, so existing rows that predate a rule can still
+// be patched.
+func validatePatch(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any, fields []string, checkRefs func() ([]apperr.FieldViolation, error)) bool {
+	return validateFields(w, r, logger, v, fields, checkRefs)
+}
+
+func validateFields(w http.ResponseWriter, r *http.Request, logger *slog.Logger, v any, fields []string, checkRefs func() ([]apperr.FieldViolation, error)) bool {
+	if err := service.Validate(v, fields, checkRefs); err != nil {
+		problem.WriteError(w, r, logger, err)
+		return false
+	}
+	return true
+}
+
+// serviceContext returns the context in which r calls the service layer,
+// carrying the client address for the audit log.
+func serviceContext(r *http.Request) context.Context {
+	return audit.WithSourceIP(r.Context(), audit.SourceIP(r))
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	Name     string   `json:"name"`
+	Capacity *int     `json:"capacity"`
+	Tags     []string `json:"tags"`
+}
+
+// problemDetail returns the status and detail of the problem in w.
+func problemDetail(t *testing.T, w *httptest.ResponseRecorder) (int, string) {
+	t.Helper()
+	var p struct {
+		Detail string `json:"detail"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("response is not a problem: %v", err)
+	}
+	return w.Code, p.Detail
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		detail string
+	}{
+		{"valid", `{"name":"Cloud","capacity":30,"tags":["aws"]}`, ""},
+		{"unknown field", `{"name":"Cloud","room":"Snell 108"}`, `unknown field "room"`},
+		{"wrong type", `{"capacity":"thirty"}`, `field "capacity" has the wrong type`},
+		{"not an object", `["Cloud"]`, "request body must be a valid JSON object"},
+		{"malformed", `{"name":`, "request body must be a valid JSON object"},
+		{"empty", ``, "request body must be a valid JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/course", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			var v decodeTarget
+			ok := decodeJSON(w, r, &v)
+			if tt.detail == "" {
+				if !ok {
+					t.Fatalf("decodeJSON rejected a valid body: %s", w.Body)
+				}
+				if v.Name != "Cloud" || v.Capacity == nil || *v.Capacity != 30 || len(v.Tags) != 1 {
+					t.Errorf("decoded %+v", v)
+				}
+				return
+			}
+			if ok {
+				t.Fatal("decodeJSON accepted an invalid body")
+			}
+			if status, detail := problemDetail(t, w); status != http.StatusBadRequest || detail != tt.detail {
+				t.Errorf("problem is %d %q, want 400 %q", status, detail, tt.detail)
+			}
+			if strings.Contains(w.Body.String(), "decodeTarget") {
+				t.Errorf("problem names a Go type: %s", w.Body)
+			}
+		})
+	}
+}
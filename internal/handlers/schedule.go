@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"api-server/internal/ical"
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+)
+
+type ScheduleHandler struct {
+	ss     *service.ScheduleService
+	logger *slog.Logger
+}
+
+func NewScheduleHandler(db *sql.DB, logger *slog.Logger) *ScheduleHandler {
+	return &ScheduleHandler{ss: service.NewScheduleService(db, logger), logger: logger.With("handler", "schedule")}
+}
+
+// meetingPath parses the course and meeting IDs of
+// /courses/{id}/schedule/{meetingID}.
+func meetingPath(w http.ResponseWriter, r *http.Request) (courseID, id uuid.UUID, ok bool) {
+	if courseID, ok = pathID(w, r); !ok {
+		return
+	}
+	id, ok = pathUUID(w, r, "meetingID")
+	return
+}
+
+func (sh *ScheduleHandler) GetCourseMeetings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	meetings, err := sh.ss.GetCourseMeetings(courseID)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	writeCollection(w, r, meetings)
+}
+
+func (sh *ScheduleHandler) GetMeetingByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := meetingPath(w, r)
+	if !ok {
+		return
+	}
+	meeting, err := sh.ss.GetMeetingByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	writeTagged(w, r, etag(meeting.Version), meeting)
+}
+
+// CreateMeeting adds a weekly meeting to a course and responds with it.
+// Meetings that double-book a room or instructor are rejected with 409.
+func (sh *ScheduleHandler) CreateMeeting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var meeting model.Meeting
+	if !decodeJSON(w, r, &meeting) {
+		return
+	}
+	meeting.CourseID = courseID
+	created, err := sh.ss.CreateMeeting(serviceContext(r), &meeting)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(meeting.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (sh *ScheduleHandler) PatchMeeting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := meetingPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	meeting, err := sh.ss.GetMeetingByID(courseID, id)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, sh.logger, meeting, patch) {
+		return
+	}
+	if _, err := sh.ss.PatchMeeting(serviceContext(r), courseID, id, meeting, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(meeting.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sh *ScheduleHandler) DeleteMeeting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	courseID, id, ok := meetingPath(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := sh.ss.DeleteMeeting(serviceContext(r), courseID, id, ifm); err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCourseCalendar exports a course's meetings as an iCalendar file.
+func (sh *ScheduleHandler) GetCourseCalendar(w http.ResponseWriter, r *http.Request) {
+	sh.writeCalendar(w, r, "course", sh.ss.GetCourseSchedule)
+}
+
+// GetInstructorCalendar exports the meetings of every course an instructor
+// teaches as an iCalendar file.
+func (sh *ScheduleHandler) GetInstructorCalendar(w http.ResponseWriter, r *http.Request) {
+	sh.writeCalendar(w, r, "instructor", sh.ss.GetInstructorSchedule)
+}
+
+// GetStudentCalendar exports the meetings of every course a user is
+// enrolled in as an iCalendar file.
+func (sh *ScheduleHandler) GetStudentCalendar(w http.ResponseWriter, r *http.Request) {
+	sh.writeCalendar(w, r, "user", sh.ss.GetStudentSchedule)
+}
+
+func (sh *ScheduleHandler) writeCalendar(w http.ResponseWriter, r *http.Request, resource string, load func(uuid.UUID) ([]model.ScheduledMeeting, error)) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	meetings, err := load(id)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.ics"`, resource, id))
+	if err := ical.Write(w, fmt.Sprintf("%s %s schedule", resource, id), calendarEvents(meetings)); err != nil {
+		sh.logger.WarnContext(r.Context(), "calendar export interrupted", "resource", resource, "resource_id", id, "error", err)
+	}
+}
+
+// weekdayCodes maps Go weekdays to iCalendar day codes.
+var weekdayCodes = [...]string{time.Sunday: "SU", time.Monday: "MO", time.Tuesday: "TU", time.Wednesday: "WE", time.Thursday: "TH", time.Friday: "FR", time.Saturday: "SA"}
+
+// calendarEvents turns meetings into weekly recurring events that start on
+// the first meeting day of the term and repeat until the term ends.
+// Meetings whose days never fall within their term are left out.
+func calendarEvents(meetings []model.ScheduledMeeting) []ical.Event {
+	events := make([]ical.Event, 0, len(meetings))
+	for _, m := range meetings {
+		termStart, err1 := time.Parse(time.DateOnly, m.TermStart)
+		termEnd, err2 := time.Parse(time.DateOnly, m.TermEnd)
+		start, err3 := time.Parse("15:04", m.StartTime)
+		end, err4 := time.Parse("15:04", m.EndTime)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+
+		var first time.Time
+		for i := 0; i < 7 && first.IsZero(); i++ {
+			if d := termStart.AddDate(0, 0, i); slices.Contains(m.Days, weekdayCodes[d.Weekday()]) {
+				first = d
+			}
+		}
+		if first.IsZero() || first.After(termEnd) {
+			continue
+		}
+
+		days := slices.Clone(m.Days)
+		slices.SortFunc(days, func(a, b string) int {
+			return slices.Index(model.Weekdays, a) - slices.Index(model.Weekdays, b)
+		})
+		location := "Online"
+		if m.Room != nil {
+			location = *m.Room
+		}
+		events = append(events, ical.Event{
+			UID:         m.ID.String() + "@api-server",
+			Summary:     m.CourseCode + " " + m.CourseName,
+			Location:    location,
+			Description: strings.ToUpper(m.Modality[:1]) + m.Modality[1:] + " meeting of " + m.CourseCode,
+			Start:       first.Add(sinceMidnight(start)),
+			End:         first.Add(sinceMidnight(end)),
+			RRule:       "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ",") + ";UNTIL=" + termEnd.Format("20060102") + "T235959",
+		})
+	}
+	return events
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
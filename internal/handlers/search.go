@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/repository"
+)
+
+// Result counts for GET /search.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	sr     *repository.SearchRepository
+	logger *slog.Logger
+}
+
+func NewSearchHandler(db *sql.DB, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{sr: repository.NewSearchRepository(db), logger: logger.With("handler", "search")}
+}
+
+// Search ranks courses, instructors and traces against the q query
+// parameter. type narrows the results to a comma-separated list of resource
+// types and limit caps their number.
+func (sh *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query, ok := searchQuery(w, r)
+	if !ok {
+		return
+	}
+	results, err := sh.sr.Search(r.Context(), query)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	json.NewEncoder(w).Encode(results)
+}
+
+// searchQuery parses the q, type and limit query parameters. On failure it
+// writes a 400 problem and returns false.
+func searchQuery(w http.ResponseWriter, r *http.Request) (model.SearchQuery, bool) {
+	q := r.URL.Query()
+	query := model.SearchQuery{Query: strings.TrimSpace(q.Get("q")), Types: model.SearchTypes, Limit: defaultSearchLimit}
+	if query.Query == "" {
+		problem.Error(w, r, http.StatusBadRequest, "q is required")
+		return query, false
+	}
+	if v := q.Get("type"); v != "" {
+		query.Types = nil
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(model.SearchTypes, t) {
+				problem.Error(w, r, http.StatusBadRequest, "type must be a comma-separated list of "+strings.Join(model.SearchTypes, ", "))
+				return query, false
+			}
+			query.Types = append(query.Types, t)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			problem.Error(w, r, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+			return query, false
+		}
+		query.Limit = n
+	}
+	return query, true
+}
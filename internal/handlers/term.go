@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+)
+
+// termStatuses are the values accepted by the status and term_status query
+// parameters.
+var termStatuses = []string{model.TermUpcoming, model.TermCurrent, model.TermArchived}
+
+type TermHandler struct {
+	ts     *service.TermService
+	logger *slog.Logger
+}
+
+func NewTermHandler(db *sql.DB, logger *slog.Logger) *TermHandler {
+	return &TermHandler{ts: service.NewTermService(db, logger), logger: logger.With("handler", "term")}
+}
+
+func (th *TermHandler) GetTerms(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(termStatuses, status) {
+		problem.Error(w, r, http.StatusBadRequest, "status must be one of upcoming, current, archived")
+		return
+	}
+	terms, err := th.ts.GetAllTerms(status)
+	if err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	writeCollection(w, r, terms)
+}
+
+func (th *TermHandler) GetTermByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	term, err := th.ts.GetTermByID(id)
+	if err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	writeTagged(w, r, etag(term.Version), term)
+}
+
+func (th *TermHandler) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var term model.Term
+	if !decodeJSON(w, r, &term) {
+		return
+	}
+	if _, err := th.ts.CreateTerm(serviceContext(r), &term); err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(term.Version))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (th *TermHandler) UpdateTerm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	var term model.Term
+	if !decodeJSON(w, r, &term) {
+		return
+	}
+	if _, err := th.ts.UpdateTerm(serviceContext(r), id, &term, ifm); err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(term.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (th *TermHandler) PatchTerm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	term, err := th.ts.GetTermByID(id)
+	if err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, th.logger, term, patch) {
+		return
+	}
+	if _, err := th.ts.PatchTerm(serviceContext(r), id, term, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(term.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (th *TermHandler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := th.ts.DeleteTerm(serviceContext(r), id, ifm); err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (th *TermHandler) RestoreTerm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	version, err := th.ts.RestoreTerm(serviceContext(r), id, ifm)
+	if err != nil {
+		problem.WriteError(w, r, th.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/metrics"
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/repository"
+	"api-server/internal/tracestatus"
+)
+
+const (
+	// heartbeatInterval is how often an idle stream sends a comment, so that
+	// proxies do not close it.
+	heartbeatInterval = 15 * time.Second
+	// reconnectDelay is the reconnection time, in milliseconds, suggested to
+	// clients.
+	reconnectDelay = 3000
+)
+
+// TraceStatusHandler streams trace status changes as server-sent events.
+// Each event is named status and carries a model.TraceStatusEvent; its ID
+// is the time of the change in Unix microseconds. A stream first sends the
+// current state and then every change as it happens. When the stream ends
+// the client should reconnect, sending Last-Event-ID, to resynchronise.
+type TraceStatusHandler struct {
+	tr     *repository.TraceRepository
+	ur     *repository.UserRepository
+	hub    *tracestatus.Hub
+	logger *slog.Logger
+}
+
+func NewTraceStatusHandler(db *sql.DB, hub *tracestatus.Hub, logger *slog.Logger) *TraceStatusHandler {
+	return &TraceStatusHandler{tr: repository.NewTraceRepository(db), ur: repository.NewUserRepository(db), hub: hub, logger: logger.With("handler", "trace_status")}
+}
+
+// StreamTrace streams the status of one trace, starting with its current
+// status.
+func (sh *TraceStatusHandler) StreamTrace(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	events, cancel := sh.hub.Subscribe(func(ev *model.TraceStatusEvent) bool { return ev.TraceID == id })
+	defer cancel()
+
+	current, err := sh.tr.GetTraceStatus(r.Context(), id)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	sh.logger.DebugContext(r.Context(), "streaming trace status", "trace_record_id", id, "status", current.Status)
+	sh.stream(w, r, []model.TraceStatusEvent{*current}, events)
+}
+
+// StreamUserTraces streams the status of the authenticated user's traces,
+// starting with those still being processed and, given Last-Event-ID, those
+// changed since that event.
+func (sh *TraceStatusHandler) StreamUserTraces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var since time.Time
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		us, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "Last-Event-ID must be an event ID from this stream")
+			return
+		}
+		since = time.UnixMicro(us).UTC()
+	}
+	userID, err := sh.ur.GetUserIDByUsername(actor.FromContext(ctx))
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	events, cancel := sh.hub.Subscribe(func(ev *model.TraceStatusEvent) bool { return ev.UserID == userID })
+	defer cancel()
+
+	current, err := sh.tr.GetUserTraceStatuses(ctx, userID, since)
+	if err != nil {
+		problem.WriteError(w, r, sh.logger, err)
+		return
+	}
+	sh.logger.DebugContext(ctx, "streaming user trace statuses", "user_id", userID, "pending", len(current))
+	sh.stream(w, r, current, events)
+}
+
+// stream writes current and then events as they arrive, until the client
+// goes away or events is closed.
+func (sh *TraceStatusHandler) stream(w http.ResponseWriter, r *http.Request, current []model.TraceStatusEvent, events <-chan model.TraceStatusEvent) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+
+	metrics.TraceStatusStreams.Inc()
+	defer metrics.TraceStatusStreams.Dec()
+
+	for i := range current {
+		if err := writeStatusEvent(w, &current[i]); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		sh.logger.ErrorContext(ctx, "cannot stream trace status", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				// The hub may have missed changes; make the client resync.
+				sh.logger.DebugContext(ctx, "trace status subscription ended")
+				return
+			}
+			err = writeStatusEvent(w, &ev)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			if context.Cause(ctx) == nil {
+				sh.logger.InfoContext(ctx, "trace status stream closed", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// writeStatusEvent writes ev as one server-sent event.
+func writeStatusEvent(w http.ResponseWriter, ev *model.TraceStatusEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: status\ndata: %s\n\n", ev.At.UnixMicro(), data)
+	return err
+}
@@ -2,122 +2,152 @@ package handlers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"log/slog"
 	"net/http"
 
 	"api-server/internal/model"
-	"api-server/internal/repository"
-
-	"github.com/google/uuid"
-	"github.com/gorilla/mux"
-	"github.com/lib/pq"
+	"api-server/internal/problem"
+	"api-server/internal/service"
 )
 
 type UserHandler struct {
-	ur *repository.UserRepository
+	us     *service.UserService
+	logger *slog.Logger
 }
 
-func NewUserHandler(db *sql.DB) *UserHandler {
-	return &UserHandler{ur: repository.NewUserRepository(db)}
+func NewUserHandler(db *sql.DB, logger *slog.Logger) *UserHandler {
+	return &UserHandler{us: service.NewUserService(db, logger), logger: logger.With("handler", "user")}
 }
 
 func (uh *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	users, err := uh.ur.GetAllUsers()
+	users, err := uh.us.GetAllUsers()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(users)
+	writeCollection(w, r, users)
 }
 
 func (uh *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
-	user, err := uh.ur.GetUserByID(id)
+	user, err := uh.us.GetUserByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
-	json.NewEncoder(w).Encode(user)
+	writeTagged(w, r, etag(user.Version), user)
 }
 
 func (uh *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var user model.User
-	err := json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &user) {
 		return
 	}
-	err = uh.ur.CreateUser(&user)
-	if err != nil {
-		// Check for duplicate key violation (PostgreSQL error code 23505)
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error":   "Username already exists",
-				"details": err.Error(),
-			})
-			return
-		}
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "Failed to create user",
-			"details": err.Error(),
-		})
+	if _, err := uh.us.CreateUser(serviceContext(r), &user); err != nil {
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(user.Version))
 	w.WriteHeader(http.StatusCreated)
 }
 
 func (uh *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 	var user model.User
-	err = json.NewDecoder(r.Body).Decode(&user)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if !decodeJSON(w, r, &user) {
+		return
+	}
+	if _, err := uh.us.UpdateUser(serviceContext(r), id, &user, ifm); err != nil {
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
-	err = uh.ur.UpdateUser(id, &user)
+	w.Header().Set("ETag", etag(user.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (uh *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	user, err := uh.us.GetUserByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, uh.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, uh.logger, user, patch) {
+		return
+	}
+	if _, err := uh.us.PatchUser(serviceContext(r), id, user, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(user.Version))
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	idStr := vars["id"]
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := uh.us.DeleteUser(serviceContext(r), id, ifm); err != nil {
+		problem.WriteError(w, r, uh.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (uh *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
-	err = uh.ur.DeleteUser(id)
+	version, err := uh.us.RestoreUser(serviceContext(r), id, ifm)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		problem.WriteError(w, r, uh.logger, err)
 		return
 	}
+	w.Header().Set("ETag", etag(version))
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Page sizes for GET /webhooks/{id}/deliveries.
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// deliveryStatuses are the values accepted by the status query parameter.
+var deliveryStatuses = []string{model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed}
+
+type WebhookHandler struct {
+	ws     *service.WebhookService
+	logger *slog.Logger
+}
+
+func NewWebhookHandler(db *sql.DB, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{ws: service.NewWebhookService(db, logger), logger: logger.With("handler", "webhook")}
+}
+
+// deliveryPath parses the webhook and delivery IDs of
+// /webhooks/{id}/deliveries/{deliveryID}.
+func deliveryPath(w http.ResponseWriter, r *http.Request) (webhookID uuid.UUID, id int64, ok bool) {
+	if webhookID, ok = pathID(w, r); !ok {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["deliveryID"], 10, 64)
+	if err != nil || id < 1 {
+		problem.Error(w, r, http.StatusBadRequest, "deliveryID must be a positive integer")
+		return webhookID, 0, false
+	}
+	return webhookID, id, true
+}
+
+func (wh *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	webhooks, err := wh.ws.GetAllWebhooks()
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	writeCollection(w, r, webhooks)
+}
+
+func (wh *WebhookHandler) GetWebhookByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	hook, err := wh.ws.GetWebhookByID(id)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	writeTagged(w, r, etag(hook.Version), hook)
+}
+
+// CreateWebhook subscribes a URL to events and responds with the webhook.
+// A secret is generated unless one is given; this response is the only one
+// that includes it.
+func (wh *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	hook := model.Webhook{Active: true}
+	if !decodeJSON(w, r, &hook) {
+		return
+	}
+	created, err := wh.ws.CreateWebhook(serviceContext(r), &hook)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(hook.Version))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// PatchWebhook changes a webhook. Patching secret rotates it; set active to
+// false to pause deliveries.
+func (wh *WebhookHandler) PatchWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := decodeMergePatch(w, r)
+	if !ok {
+		return
+	}
+	hook, err := wh.ws.GetWebhookByID(id)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	if !applyMergePatch(w, r, wh.logger, hook, patch) {
+		return
+	}
+	if _, err := wh.ws.PatchWebhook(serviceContext(r), id, hook, patchFields(patch), ifm); err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(hook.Version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (wh *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	if err := wh.ws.DeleteWebhook(serviceContext(r), id, ifm); err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (wh *WebhookHandler) RestoreWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	ifm, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+	version, err := wh.ws.RestoreWebhook(serviceContext(r), id, ifm)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries lists the deliveries of a webhook, newest first, optionally
+// limited to one status. Pass the smallest id of a page as before_id to
+// fetch the next one.
+func (wh *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	filter := model.WebhookDeliveryFilter{Status: q.Get("status"), Limit: defaultDeliveryLimit}
+	if filter.Status != "" && !slices.Contains(deliveryStatuses, filter.Status) {
+		problem.Error(w, r, http.StatusBadRequest, "status must be one of "+strings.Join(deliveryStatuses, ", "))
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryLimit))
+			return
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("before_id"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			problem.Error(w, r, http.StatusBadRequest, "before_id must be a positive integer")
+			return
+		}
+		filter.BeforeID = n
+	}
+
+	deliveries, err := wh.ws.ListWebhookDeliveries(r.Context(), id, filter)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// GetDelivery returns a delivery with its payload and the log of every
+// attempt to send it.
+func (wh *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	webhookID, id, ok := deliveryPath(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := wh.ws.GetWebhookDelivery(r.Context(), webhookID, id)
+	if err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// Redeliver queues a succeeded or failed delivery to be sent again. The
+// receiver gets the original payload and delivery ID.
+func (wh *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	webhookID, id, ok := deliveryPath(w, r)
+	if !ok {
+		return
+	}
+	if err := wh.ws.RedeliverWebhookDelivery(r.Context(), webhookID, id); err != nil {
+		problem.WriteError(w, r, wh.logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,85 @@
+// Package ical writes iCalendar (RFC 5545) calendars of recurring events.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of iCalendar documents.
+const ContentType = "text/calendar; charset=utf-8"
+
+// prodID identifies this server as the producer of calendars.
+const prodID = "-//csye7125//api-server//EN"
+
+// localLayout formats floating local times, which calendar clients show in
+// the viewer's time zone as written.
+const localLayout = "20060102T150405"
+
+// Event is a calendar event. Start and End are floating local times; only
+// their wall-clock values are used. A non-empty RRule makes the event recur,
+// for example "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20260515T235959".
+type Event struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Start       time.Time
+	End         time.Time
+	RRule       string
+}
+
+// Write writes a calendar named name containing events to w.
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC().Format("20060102T150405Z")
+
+	line(bw, "BEGIN:VCALENDAR")
+	line(bw, "VERSION:2.0")
+	line(bw, "PRODID:"+prodID)
+	line(bw, "CALSCALE:GREGORIAN")
+	line(bw, "X-WR-CALNAME:"+escape(name))
+	for _, e := range events {
+		line(bw, "BEGIN:VEVENT")
+		line(bw, "UID:"+escape(e.UID))
+		line(bw, "DTSTAMP:"+now)
+		line(bw, "DTSTART:"+e.Start.Format(localLayout))
+		line(bw, "DTEND:"+e.End.Format(localLayout))
+		if e.RRule != "" {
+			line(bw, "RRULE:"+e.RRule)
+		}
+		line(bw, "SUMMARY:"+escape(e.Summary))
+		if e.Location != "" {
+			line(bw, "LOCATION:"+escape(e.Location))
+		}
+		if e.Description != "" {
+			line(bw, "DESCRIPTION:"+escape(e.Description))
+		}
+		line(bw, "END:VEVENT")
+	}
+	line(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escape escapes TEXT property values.
+var escape = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace
+
+// line writes a content line terminated by CRLF, folding it so that no line
+// exceeds 75 octets. Folds never split a UTF-8 sequence.
+func line(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,315 @@
+// Package idempotency lets clients retry POST requests safely. A request
+// sent with an Idempotency-Key header is processed once; retries with the
+// same key replay the stored response instead of repeating the request.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/model"
+	"api-server/internal/problem"
+	"api-server/internal/repository"
+	"api-server/internal/requestid"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/google/uuid"
+)
+
+// Header is the request header carrying the client's idempotency key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks a response replayed from an earlier request.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Limits on requests sent with an idempotency key.
+const (
+	maxKeyLength = 255
+	maxBodySize  = 16 << 20
+	// maxMemory matches the limit r.FormFile parses multipart forms with, so
+	// handlers reuse the form parsed here.
+	maxMemory = 32 << 20
+)
+
+// skippedHeaders describe a single response and are not stored.
+var skippedHeaders = []string{requestid.Header, "Date", "Content-Length"}
+
+// Config controls how long idempotency keys are kept and locked.
+type Config struct {
+	TTL time.Duration
+	// Lease is how long a request holds its key without renewing it. A
+	// request keeps renewing its lease while it is processed, so a retry
+	// takes the key over only once the process handling it has crashed.
+	Lease time.Duration
+}
+
+// LoadConfig reads IDEMPOTENCY_KEY_TTL (default 24h) and
+// IDEMPOTENCY_KEY_LEASE (default 1m) as Go durations.
+func LoadConfig() (Config, error) {
+	ttl, err := durationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	lease, err := durationEnv("IDEMPOTENCY_KEY_LEASE", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{TTL: ttl, Lease: lease}, nil
+}
+
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as %s", name, v, def)
+	}
+	return d, nil
+}
+
+// Middleware makes POST requests with an Idempotency-Key header idempotent
+// per authenticated user. The first request with a key is processed and its
+// response stored; retries replay that response. Reusing a key for a
+// different request is a 422, and retrying while the first request is still
+// in progress is a 409. A request that is not renewing its lease any more,
+// because the process handling it crashed, is taken over by its retry.
+// Server errors are not stored, so such requests can be retried with the
+// same key. It must run after BasicAuth.
+func Middleware(db *sql.DB, cfg Config, logger *slog.Logger) func(http.Handler) http.Handler {
+	repo := repository.NewIdempotencyRepository(db)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("%s must not exceed %d characters", Header, maxKeyLength))
+				return
+			}
+
+			fingerprint, err := fingerprintRequest(w, r)
+			var maxErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxErr):
+				problem.Error(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must not exceed %d bytes", maxBodySize))
+				return
+			case err != nil:
+				problem.Error(w, r, http.StatusBadRequest, "request body could not be read")
+				return
+			}
+
+			username := actor.FromContext(r.Context())
+			owner := uuid.New()
+			stored, err := repo.ClaimIdempotencyKey(r.Context(), username, key, fingerprint, owner, cfg.TTL, cfg.Lease)
+			if err != nil {
+				problem.WriteError(w, r, logger, err)
+				return
+			}
+			if stored != nil {
+				switch {
+				case stored.Fingerprint != fingerprint:
+					problem.Error(w, r, http.StatusUnprocessableEntity, Header+" was already used for a different request")
+				case stored.Status == 0:
+					problem.Error(w, r, http.StatusConflict, "a request with this "+Header+" is still being processed")
+				default:
+					logger.InfoContext(r.Context(), "replaying idempotent response", "path", r.URL.Path, "status", stored.Status)
+					replay(w, stored)
+				}
+				return
+			}
+
+			stopRenewing := renewLease(r.Context(), repo, username, key, owner, cfg.Lease, logger)
+			rec := &recorder{}
+			completed := false
+			defer func() {
+				stopRenewing()
+				// Store the outcome even if the client has gone away, so that
+				// its retry is answered from the stored response.
+				ctx := context.WithoutCancel(r.Context())
+				if !completed || rec.status >= http.StatusInternalServerError {
+					if err := repo.ReleaseIdempotencyKey(ctx, username, key, owner); err != nil {
+						logger.ErrorContext(ctx, "failed to release idempotency key", "error", err)
+					}
+					return
+				}
+				if err := repo.SaveIdempotentResponse(ctx, username, key, owner, rec.response(fingerprint)); err != nil {
+					logger.ErrorContext(ctx, "failed to store idempotent response", "error", err)
+				}
+			}()
+			next.ServeHTTP(rec.wrap(w), r)
+			completed = true
+		})
+	}
+}
+
+// renewLease extends the lease owner holds on key every third of lease
+// until the returned function is called, which waits for renewal to stop.
+func renewLease(ctx context.Context, repo *repository.IdempotencyRepository, actor, key string, owner uuid.UUID, lease time.Duration, logger *slog.Logger) func() {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := repo.RenewIdempotencyLock(ctx, actor, key, owner, lease); err != nil && ctx.Err() == nil {
+					logger.WarnContext(ctx, "failed to renew idempotency key lease", "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// fingerprintRequest hashes the method, URL and body of r. Multipart forms
+// are hashed by their fields and file contents rather than their bytes, so
+// that a retry with a new boundary matches. The body is restored for the
+// handler.
+func fingerprintRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	h := sha256.New()
+	writeField(h, r.Method)
+	writeField(h, r.URL.RequestURI())
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return "", err
+		}
+		form := r.MultipartForm
+		for _, name := range sortedKeys(form.Value) {
+			writeField(h, name)
+			for _, v := range form.Value[name] {
+				writeField(h, v)
+			}
+		}
+		for _, name := range sortedKeys(form.File) {
+			writeField(h, name)
+			for _, fh := range form.File[name] {
+				writeField(h, fh.Filename)
+				f, err := fh.Open()
+				if err != nil {
+					return "", err
+				}
+				_, err = io.Copy(h, f)
+				f.Close()
+				if err != nil {
+					return "", err
+				}
+				h.Write([]byte{0})
+			}
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeField writes s to h followed by a separator, so that adjacent fields
+// cannot be confused.
+func writeField(h hash.Hash, s string) {
+	io.WriteString(h, s)
+	h.Write([]byte{0})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// replay writes a stored response.
+func replay(w http.ResponseWriter, stored *model.IdempotentResponse) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// recorder captures the status, headers and body of a response while it is
+// written to the client.
+type recorder struct {
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rec *recorder) wrap(w http.ResponseWriter) http.ResponseWriter {
+	writeHeader := func(code int) {
+		if rec.status == 0 {
+			rec.status = code
+			rec.header = w.Header().Clone()
+		}
+	}
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				writeHeader(code)
+				next(code)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				writeHeader(http.StatusOK)
+				n, err := next(b)
+				rec.body.Write(b[:n])
+				return n, err
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				writeHeader(http.StatusOK)
+				return next(io.TeeReader(src, &rec.body))
+			}
+		},
+	})
+}
+
+// response returns the recorded response for storage.
+func (rec *recorder) response(fingerprint string) *model.IdempotentResponse {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+		rec.header = http.Header{}
+	}
+	for _, name := range skippedHeaders {
+		rec.header.Del(name)
+	}
+	return &model.IdempotentResponse{Fingerprint: fingerprint, Status: rec.status, Header: rec.header, Body: rec.body.Bytes()}
+}
@@ -0,0 +1,272 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/logging"
+	"api-server/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// testConfig has a lease long enough that no test renews it.
+var testConfig = Config{TTL: 24 * time.Hour, Lease: time.Hour}
+
+func newRequest(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/course", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(Header, "key-1")
+	return r.WithContext(actor.NewContext(r.Context(), "registrar"))
+}
+
+func multipartRequest(t *testing.T, boundary, trace string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.SetBoundary(boundary); err != nil {
+		t.Fatal(err)
+	}
+	mw.WriteField("instructor_id", "7")
+	fw, err := mw.CreateFormFile("trace", "trace.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(trace))
+	mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/v1/course/1/trace", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func fingerprint(t *testing.T, r *http.Request) string {
+	t.Helper()
+	fp, err := fingerprintRequest(httptest.NewRecorder(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fp
+}
+
+func TestFingerprintRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b func(t *testing.T) *http.Request
+		same bool
+	}{
+		{
+			"same body",
+			func(*testing.T) *http.Request { return newRequest(`{"name":"Cloud"}`) },
+			func(*testing.T) *http.Request { return newRequest(`{"name":"Cloud"}`) },
+			true,
+		},
+		{
+			"different body",
+			func(*testing.T) *http.Request { return newRequest(`{"name":"Cloud"}`) },
+			func(*testing.T) *http.Request { return newRequest(`{"name":"Networks"}`) },
+			false,
+		},
+		{
+			"different path",
+			func(*testing.T) *http.Request { return newRequest(`{}`) },
+			func(*testing.T) *http.Request {
+				r := newRequest(`{}`)
+				r.URL.Path = "/v1/instructor"
+				return r
+			},
+			false,
+		},
+		{
+			"multipart with a new boundary",
+			func(t *testing.T) *http.Request { return multipartRequest(t, "first", "%PDF-1.7") },
+			func(t *testing.T) *http.Request { return multipartRequest(t, "second", "%PDF-1.7") },
+			true,
+		},
+		{
+			"multipart with a different file",
+			func(t *testing.T) *http.Request { return multipartRequest(t, "first", "%PDF-1.7") },
+			func(t *testing.T) *http.Request { return multipartRequest(t, "first", "%PDF-1.4") },
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := fingerprint(t, tt.a(t)), fingerprint(t, tt.b(t))
+			if (a == b) != tt.same {
+				t.Errorf("fingerprints %s and %s: equal = %t, want %t", a, b, a == b, tt.same)
+			}
+		})
+	}
+}
+
+func TestFingerprintRequestRestoresBody(t *testing.T) {
+	r := newRequest(`{"name":"Cloud"}`)
+	fingerprint(t, r)
+	var body bytes.Buffer
+	body.ReadFrom(r.Body)
+	if got := body.String(); got != `{"name":"Cloud"}` {
+		t.Errorf("body after fingerprinting = %q, want the original", got)
+	}
+}
+
+// serve runs r through the middleware in front of next, recovering a panic
+// the way the server does.
+func serve(db *sql.DB, next http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	defer func() { recover() }()
+	Middleware(db, testConfig, logging.Discard())(next).ServeHTTP(w, r)
+	return w
+}
+
+func expectClaim(mock sqlmock.Sqlmock, fp driver.Value, claimed bool) {
+	mock.ExpectExec("DELETE FROM api.idempotency_key WHERE .* expires_at <= CURRENT_TIMESTAMP").
+		WithArgs("registrar", "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	var n int64
+	if claimed {
+		n = 1
+	}
+	mock.ExpectExec("INSERT INTO api.idempotency_key").
+		WithArgs("registrar", "key-1", fp, sqlmock.AnyArg(), testConfig.Lease.Seconds(), testConfig.TTL.Seconds()).
+		WillReturnResult(sqlmock.NewResult(0, n))
+}
+
+func TestMiddlewareStoresResponse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fp := fingerprint(t, newRequest(`{"name":"Cloud"}`))
+	expectClaim(mock, fp, true)
+	mock.ExpectExec("UPDATE api.idempotency_key SET status = .*, locked_by = NULL").
+		WithArgs(http.StatusCreated, []byte(`{"Content-Type":["application/json"]}`), []byte(`{"id":1}`), "registrar", "key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := serve(db, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}, newRequest(`{"name":"Cloud"}`))
+	if w.Code != http.StatusCreated || w.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("response is %d, replayed %q; want the handler's 201", w.Code, w.Header().Get(ReplayedHeader))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMiddlewareAnswersClaimedKey(t *testing.T) {
+	fp := fingerprint(t, newRequest(`{"name":"Cloud"}`))
+	tests := []struct {
+		name        string
+		fingerprint string
+		status      any
+		want        int
+		replayed    bool
+	}{
+		{"replay", fp, http.StatusCreated, http.StatusCreated, true},
+		{"different request", "other", http.StatusCreated, http.StatusUnprocessableEntity, false},
+		{"in progress", fp, nil, http.StatusConflict, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			expectClaim(mock, fp, false)
+			mock.ExpectQuery("SELECT fingerprint, status, headers, body FROM api.idempotency_key").
+				WithArgs("registrar", "key-1").
+				WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status", "headers", "body"}).
+					AddRow(tt.fingerprint, tt.status, []byte(`{"Content-Type":["application/json"]}`), []byte(`{"id":1}`)))
+
+			w := serve(db, func(http.ResponseWriter, *http.Request) {
+				t.Error("handler ran for a claimed key")
+			}, newRequest(`{"name":"Cloud"}`))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if replayed := w.Header().Get(ReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Errorf("replayed = %t, want %t", replayed, tt.replayed)
+			}
+			if tt.replayed && (w.Body.String() != `{"id":1}` || w.Header().Get("Content-Type") != "application/json") {
+				t.Errorf("replayed %q with Content-Type %q, want the stored response", w.Body, w.Header().Get("Content-Type"))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMiddlewareReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{"panic", func(http.ResponseWriter, *http.Request) {
+			panic("handler failed")
+		}},
+		{"client gone", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			expectClaim(mock, sqlmock.AnyArg(), true)
+			mock.ExpectExec("DELETE FROM api.idempotency_key WHERE .* locked_by = ").
+				WithArgs("registrar", "key-1", sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			serve(db, tt.handler, newRequest(`{"name":"Cloud"}`))
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRenewLease(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	owner := uuid.New()
+	lease := 30 * time.Millisecond
+	mock.ExpectExec("UPDATE api.idempotency_key SET locked_until").
+		WithArgs(lease.Seconds(), "registrar", "key-1", owner).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stop := renewLease(context.Background(), repository.NewIdempotencyRepository(db), "registrar", "key-1", owner, lease, logging.Discard())
+	defer stop()
+	for deadline := time.Now().Add(time.Second); mock.ExpectationsWereMet() != nil; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("lease was not renewed")
+		}
+	}
+}
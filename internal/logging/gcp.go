@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"cloud.google.com/go/logging"
+	"go.opentelemetry.io/otel/trace"
+	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
+)
+
+// GCPHandler writes records to Google Cloud Logging.
+type GCPHandler struct {
+	level     slog.Leveler
+	projectID string
+	logger    *logging.Logger
+	goas      []groupOrAttrs
+}
+
+// NewGCPHandler creates a Cloud Logging client for projectID and returns a
+// handler writing to logName. The close function flushes and closes the client.
+func NewGCPHandler(ctx context.Context, projectID, logName string, level slog.Leveler) (*GCPHandler, func() error, error) {
+	if projectID == "" {
+		return nil, nil, fmt.Errorf("PROJECT_ID is required for the gcp log sink")
+	}
+	client, err := logging.NewClient(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create cloud logging client: %w", err)
+	}
+
+	logger := client.Logger(logName, logging.CommonResource(&monitoredrespb.MonitoredResource{
+		Type: "k8s_container",
+		Labels: map[string]string{
+			"project_id":     projectID,
+			"namespace_name": "api-server",
+			"container_name": "api-server",
+			"cluster_name":   "my-gke-cluster",
+			"location":       "us-east1",
+			"pod_name":       os.Getenv("HOSTNAME"),
+		},
+	}))
+
+	h := &GCPHandler{level: level, projectID: projectID, logger: logger}
+	return h, client.Close, nil
+}
+
+func (h *GCPHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *GCPHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.logger.Log(h.entry(ctx, r))
+	return nil
+}
+
+// entry maps r to a Cloud Logging entry, linking it to the span in ctx.
+func (h *GCPHandler) entry(ctx context.Context, r slog.Record) logging.Entry {
+	fields := recordFields(h.goas, r)
+	fields["message"] = r.Message
+
+	entry := logging.Entry{
+		Timestamp: r.Time,
+		Severity:  severity(r.Level),
+		Payload:   fields,
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.Trace = fmt.Sprintf("projects/%s/traces/%s", h.projectID, sc.TraceID())
+		entry.SpanID = sc.SpanID().String()
+		entry.TraceSampled = sc.IsSampled()
+	}
+	return entry
+}
+
+func (h *GCPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+func (h *GCPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{group: name})
+	return &h2
+}
+
+func severity(level slog.Level) logging.Severity {
+	switch {
+	case level >= slog.LevelError:
+		return logging.Error
+	case level >= slog.LevelWarn:
+		return logging.Warning
+	case level >= slog.LevelInfo:
+		return logging.Info
+	default:
+		return logging.Debug
+	}
+}
@@ -0,0 +1,201 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"api-server/internal/requestid"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sink names accepted in LOG_SINK. The in-memory sink used by tests is not
+// one of them; it is only available through NewMemory.
+const (
+	SinkJSON = "json"
+	SinkGCP  = "gcp"
+	SinkOTLP = "otlp"
+)
+
+type Config struct {
+	Level     slog.Level
+	Sink      string // "json", "gcp" or "otlp"
+	ProjectID string
+	LogName   string
+}
+
+// LoadConfig reads the logger configuration from the environment.
+func LoadConfig() (*Config, error) {
+	level, err := ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return nil, err
+	}
+
+	sink := strings.ToLower(os.Getenv("LOG_SINK"))
+	if sink == "" {
+		sink = SinkJSON
+	}
+
+	logName := os.Getenv("LOG_NAME")
+	if logName == "" {
+		logName = "api-server"
+	}
+
+	return &Config{
+		Level:     level,
+		Sink:      sink,
+		ProjectID: os.Getenv("PROJECT_ID"),
+		LogName:   logName,
+	}, nil
+}
+
+// ParseLevel converts a level name such as "debug" or "warn" into a slog.Level.
+// An empty string yields slog.LevelInfo.
+func ParseLevel(s string) (slog.Level, error) {
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid LOG_LEVEL %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// New builds a logger for the configured sink. The returned close function
+// flushes any buffered entries and must be called on shutdown.
+func New(ctx context.Context, cfg *Config) (*slog.Logger, func() error, error) {
+	switch cfg.Sink {
+	case SinkJSON:
+		return NewJSON(os.Stdout, cfg.Level), func() error { return nil }, nil
+	case SinkGCP:
+		h, closeFn, err := NewGCPHandler(ctx, cfg.ProjectID, cfg.LogName, cfg.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		return slog.New(WithTraceContext(h)), closeFn, nil
+	case SinkOTLP:
+		// Keep JSON on stdout so kubectl logs still works; the OTLP copy
+		// carries trace context natively.
+		json := WithTraceContext(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.Level}))
+		return slog.New(fanoutHandler{json, NewOTelHandler(cfg.LogName, cfg.Level)}), func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown LOG_SINK %q", cfg.Sink)
+	}
+}
+
+// NewJSON returns a logger that writes JSON lines to w.
+func NewJSON(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(WithTraceContext(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// WithTraceContext wraps h so that every record logged with a context
+// carrying an OpenTelemetry span gets trace_id and span_id attributes, and a
+// request_id attribute when the request ID middleware has run.
+func WithTraceContext(h slog.Handler) slog.Handler {
+	return &traceContextHandler{inner: h}
+}
+
+type traceContextHandler struct {
+	inner slog.Handler
+}
+
+func (h *traceContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *traceContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *traceContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceContextHandler{inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *traceContextHandler) WithGroup(name string) slog.Handler {
+	return &traceContextHandler{inner: h.inner.WithGroup(name)}
+}
+
+// groupOrAttrs records one WithGroup or WithAttrs call so that handlers which
+// render records into maps can replay them in order.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+func appendGroupOrAttrs(goas []groupOrAttrs, goa groupOrAttrs) []groupOrAttrs {
+	out := make([]groupOrAttrs, len(goas), len(goas)+1)
+	copy(out, goas)
+	return append(out, goa)
+}
+
+// recordFields flattens the handler state and the record attributes into a
+// nested map suitable for structured payloads.
+func recordFields(goas []groupOrAttrs, r slog.Record) map[string]any {
+	root := make(map[string]any)
+	cur := root
+	for _, goa := range goas {
+		if goa.group != "" {
+			next := make(map[string]any)
+			cur[goa.group] = next
+			cur = next
+			continue
+		}
+		for _, a := range goa.attrs {
+			addAttr(cur, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(cur, a)
+		return true
+	})
+	return root
+}
+
+func addAttr(m map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		target := m
+		if a.Key != "" {
+			target = make(map[string]any)
+			m[a.Key] = target
+		}
+		for _, ga := range attrs {
+			addAttr(target, ga)
+		}
+		return
+	}
+	switch v := a.Value.Any().(type) {
+	case error:
+		m[a.Key] = v.Error()
+	case fmt.Stringer:
+		m[a.Key] = v.String()
+	default:
+		m[a.Key] = v
+	}
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"api-server/internal/requestid"
+
+	"cloud.google.com/go/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testTraceID = trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	testSpanID  = trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+)
+
+// spanContext returns ctx carrying a sampled span with the test IDs.
+func spanContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    testTraceID,
+		SpanID:     testSpanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestMemoryLevelFiltering(t *testing.T) {
+	logger, h := NewMemory(slog.LevelWarn)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	entries := h.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Message != "warn" || entries[0].Level != slog.LevelWarn {
+		t.Errorf("entries[0] = %q at %v, want warn at WARN", entries[0].Message, entries[0].Level)
+	}
+	if entries[1].Message != "error" || entries[1].Level != slog.LevelError {
+		t.Errorf("entries[1] = %q at %v, want error at ERROR", entries[1].Message, entries[1].Level)
+	}
+
+	h.Reset()
+	if n := len(h.Entries()); n != 0 {
+		t.Errorf("got %d entries after Reset, want 0", n)
+	}
+}
+
+func TestTraceContextAttributes(t *testing.T) {
+	logger, h := NewMemory(slog.LevelInfo)
+	ctx := requestid.NewContext(spanContext(context.Background()), "req-1")
+	logger.InfoContext(ctx, "with span")
+	logger.InfoContext(context.Background(), "without span")
+
+	entries := h.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	want := map[string]any{
+		"trace_id":   testTraceID.String(),
+		"span_id":    testSpanID.String(),
+		"request_id": "req-1",
+	}
+	for k, v := range want {
+		if got := entries[0].Fields[k]; got != v {
+			t.Errorf("%s = %v, want %v", k, got, v)
+		}
+	}
+	for k := range want {
+		if _, ok := entries[1].Fields[k]; ok {
+			t.Errorf("%s set without a span or request ID", k)
+		}
+	}
+}
+
+func TestMemoryGroupsAndAttrs(t *testing.T) {
+	logger, h := NewMemory(slog.LevelInfo)
+	logger.With("service", "api").WithGroup("http").Info("request", "status", 200)
+
+	fields := h.Entries()[0].Fields
+	if fields["service"] != "api" {
+		t.Errorf("service = %v, want api", fields["service"])
+	}
+	group, ok := fields["http"].(map[string]any)
+	if !ok {
+		t.Fatalf("http = %#v, want a group", fields["http"])
+	}
+	if group["status"] != int64(200) {
+		t.Errorf("http.status = %#v, want 200", group["status"])
+	}
+}
+
+func TestGCPEntry(t *testing.T) {
+	h := &GCPHandler{level: slog.LevelInfo, projectID: "my-project"}
+	hh := h.WithAttrs([]slog.Attr{slog.String("component", "purge")}).WithGroup("db").(*GCPHandler)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := slog.NewRecord(now, slog.LevelWarn, "slow query", 0)
+	r.AddAttrs(slog.Int("rows", 3))
+	e := hh.entry(spanContext(context.Background()), r)
+
+	if !e.Timestamp.Equal(now) {
+		t.Errorf("Timestamp = %v, want %v", e.Timestamp, now)
+	}
+	if e.Severity != logging.Warning {
+		t.Errorf("Severity = %v, want Warning", e.Severity)
+	}
+	if want := "projects/my-project/traces/" + testTraceID.String(); e.Trace != want {
+		t.Errorf("Trace = %q, want %q", e.Trace, want)
+	}
+	if e.SpanID != testSpanID.String() || !e.TraceSampled {
+		t.Errorf("SpanID, TraceSampled = %q, %v, want %q, true", e.SpanID, e.TraceSampled, testSpanID.String())
+	}
+	payload := e.Payload.(map[string]any)
+	if payload["message"] != "slow query" || payload["component"] != "purge" {
+		t.Errorf("payload = %v, want message and component", payload)
+	}
+	if db, _ := payload["db"].(map[string]any); db["rows"] != int64(3) {
+		t.Errorf("payload db = %v, want rows 3", payload["db"])
+	}
+
+	if e := h.entry(context.Background(), r); e.Trace != "" || e.SpanID != "" {
+		t.Errorf("Trace, SpanID = %q, %q without a span, want empty", e.Trace, e.SpanID)
+	}
+}
+
+func TestGCPSeverity(t *testing.T) {
+	for level, want := range map[slog.Level]logging.Severity{
+		slog.LevelDebug:     logging.Debug,
+		slog.LevelInfo:      logging.Info,
+		slog.LevelInfo + 2:  logging.Info,
+		slog.LevelWarn:      logging.Warning,
+		slog.LevelError:     logging.Error,
+		slog.LevelError + 4: logging.Error,
+	} {
+		if got := severity(level); got != want {
+			t.Errorf("severity(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
+
+func TestNewRejectsMemorySink(t *testing.T) {
+	if _, _, err := New(context.Background(), &Config{Sink: "memory"}); err == nil {
+		t.Error("New accepted LOG_SINK=memory")
+	}
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Entry is a log record captured by the in-memory sink.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Fields  map[string]any
+}
+
+// MemoryHandler keeps every record in memory. It is intended for tests that
+// need to assert on what was logged.
+type MemoryHandler struct {
+	level slog.Leveler
+	goas  []groupOrAttrs
+	store *memoryStore
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemory returns a logger backed by a MemoryHandler, along with the handler
+// so callers can inspect the captured entries.
+func NewMemory(level slog.Leveler) (*slog.Logger, *MemoryHandler) {
+	h := &MemoryHandler{level: level, store: &memoryStore{}}
+	return slog.New(WithTraceContext(h)), h
+}
+
+func (h *MemoryHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *MemoryHandler) Handle(_ context.Context, r slog.Record) error {
+	e := Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Fields:  recordFields(h.goas, r),
+	}
+	h.store.mu.Lock()
+	h.store.entries = append(h.store.entries, e)
+	h.store.mu.Unlock()
+	return nil
+}
+
+func (h *MemoryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &MemoryHandler{level: h.level, goas: appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs}), store: h.store}
+}
+
+func (h *MemoryHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &MemoryHandler{level: h.level, goas: appendGroupOrAttrs(h.goas, groupOrAttrs{group: name}), store: h.store}
+}
+
+// Entries returns a copy of the captured entries in the order they were logged.
+func (h *MemoryHandler) Entries() []Entry {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+	out := make([]Entry, len(h.store.entries))
+	copy(out, h.store.entries)
+	return out
+}
+
+// Reset drops all captured entries.
+func (h *MemoryHandler) Reset() {
+	h.store.mu.Lock()
+	h.store.entries = nil
+	h.store.mu.Unlock()
+}
@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"api-server/internal/requestid"
+
+	otellog "go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+)
+
+// OTelHandler emits records through the global OpenTelemetry LoggerProvider,
+// so they are exported over OTLP alongside traces and metrics. Trace context
+// is taken from the context passed to the log call by the SDK itself.
+type OTelHandler struct {
+	level  slog.Leveler
+	logger otellog.Logger
+	goas   []groupOrAttrs
+}
+
+// NewOTelHandler returns a handler bound to the global LoggerProvider. The
+// provider may be installed after the handler is created.
+func NewOTelHandler(name string, level slog.Leveler) *OTelHandler {
+	return &OTelHandler{level: level, logger: global.GetLoggerProvider().Logger(name)}
+}
+
+func (h *OTelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *OTelHandler) Handle(ctx context.Context, r slog.Record) error {
+	var rec otellog.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetObservedTimestamp(time.Now())
+	rec.SetSeverity(otelSeverity(r.Level))
+	rec.SetSeverityText(r.Level.String())
+	rec.SetBody(otellog.StringValue(r.Message))
+	rec.AddAttributes(keyValues(recordFields(h.goas, r))...)
+	if id := requestid.FromContext(ctx); id != "" {
+		rec.AddAttributes(otellog.String("request_id", id))
+	}
+	h.logger.Emit(ctx, rec)
+	return nil
+}
+
+func (h *OTelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{attrs: attrs})
+	return &h2
+}
+
+func (h *OTelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.goas = appendGroupOrAttrs(h.goas, groupOrAttrs{group: name})
+	return &h2
+}
+
+func otelSeverity(level slog.Level) otellog.Severity {
+	switch {
+	case level >= slog.LevelError:
+		return otellog.SeverityError
+	case level >= slog.LevelWarn:
+		return otellog.SeverityWarn
+	case level >= slog.LevelInfo:
+		return otellog.SeverityInfo
+	default:
+		return otellog.SeverityDebug
+	}
+}
+
+func keyValues(m map[string]any) []otellog.KeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otellog.KeyValue, 0, len(m))
+	for _, k := range keys {
+		kvs = append(kvs, otellog.KeyValue{Key: k, Value: otelValue(m[k])})
+	}
+	return kvs
+}
+
+func otelValue(v any) otellog.Value {
+	switch v := v.(type) {
+	case string:
+		return otellog.StringValue(v)
+	case bool:
+		return otellog.BoolValue(v)
+	case int64:
+		return otellog.Int64Value(v)
+	case uint64:
+		return otellog.Int64Value(int64(v))
+	case float64:
+		return otellog.Float64Value(v)
+	case map[string]any:
+		return otellog.MapValue(keyValues(v)...)
+	default:
+		return otellog.StringValue(fmt.Sprint(v))
+	}
+}
+
+// fanoutHandler sends every record to all of its handlers.
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
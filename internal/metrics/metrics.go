@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	RequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_requests_total",
+			Help: "Total number of requests to the API server by route template, method and status code",
+		},
+		[]string{"endpoint", "method", "status"},
+	)
+	RequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "api_server_request_duration_seconds",
+			Help:    "Duration of API server requests in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"endpoint", "method", "status"},
+	)
+	RequestSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "api_server_request_size_bytes",
+			Help:    "Size of API server request bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(128, 4, 10), // 128B .. 32MiB
+		},
+		[]string{"endpoint", "method"},
+	)
+	ResponseSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "api_server_response_size_bytes",
+			Help:    "Size of API server response bodies in bytes",
+			Buckets: prometheus.ExponentialBuckets(128, 4, 10),
+		},
+		[]string{"endpoint", "method", "status"},
+	)
+
+	// Business metrics
+	TraceUploads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_trace_uploads_total",
+			Help: "Number of trace PDF uploads by result",
+		},
+		[]string{"result"},
+	)
+	TraceUploadBytes = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "api_server_trace_upload_bytes",
+			Help:    "Size of successfully uploaded trace PDFs in bytes",
+			Buckets: prometheus.ExponentialBuckets(16*1024, 4, 8), // 16KiB .. 256MiB
+		},
+	)
+	AuthFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_auth_failures_total",
+			Help: "Number of rejected authentication attempts by reason",
+		},
+		[]string{"reason"},
+	)
+	PurgedRecords = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_purged_records_total",
+			Help: "Number of soft-deleted records permanently removed by resource",
+		},
+		[]string{"resource"},
+	)
+	WebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_webhook_deliveries_total",
+			Help: "Number of webhook delivery attempts by result",
+		},
+		[]string{"result"},
+	)
+	EventsPublished = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_events_published_total",
+			Help: "Number of outbox events published to the message broker by result",
+		},
+		[]string{"result"},
+	)
+	TracesProcessed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_server_traces_processed_total",
+			Help: "Number of uploaded traces processed by final status",
+		},
+		[]string{"status"},
+	)
+	TraceStatusStreams = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "api_server_trace_status_streams",
+			Help: "Number of open trace status event streams",
+		},
+	)
+)
+
+// Upload results recorded on TraceUploads.
+const (
+	UploadSuccess  = "success"
+	UploadRejected = "rejected"
+	UploadFailed   = "failed"
+)
+
+// Auth failure reasons recorded on AuthFailures.
+const (
+	AuthMissingCredentials = "missing_credentials"
+	AuthUnknownUser        = "unknown_user"
+	AuthBadPassword        = "bad_password"
+)
+
+func init() {
+	prometheus.MustRegister(RequestCounter)
+	prometheus.MustRegister(RequestDuration)
+	prometheus.MustRegister(RequestSize)
+	prometheus.MustRegister(ResponseSize)
+	prometheus.MustRegister(TraceUploads)
+	prometheus.MustRegister(TraceUploadBytes)
+	prometheus.MustRegister(AuthFailures)
+	prometheus.MustRegister(PurgedRecords)
+	prometheus.MustRegister(WebhookDeliveries)
+	prometheus.MustRegister(EventsPublished)
+	prometheus.MustRegister(TracesProcessed)
+	prometheus.MustRegister(TraceStatusStreams)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels requests that did not resolve to a registered route,
+// so that arbitrary paths cannot create new time series.
+const unmatchedRoute = "unmatched"
+
+// Middleware records request count, latency and body sizes labelled by the
+// matched route template rather than the raw URL path. It must be installed
+// with Router.Use so that mux.CurrentRoute is populated.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := routeTemplate(r)
+		method := r.Method
+
+		body := &countingReader{ReadCloser: r.Body}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = body
+		}
+
+		m := httpsnoop.CaptureMetrics(next, w, r)
+		status := strconv.Itoa(m.Code)
+
+		RequestCounter.WithLabelValues(endpoint, method, status).Inc()
+		RequestDuration.WithLabelValues(endpoint, method, status).Observe(m.Duration.Seconds())
+		RequestSize.WithLabelValues(endpoint, method).Observe(float64(body.n))
+		ResponseSize.WithLabelValues(endpoint, method, status).Observe(float64(m.Written))
+	})
+}
+
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unmatchedRoute
+	}
+	if tpl, err := route.GetPathTemplate(); err == nil {
+		return tpl
+	}
+	return unmatchedRoute
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
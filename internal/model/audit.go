@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent records one mutation of a resource. Changes maps each JSON field
+// that changed to its values before and after the mutation.
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Actor      string                 `json:"actor"`
+	Resource   string                 `json:"resource"`
+	ResourceID uuid.UUID              `json:"resource_id"`
+	Action     string                 `json:"action"`
+	Changes    map[string]AuditChange `json:"changes"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	SourceIP   string                 `json:"source_ip,omitempty"`
+}
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditFilter narrows an audit query. Zero values match everything.
+type AuditFilter struct {
+	Actor      string
+	Resource   string
+	ResourceID uuid.UUID
+	Action     string
+	From       time.Time
+	To         time.Time
+	BeforeID   int64
+	Limit      int
+}
@@ -4,17 +4,34 @@ import (
 	"github.com/google/uuid"
 )
 
+// Course is a course offering in a term. SemesterTerm and SemesterYear are
+// read from the term; set TermID to move a course. Instructors are assigned
+// through CourseInstructor. Code, TermID and Section identify an offering;
+// Section defaults to DefaultSection. Capacity limits the number of enrolled
+// students; nil means unlimited.
 type Course struct {
 	ID              uuid.UUID `json:"id"`
-	Code            string    `json:"code"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
+	Code            string    `json:"code" validate:"required,max=20"`
+	Name            string    `json:"name" validate:"required,max=255"`
+	Section         string    `json:"section" validate:"omitempty,alphanum,max=10"`
+	Description     string    `json:"description" validate:"max=2000"`
 	SemesterTerm    string    `json:"semester_term"`
-	Manufacturer    string    `json:"manufacturer"`
-	CreditHours     int       `json:"credit_hours"`
+	Manufacturer    string    `json:"manufacturer" validate:"max=255"`
+	CreditHours     int       `json:"credit_hours" validate:"gte=0,lte=12"`
 	SemesterYear    int       `json:"semester_year"`
 	DateAdded       string    `json:"date_added"`
 	DateLastUpdated string    `json:"date_last_updated"`
-	OwnerUserID     uuid.UUID `json:"owner_user_id"`
-	InstructorID    uuid.UUID `json:"instructor_id"`
+	OwnerUserID     uuid.UUID `json:"owner_user_id" validate:"required"`
+	TermID          uuid.UUID `json:"term_id" validate:"required"`
+	Capacity        *int      `json:"capacity" validate:"omitempty,gte=1"`
+	Version         int64     `json:"-"`
+}
+
+// DefaultSection is the section of courses created without one.
+const DefaultSection = "01"
+
+// CourseFilter narrows a course listing. Zero values match everything.
+type CourseFilter struct {
+	TermID     uuid.UUID
+	TermStatus string
 }
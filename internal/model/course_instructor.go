@@ -0,0 +1,38 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Course instructor roles.
+const (
+	RoleLead         = "lead"
+	RoleCoInstructor = "co-instructor"
+	RoleTA           = "ta"
+)
+
+// CourseInstructor assigns an instructor to a course in a role. StartDate
+// and EndDate are optional YYYY-MM-DD bounds of the assignment; nil means
+// open-ended. InstructorName is read from the instructor.
+type CourseInstructor struct {
+	ID              uuid.UUID `json:"id"`
+	CourseID        uuid.UUID `json:"course_id"`
+	InstructorID    uuid.UUID `json:"instructor_id" validate:"required"`
+	InstructorName  string    `json:"instructor_name"`
+	Role            string    `json:"role" validate:"required,oneof=lead co-instructor ta"`
+	StartDate       *string   `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate         *string   `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	DateCreated     string    `json:"date_created"`
+	DateLastUpdated string    `json:"date_last_updated"`
+	Version         int64     `json:"-"`
+}
+
+// InstructorCourse is a course an instructor is assigned to, with the
+// assignment.
+type InstructorCourse struct {
+	AssignmentID uuid.UUID `json:"assignment_id"`
+	Role         string    `json:"role"`
+	StartDate    *string   `json:"start_date"`
+	EndDate      *string   `json:"end_date"`
+	Course       Course    `json:"course"`
+}
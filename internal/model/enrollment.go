@@ -0,0 +1,66 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Enrollment states. A student is enrolled while the course has room and
+// waitlisted otherwise; waitlisted students are promoted in the order they
+// joined as seats free up.
+const (
+	EnrollmentEnrolled   = "enrolled"
+	EnrollmentWaitlisted = "waitlisted"
+	EnrollmentDropped    = "dropped"
+	EnrollmentCompleted  = "completed"
+)
+
+// Enrollment connects a student to a course offering. TermID is copied from
+// the course when the student enrolls.
+type Enrollment struct {
+	ID               uuid.UUID `json:"id"`
+	CourseID         uuid.UUID `json:"course_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	TermID           uuid.UUID `json:"term_id"`
+	Status           string    `json:"status" validate:"required,oneof=enrolled waitlisted dropped completed"`
+	WaitlistPosition int       `json:"waitlist_position,omitempty"`
+	DateCreated      string    `json:"date_created"`
+	StatusChangedAt  string    `json:"status_changed_at"`
+	Version          int64     `json:"-"`
+}
+
+// EnrollmentRequest is the body of POST /courses/{id}/enrollments.
+type EnrollmentRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
+
+// StudentEnrollment is an enrollment with the course it belongs to, as shown
+// on a student's schedule.
+type StudentEnrollment struct {
+	Enrollment
+	CourseCode string `json:"course_code"`
+	CourseName string `json:"course_name"`
+	TermName   string `json:"term_name"`
+}
+
+// CourseRoster lists the students of one course taught by an instructor.
+type CourseRoster struct {
+	CourseID     uuid.UUID     `json:"course_id"`
+	CourseCode   string        `json:"course_code"`
+	CourseName   string        `json:"course_name"`
+	TermID       uuid.UUID     `json:"term_id"`
+	SemesterTerm string        `json:"semester_term"`
+	SemesterYear int           `json:"semester_year"`
+	Capacity     *int          `json:"capacity"`
+	Enrolled     []RosterEntry `json:"enrolled"`
+	Waitlisted   []RosterEntry `json:"waitlisted"`
+}
+
+// RosterEntry is one student on a course roster.
+type RosterEntry struct {
+	EnrollmentID     uuid.UUID `json:"enrollment_id"`
+	UserID           uuid.UUID `json:"user_id"`
+	FirstName        string    `json:"first_name"`
+	LastName         string    `json:"last_name"`
+	Username         string    `json:"username"`
+	WaitlistPosition int       `json:"waitlist_position,omitempty"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CloudEvent is a domain event in the CloudEvents 1.0 JSON format. Subject
+// is the ID of the resource the event is about. Actor and RequestID are
+// extension attributes identifying who caused the event.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	Actor           string          `json:"actor,omitempty"`
+	RequestID       string          `json:"requestid,omitempty"`
+}
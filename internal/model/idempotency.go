@@ -0,0 +1,15 @@
+package model
+
+import (
+	"net/http"
+)
+
+// IdempotentResponse is the response stored for an Idempotency-Key.
+// Fingerprint identifies the request that used the key; Status is zero
+// while that request is still being processed.
+type IdempotentResponse struct {
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
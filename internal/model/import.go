@@ -0,0 +1,15 @@
+package model
+
+// Import modes. An atomic import writes nothing unless every row succeeds; a
+// best-effort import writes the rows that succeed and reports the others.
+const (
+	ImportAtomic     = "atomic"
+	ImportBestEffort = "best-effort"
+)
+
+// ImportOptions controls a bulk import. A dry run checks every row, including
+// against the database, and then writes nothing.
+type ImportOptions struct {
+	Mode   string
+	DryRun bool
+}
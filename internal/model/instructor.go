@@ -6,7 +6,8 @@ import (
 
 type Instructor struct {
 	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	Name        string    `json:"name"`
+	UserID      uuid.UUID `json:"user_id" validate:"required"`
+	Name        string    `json:"name" validate:"required,max=255"`
 	DateCreated string    `json:"date_created"`
+	Version     int64     `json:"-"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Meeting modalities. Online meetings have no room.
+const (
+	ModalityInPerson = "in-person"
+	ModalityOnline   = "online"
+	ModalityHybrid   = "hybrid"
+)
+
+// Weekdays lists the iCalendar day codes accepted in Meeting.Days, in week
+// order.
+var Weekdays = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// Meeting is a weekly class meeting of a course. It recurs on Days from
+// StartTime to EndTime (HH:MM, local time) for the dates of the course's
+// term. Room is required unless the meeting is online.
+type Meeting struct {
+	ID              uuid.UUID `json:"id"`
+	CourseID        uuid.UUID `json:"course_id"`
+	Days            []string  `json:"days" validate:"required,min=1,unique,dive,oneof=MO TU WE TH FR SA SU"`
+	StartTime       string    `json:"start_time" validate:"required,datetime=15:04"`
+	EndTime         string    `json:"end_time" validate:"required,datetime=15:04"`
+	Room            *string   `json:"room" validate:"omitempty,min=1,max=100"`
+	Modality        string    `json:"modality" validate:"required,oneof=in-person online hybrid"`
+	DateCreated     string    `json:"date_created"`
+	DateLastUpdated string    `json:"date_last_updated"`
+	Version         int64     `json:"-"`
+}
+
+// ScheduledMeeting is a meeting with the course and term dates needed to
+// place it on a calendar.
+type ScheduledMeeting struct {
+	Meeting
+	CourseCode string
+	CourseName string
+	TermStart  string
+	TermEnd    string
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Requisite kinds. A prerequisite must be completed before enrolling; a
+// corequisite may instead be taken in the same term.
+const (
+	RequisitePrerequisite = "prerequisite"
+	RequisiteCorequisite  = "corequisite"
+)
+
+// Requisite is a course, identified by code, that another course requires.
+type Requisite struct {
+	ID          uuid.UUID `json:"id"`
+	Code        string    `json:"code" validate:"required,max=20"`
+	Kind        string    `json:"kind" validate:"required,oneof=prerequisite corequisite"`
+	DateCreated string    `json:"date_created"`
+}
+
+// TransitivePrerequisite is a course that must be completed, directly or
+// through other prerequisites, before a course. Depth is 1 for direct
+// prerequisites and counts the shortest chain otherwise.
+type TransitivePrerequisite struct {
+	Code  string `json:"code"`
+	Depth int    `json:"depth"`
+}
+
+// Eligibility tells whether a user may take a course. Missing lists the
+// requisites the user has not satisfied: prerequisites they have not
+// completed, and corequisites they have neither completed nor enrolled in.
+type Eligibility struct {
+	CourseID uuid.UUID   `json:"course_id"`
+	UserID   uuid.UUID   `json:"user_id"`
+	Eligible bool        `json:"eligible"`
+	Missing  []Requisite `json:"missing"`
+}
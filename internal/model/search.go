@@ -0,0 +1,34 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Resource types returned by search.
+const (
+	SearchCourse     = "course"
+	SearchInstructor = "instructor"
+	SearchTrace      = "trace"
+)
+
+// SearchTypes lists every searchable resource type.
+var SearchTypes = []string{SearchCourse, SearchInstructor, SearchTrace}
+
+// SearchQuery is a full-text query in web search syntax: quoted phrases,
+// "or" and a leading "-" to exclude a word.
+type SearchQuery struct {
+	Query string
+	Types []string
+	Limit int
+}
+
+// SearchResult is one match. Title and Snippet mark matched words with
+// <mark> and </mark>; the surrounding text is not HTML-escaped. Snippet is
+// empty for resources with nothing to quote beyond their title.
+type SearchResult struct {
+	Type    string    `json:"type"`
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet,omitempty"`
+	Rank    float64   `json:"rank"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Term states, derived from the term's dates.
+const (
+	TermUpcoming = "upcoming"
+	TermCurrent  = "current"
+	TermArchived = "archived"
+)
+
+// Term is an academic term. Dates are YYYY-MM-DD; students may enroll in the
+// term's courses from RegistrationOpens through RegistrationCloses. Status is
+// computed from today's date and cannot be written.
+type Term struct {
+	ID                 uuid.UUID `json:"id"`
+	Name               string    `json:"name" validate:"required,max=100"`
+	SemesterTerm       string    `json:"semester_term" validate:"required,semester_term"`
+	SemesterYear       int       `json:"semester_year" validate:"gte=2000,lte=2100"`
+	StartDate          string    `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate            string    `json:"end_date" validate:"required,datetime=2006-01-02"`
+	RegistrationOpens  string    `json:"registration_opens" validate:"required,datetime=2006-01-02"`
+	RegistrationCloses string    `json:"registration_closes" validate:"required,datetime=2006-01-02"`
+	Status             string    `json:"status"`
+	DateCreated        string    `json:"date_created"`
+	DateLastUpdated    string    `json:"date_last_updated"`
+	Version            int64     `json:"-"`
+}
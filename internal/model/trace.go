@@ -1,13 +1,42 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// Processing statuses of a trace, in the order they are reached. A trace
+// ends up ready or failed.
+const (
+	TraceUploaded = "uploaded"
+	TraceScanning = "scanning"
+	TraceParsing  = "parsing"
+	TraceReady    = "ready"
+	TraceFailed   = "failed"
+)
+
 type Trace struct {
 	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	FileName    string    `json:"file_name"`
+	UserID      uuid.UUID `json:"user_id" validate:"required"`
+	FileName    string    `json:"file_name" validate:"required,endswith=.pdf,max=255"`
 	DateCreated string    `json:"date_created"`
-	BucketPath  string    `json:"bucket_path"`
+	BucketPath  string    `json:"bucket_path" validate:"required,startswith=gs://"`
+	// Status and StatusError are set by the trace processor and ignored in
+	// requests.
+	Status      string `json:"status"`
+	StatusError string `json:"status_error,omitempty"`
+	Version     int64  `json:"-"`
+	// Text is extracted from the uploaded PDF for search. It is written when
+	// the trace is processed and never returned.
+	Text string `json:"-"`
+}
+
+// TraceStatusEvent announces that a trace reached Status.
+type TraceStatusEvent struct {
+	TraceID uuid.UUID `json:"trace_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	At      time.Time `json:"at"`
 }
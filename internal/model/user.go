@@ -6,10 +6,11 @@ import (
 
 type User struct {
 	ID             uuid.UUID `json:"id"`
-	FirstName      string    `json:"first_name"`
-	LastName       string    `json:"last_name"`
-	Username       string    `json:"username"`
-	Password       string    `json:"password"`
+	FirstName      string    `json:"first_name" validate:"required,max=255"`
+	LastName       string    `json:"last_name" validate:"required,max=255"`
+	Username       string    `json:"username" validate:"required,email,max=255"`
+	Password       string    `json:"password" validate:"required,min=8,max=255"`
 	AccountCreated string    `json:"account_created"`
 	AccountUpdated string    `json:"account_updated"`
+	Version        int64     `json:"-"`
 }
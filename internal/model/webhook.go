@@ -0,0 +1,93 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription to resource lifecycle events. EventTypes limits
+// the events delivered, such as course.updated; an empty list subscribes to
+// every event. Secret signs the payloads and is only returned when the
+// webhook is created.
+type Webhook struct {
+	ID              uuid.UUID `json:"id"`
+	URL             string    `json:"url" validate:"required,http_url,max=2048"`
+	Description     string    `json:"description" validate:"max=255"`
+	EventTypes      []string  `json:"event_types" validate:"unique"`
+	Secret          string    `json:"secret,omitempty" validate:"omitempty,min=16,max=255"`
+	Active          bool      `json:"active"`
+	DateCreated     string    `json:"date_created"`
+	DateLastUpdated string    `json:"date_last_updated"`
+	Version         int64     `json:"-"`
+}
+
+// WebhookEvent is the payload delivered to webhooks. Data is the resource
+// after the change, or before it for deletes; Changes lists the fields that
+// changed as in the audit log.
+type WebhookEvent struct {
+	ID         uuid.UUID              `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Resource   string                 `json:"resource"`
+	ResourceID uuid.UUID              `json:"resource_id"`
+	Actor      string                 `json:"actor"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Data       map[string]any         `json:"data"`
+	Changes    map[string]AuditChange `json:"changes"`
+}
+
+// WebhookDelivery is an event queued for one webhook. Log lists the attempts
+// to send it and is only filled in when a single delivery is read.
+type WebhookDelivery struct {
+	ID            int64            `json:"id"`
+	WebhookID     uuid.UUID        `json:"webhook_id"`
+	EventID       uuid.UUID        `json:"event_id"`
+	EventType     string           `json:"event_type"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+	Payload       json.RawMessage  `json:"payload,omitempty"`
+	Log           []WebhookAttempt `json:"log,omitempty"`
+}
+
+// WebhookAttempt records one attempt to send a delivery. ResponseStatus is
+// nil if no response was received, and Error then says why.
+type WebhookAttempt struct {
+	AttemptedAt    time.Time `json:"attempted_at"`
+	DurationMS     int64     `json:"duration_ms"`
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	ResponseBody   string    `json:"response_body,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// WebhookDeliveryFilter narrows a delivery listing. Zero values match
+// everything.
+type WebhookDeliveryFilter struct {
+	Status   string
+	BeforeID int64
+	Limit    int
+}
+
+// WebhookJob is a delivery claimed by the dispatcher, with what it needs to
+// send it. Live is false if the webhook was disabled or deleted since the
+// event was queued.
+type WebhookJob struct {
+	ID        int64
+	EventType string
+	Payload   []byte
+	Attempts  int
+	URL       string
+	Secret    string
+	Live      bool
+}
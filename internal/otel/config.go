@@ -0,0 +1,211 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/detectors/gcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
+)
+
+// Exporter names accepted in OTEL_TRACES_EXPORTER, OTEL_METRICS_EXPORTER and
+// OTEL_LOGS_EXPORTER. "stdout" is accepted as an alias for "console".
+const (
+	exporterOTLP    = "otlp"
+	exporterConsole = "console"
+	exporterNone    = "none"
+)
+
+// exporterFromEnv returns the exporter selected by key, defaulting to "otlp".
+func exporterFromEnv(key string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch v {
+	case "":
+		return exporterOTLP, nil
+	case exporterOTLP, exporterConsole, exporterNone:
+		return v, nil
+	case "stdout":
+		return exporterConsole, nil
+	default:
+		return "", fmt.Errorf("unsupported %s %q", key, v)
+	}
+}
+
+// otlpProtocol returns the OTLP transport for a signal, honoring the
+// signal-specific variable before the general one. Defaults to http/protobuf.
+func otlpProtocol(signal string) string {
+	for _, key := range []string{"OTEL_EXPORTER_OTLP_" + signal + "_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return "http/protobuf"
+}
+
+// newTraceExporter builds the span exporter selected by OTEL_TRACES_EXPORTER.
+// Endpoints, headers and TLS settings come from the standard
+// OTEL_EXPORTER_OTLP_* variables. A nil exporter means tracing is disabled.
+func newTraceExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	name, err := exporterFromEnv("OTEL_TRACES_EXPORTER")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case exporterNone:
+		return nil, nil
+	case exporterConsole:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	}
+
+	switch proto := otlpProtocol("TRACES"); proto {
+	case "grpc":
+		return otlptracegrpc.New(ctx)
+	case "http/protobuf":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP traces protocol %q", proto)
+	}
+}
+
+// newMetricExporter builds the metric exporter selected by
+// OTEL_METRICS_EXPORTER, over the transport selected like newTraceExporter's.
+// A nil exporter means metrics are only served on /metrics.
+func newMetricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	name, err := exporterFromEnv("OTEL_METRICS_EXPORTER")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case exporterNone:
+		return nil, nil
+	case exporterConsole:
+		return stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+	}
+
+	switch proto := otlpProtocol("METRICS"); proto {
+	case "grpc":
+		return otlpmetricgrpc.New(ctx)
+	case "http/protobuf":
+		return otlpmetrichttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP metrics protocol %q", proto)
+	}
+}
+
+// newLogExporter builds the log exporter selected by OTEL_LOGS_EXPORTER,
+// over the transport selected like newTraceExporter's. A nil exporter means
+// logs are only written by the LOG_SINK handler.
+func newLogExporter(ctx context.Context) (sdklog.Exporter, error) {
+	name, err := exporterFromEnv("OTEL_LOGS_EXPORTER")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case exporterNone:
+		return nil, nil
+	case exporterConsole:
+		return stdoutlog.New(stdoutlog.WithPrettyPrint())
+	}
+
+	switch proto := otlpProtocol("LOGS"); proto {
+	case "grpc":
+		return otlploggrpc.New(ctx)
+	case "http/protobuf":
+		return otlploghttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP logs protocol %q", proto)
+	}
+}
+
+// newSampler returns a parent-based ratio sampler. The ratio is read from
+// OTEL_TRACES_SAMPLER_ARG and defaults to 1. If OTEL_TRACES_SAMPLER is set
+// the SDK configures the sampler itself and nil is returned.
+func newSampler() (sdktrace.Sampler, error) {
+	if os.Getenv("OTEL_TRACES_SAMPLER") != "" {
+		return nil, nil
+	}
+
+	ratio := 1.0
+	if arg := strings.TrimSpace(os.Getenv("OTEL_TRACES_SAMPLER_ARG")); arg != "" {
+		r, err := strconv.ParseFloat(arg, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q: must be a number between 0 and 1", arg)
+		}
+		ratio = r
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+}
+
+// newResource describes this process. Later options win, so attributes from
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME override the defaults.
+func newResource(ctx context.Context, serviceVersion string) (*resource.Resource, error) {
+	environment := os.Getenv("ENVIRONMENT")
+	if environment == "" {
+		environment = "local"
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName("api-server"),
+			semconv.ServiceVersion(serviceVersion),
+			attribute.String("deployment.environment", environment),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithContainer(),
+		resource.WithDetectors(gcp.NewDetector(), kubernetesDetector{}),
+		resource.WithFromEnv(),
+	)
+	// Detectors report partial results (for example no container ID outside
+	// Docker) and schema URL mismatches alongside a usable resource.
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// kubernetesDetector reads pod metadata exposed through the downward API.
+// The variables are set in k8s/deployment/webapp.yaml.
+type kubernetesDetector struct{}
+
+func (kubernetesDetector) Detect(context.Context) (*resource.Resource, error) {
+	var attrs []attribute.KeyValue
+	for env, key := range map[string]attribute.Key{
+		"K8S_POD_NAME":       semconv.K8SPodNameKey,
+		"K8S_POD_UID":        semconv.K8SPodUIDKey,
+		"K8S_NAMESPACE_NAME": semconv.K8SNamespaceNameKey,
+		"K8S_NODE_NAME":      semconv.K8SNodeNameKey,
+		"K8S_CLUSTER_NAME":   semconv.K8SClusterNameKey,
+	} {
+		if v := os.Getenv(env); v != "" {
+			attrs = append(attrs, key.String(v))
+		}
+	}
+	if len(attrs) == 0 {
+		return resource.Empty(), nil
+	}
+	return resource.NewSchemaless(attrs...), nil
+}
@@ -2,51 +2,100 @@ package otel
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	promBridge "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.28.0"
 )
 
-// SetupOTelSDK initializes OpenTelemetry tracing.
-func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, err error) {
+// PrometheusRegistry holds metrics recorded through the OpenTelemetry meter
+// provider (runtime and database pool statistics). Serve it next to the
+// default Prometheus registry so scrapers see both.
+var PrometheusRegistry = prometheus.NewRegistry()
+
+// SetupOTelSDK initializes OpenTelemetry tracing, metrics and logs. Exporters,
+// endpoints, sampling and resource attributes follow the standard OTEL_*
+// environment variables.
+func SetupOTelSDK(ctx context.Context, serviceVersion string) (shutdown func(context.Context) error, err error) {
 	var shutdownFuncs []func(context.Context) error
 
+	// Return shutdown function.
+	shutdown = func(ctx context.Context) error {
+		var err error
+		for _, fn := range shutdownFuncs {
+			err = errors.Join(err, fn(ctx))
+		}
+		return err
+	}
+
 	// Define resource with service metadata.
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName("api-server"),
-			semconv.ServiceVersion("1.0.0"),
-			attribute.String("deployment.environment", "production"),
-		),
-	)
+	res, err := newResource(ctx, serviceVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize OTLP trace exporter.
-	traceExporter, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpoint("otel-collector.monitoring.svc.cluster.local:4318"),
-		// otlptracehttp.WithEndpoint("otel-collector:4318"), // Collector service in GKE
-		otlptracehttp.WithInsecure(), // Use HTTP for simplicity
-	)
+	// Initialize trace exporter.
+	traceExporter, err := newTraceExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sampler, err := newSampler()
 	if err != nil {
 		return nil, err
 	}
 
 	// Initialize trace provider.
-	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Sample all traces (adjust for production)
-	)
+	traceOpts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if traceExporter != nil {
+		traceOpts = append(traceOpts, sdktrace.WithBatcher(traceExporter))
+	}
+	if sampler != nil {
+		traceOpts = append(traceOpts, sdktrace.WithSampler(sampler))
+	}
+	traceProvider := sdktrace.NewTracerProvider(traceOpts...)
 	otel.SetTracerProvider(traceProvider)
 	shutdownFuncs = append(shutdownFuncs, traceProvider.Shutdown)
 
+	// Initialize meter provider.
+	meterProvider, err := newMeterProvider(ctx, res)
+	if err != nil {
+		return nil, errors.Join(err, shutdown(ctx))
+	}
+	otel.SetMeterProvider(meterProvider)
+	shutdownFuncs = append(shutdownFuncs, meterProvider.Shutdown)
+
+	// Go runtime metrics (goroutines, memory, GC) replace the hand-rolled
+	// Prometheus gauges that used to be refreshed from a goroutine.
+	if err := runtime.Start(
+		runtime.WithMeterProvider(meterProvider),
+		runtime.WithMinimumReadMemStatsInterval(15*time.Second),
+	); err != nil {
+		return nil, errors.Join(err, shutdown(ctx))
+	}
+
+	// Initialize logger provider.
+	logExporter, err := newLogExporter(ctx)
+	if err != nil {
+		return nil, errors.Join(err, shutdown(ctx))
+	}
+	logOpts := []sdklog.LoggerProviderOption{sdklog.WithResource(res)}
+	if logExporter != nil {
+		logOpts = append(logOpts, sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)))
+	}
+	loggerProvider := sdklog.NewLoggerProvider(logOpts...)
+	global.SetLoggerProvider(loggerProvider)
+	shutdownFuncs = append(shutdownFuncs, loggerProvider.Shutdown)
+
 	// Set up context propagation.
 	otel.SetTextMapPropagator(
 		propagation.NewCompositeTextMapPropagator(
@@ -55,16 +104,32 @@ func SetupOTelSDK(ctx context.Context) (shutdown func(context.Context) error, er
 		),
 	)
 
-	// Return shutdown function.
-	shutdown = func(ctx context.Context) error {
-		var err error
-		for _, fn := range shutdownFuncs {
-			if shutdownErr := fn(ctx); shutdownErr != nil {
-				err = shutdownErr
-			}
-		}
-		return err
+	return shutdown, nil
+}
+
+// newMeterProvider exports metrics to PrometheusRegistry and to the exporter
+// selected by OTEL_METRICS_EXPORTER, if any. That reader also pulls in
+// everything registered with the default Prometheus registry through the
+// bridge, so both pipelines carry the same request and business metrics.
+func newMeterProvider(ctx context.Context, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	promExporter, err := otelprom.New(otelprom.WithRegisterer(PrometheusRegistry))
+	if err != nil {
+		return nil, err
+	}
+	opts := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(promExporter),
 	}
 
-	return shutdown, nil
+	metricExporter, err := newMetricExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if metricExporter != nil {
+		opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter,
+			sdkmetric.WithProducer(promBridge.NewMetricProducer()),
+		)))
+	}
+
+	return sdkmetric.NewMeterProvider(opts...), nil
 }
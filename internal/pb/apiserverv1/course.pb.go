@@ -0,0 +1,605 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: apiserver/v1/course.proto
+
+package apiserverv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Course is a course offering in a term. semester_term and semester_year are
+// read from the term and ignored in requests. An unset capacity means
+// unlimited.
+type Course struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code            string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Name            string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Section         string                 `protobuf:"bytes,4,opt,name=section,proto3" json:"section,omitempty"`
+	Description     string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	SemesterTerm    string                 `protobuf:"bytes,6,opt,name=semester_term,json=semesterTerm,proto3" json:"semester_term,omitempty"`
+	Manufacturer    string                 `protobuf:"bytes,7,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	CreditHours     int32                  `protobuf:"varint,8,opt,name=credit_hours,json=creditHours,proto3" json:"credit_hours,omitempty"`
+	SemesterYear    int32                  `protobuf:"varint,9,opt,name=semester_year,json=semesterYear,proto3" json:"semester_year,omitempty"`
+	DateAdded       string                 `protobuf:"bytes,10,opt,name=date_added,json=dateAdded,proto3" json:"date_added,omitempty"`
+	DateLastUpdated string                 `protobuf:"bytes,11,opt,name=date_last_updated,json=dateLastUpdated,proto3" json:"date_last_updated,omitempty"`
+	OwnerUserId     string                 `protobuf:"bytes,12,opt,name=owner_user_id,json=ownerUserId,proto3" json:"owner_user_id,omitempty"`
+	TermId          string                 `protobuf:"bytes,13,opt,name=term_id,json=termId,proto3" json:"term_id,omitempty"`
+	Capacity        *int32                 `protobuf:"varint,14,opt,name=capacity,proto3,oneof" json:"capacity,omitempty"`
+	// version changes on every write; pass it as expected_version to make a
+	// write conditional.
+	Version       int64 `protobuf:"varint,15,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Course) Reset() {
+	*x = Course{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Course) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Course) ProtoMessage() {}
+
+func (x *Course) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Course.ProtoReflect.Descriptor instead.
+func (*Course) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Course) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Course) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Course) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Course) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *Course) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Course) GetSemesterTerm() string {
+	if x != nil {
+		return x.SemesterTerm
+	}
+	return ""
+}
+
+func (x *Course) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *Course) GetCreditHours() int32 {
+	if x != nil {
+		return x.CreditHours
+	}
+	return 0
+}
+
+func (x *Course) GetSemesterYear() int32 {
+	if x != nil {
+		return x.SemesterYear
+	}
+	return 0
+}
+
+func (x *Course) GetDateAdded() string {
+	if x != nil {
+		return x.DateAdded
+	}
+	return ""
+}
+
+func (x *Course) GetDateLastUpdated() string {
+	if x != nil {
+		return x.DateLastUpdated
+	}
+	return ""
+}
+
+func (x *Course) GetOwnerUserId() string {
+	if x != nil {
+		return x.OwnerUserId
+	}
+	return ""
+}
+
+func (x *Course) GetTermId() string {
+	if x != nil {
+		return x.TermId
+	}
+	return ""
+}
+
+func (x *Course) GetCapacity() int32 {
+	if x != nil && x.Capacity != nil {
+		return *x.Capacity
+	}
+	return 0
+}
+
+func (x *Course) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListCoursesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// term_id, if set, limits the listing to one term.
+	TermId string `protobuf:"bytes,1,opt,name=term_id,json=termId,proto3" json:"term_id,omitempty"`
+	// term_status, if set, limits the listing to terms with that status.
+	TermStatus    string `protobuf:"bytes,2,opt,name=term_status,json=termStatus,proto3" json:"term_status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCoursesRequest) Reset() {
+	*x = ListCoursesRequest{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCoursesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoursesRequest) ProtoMessage() {}
+
+func (x *ListCoursesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoursesRequest.ProtoReflect.Descriptor instead.
+func (*ListCoursesRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListCoursesRequest) GetTermId() string {
+	if x != nil {
+		return x.TermId
+	}
+	return ""
+}
+
+func (x *ListCoursesRequest) GetTermStatus() string {
+	if x != nil {
+		return x.TermStatus
+	}
+	return ""
+}
+
+type ListCoursesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Courses       []*Course              `protobuf:"bytes,1,rep,name=courses,proto3" json:"courses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCoursesResponse) Reset() {
+	*x = ListCoursesResponse{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCoursesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCoursesResponse) ProtoMessage() {}
+
+func (x *ListCoursesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCoursesResponse.ProtoReflect.Descriptor instead.
+func (*ListCoursesResponse) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCoursesResponse) GetCourses() []*Course {
+	if x != nil {
+		return x.Courses
+	}
+	return nil
+}
+
+type GetCourseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCourseRequest) Reset() {
+	*x = GetCourseRequest{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCourseRequest) ProtoMessage() {}
+
+func (x *GetCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCourseRequest.ProtoReflect.Descriptor instead.
+func (*GetCourseRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCourseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateCourseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Course        *Course                `protobuf:"bytes,1,opt,name=course,proto3" json:"course,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCourseRequest) Reset() {
+	*x = CreateCourseRequest{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCourseRequest) ProtoMessage() {}
+
+func (x *CreateCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCourseRequest.ProtoReflect.Descriptor instead.
+func (*CreateCourseRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateCourseRequest) GetCourse() *Course {
+	if x != nil {
+		return x.Course
+	}
+	return nil
+}
+
+type UpdateCourseRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Course *Course                `protobuf:"bytes,2,opt,name=course,proto3" json:"course,omitempty"`
+	// expected_version, if set, fails the update with ABORTED unless the
+	// course is still at that version.
+	ExpectedVersion *int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateCourseRequest) Reset() {
+	*x = UpdateCourseRequest{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCourseRequest) ProtoMessage() {}
+
+func (x *UpdateCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCourseRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCourseRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateCourseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateCourseRequest) GetCourse() *Course {
+	if x != nil {
+		return x.Course
+	}
+	return nil
+}
+
+func (x *UpdateCourseRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteCourseRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteCourseRequest) Reset() {
+	*x = DeleteCourseRequest{}
+	mi := &file_apiserver_v1_course_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCourseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCourseRequest) ProtoMessage() {}
+
+func (x *DeleteCourseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_course_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCourseRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCourseRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_course_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteCourseRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteCourseRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+var File_apiserver_v1_course_proto protoreflect.FileDescriptor
+
+const file_apiserver_v1_course_proto_rawDesc = "" +
+	"\n" +
+	"\x19apiserver/v1/course.proto\x12\fapiserver.v1\x1a\x1bgoogle/protobuf/empty.proto\"\xdd\x03\n" +
+	"\x06Course\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x18\n" +
+	"\asection\x18\x04 \x01(\tR\asection\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12#\n" +
+	"\rsemester_term\x18\x06 \x01(\tR\fsemesterTerm\x12\"\n" +
+	"\fmanufacturer\x18\a \x01(\tR\fmanufacturer\x12!\n" +
+	"\fcredit_hours\x18\b \x01(\x05R\vcreditHours\x12#\n" +
+	"\rsemester_year\x18\t \x01(\x05R\fsemesterYear\x12\x1d\n" +
+	"\n" +
+	"date_added\x18\n" +
+	" \x01(\tR\tdateAdded\x12*\n" +
+	"\x11date_last_updated\x18\v \x01(\tR\x0fdateLastUpdated\x12\"\n" +
+	"\rowner_user_id\x18\f \x01(\tR\vownerUserId\x12\x17\n" +
+	"\aterm_id\x18\r \x01(\tR\x06termId\x12\x1f\n" +
+	"\bcapacity\x18\x0e \x01(\x05H\x00R\bcapacity\x88\x01\x01\x12\x18\n" +
+	"\aversion\x18\x0f \x01(\x03R\aversionB\v\n" +
+	"\t_capacity\"N\n" +
+	"\x12ListCoursesRequest\x12\x17\n" +
+	"\aterm_id\x18\x01 \x01(\tR\x06termId\x12\x1f\n" +
+	"\vterm_status\x18\x02 \x01(\tR\n" +
+	"termStatus\"E\n" +
+	"\x13ListCoursesResponse\x12.\n" +
+	"\acourses\x18\x01 \x03(\v2\x14.apiserver.v1.CourseR\acourses\"\"\n" +
+	"\x10GetCourseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"C\n" +
+	"\x13CreateCourseRequest\x12,\n" +
+	"\x06course\x18\x01 \x01(\v2\x14.apiserver.v1.CourseR\x06course\"\x98\x01\n" +
+	"\x13UpdateCourseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12,\n" +
+	"\x06course\x18\x02 \x01(\v2\x14.apiserver.v1.CourseR\x06course\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"j\n" +
+	"\x13DeleteCourseRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version2\x83\x03\n" +
+	"\rCourseService\x12R\n" +
+	"\vListCourses\x12 .apiserver.v1.ListCoursesRequest\x1a!.apiserver.v1.ListCoursesResponse\x12A\n" +
+	"\tGetCourse\x12\x1e.apiserver.v1.GetCourseRequest\x1a\x14.apiserver.v1.Course\x12G\n" +
+	"\fCreateCourse\x12!.apiserver.v1.CreateCourseRequest\x1a\x14.apiserver.v1.Course\x12G\n" +
+	"\fUpdateCourse\x12!.apiserver.v1.UpdateCourseRequest\x1a\x14.apiserver.v1.Course\x12I\n" +
+	"\fDeleteCourse\x12!.apiserver.v1.DeleteCourseRequest\x1a\x16.google.protobuf.EmptyB0Z.api-server/internal/pb/apiserverv1;apiserverv1b\x06proto3"
+
+var (
+	file_apiserver_v1_course_proto_rawDescOnce sync.Once
+	file_apiserver_v1_course_proto_rawDescData []byte
+)
+
+func file_apiserver_v1_course_proto_rawDescGZIP() []byte {
+	file_apiserver_v1_course_proto_rawDescOnce.Do(func() {
+		file_apiserver_v1_course_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apiserver_v1_course_proto_rawDesc), len(file_apiserver_v1_course_proto_rawDesc)))
+	})
+	return file_apiserver_v1_course_proto_rawDescData
+}
+
+var file_apiserver_v1_course_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_apiserver_v1_course_proto_goTypes = []any{
+	(*Course)(nil),              // 0: apiserver.v1.Course
+	(*ListCoursesRequest)(nil),  // 1: apiserver.v1.ListCoursesRequest
+	(*ListCoursesResponse)(nil), // 2: apiserver.v1.ListCoursesResponse
+	(*GetCourseRequest)(nil),    // 3: apiserver.v1.GetCourseRequest
+	(*CreateCourseRequest)(nil), // 4: apiserver.v1.CreateCourseRequest
+	(*UpdateCourseRequest)(nil), // 5: apiserver.v1.UpdateCourseRequest
+	(*DeleteCourseRequest)(nil), // 6: apiserver.v1.DeleteCourseRequest
+	(*emptypb.Empty)(nil),       // 7: google.protobuf.Empty
+}
+var file_apiserver_v1_course_proto_depIdxs = []int32{
+	0, // 0: apiserver.v1.ListCoursesResponse.courses:type_name -> apiserver.v1.Course
+	0, // 1: apiserver.v1.CreateCourseRequest.course:type_name -> apiserver.v1.Course
+	0, // 2: apiserver.v1.UpdateCourseRequest.course:type_name -> apiserver.v1.Course
+	1, // 3: apiserver.v1.CourseService.ListCourses:input_type -> apiserver.v1.ListCoursesRequest
+	3, // 4: apiserver.v1.CourseService.GetCourse:input_type -> apiserver.v1.GetCourseRequest
+	4, // 5: apiserver.v1.CourseService.CreateCourse:input_type -> apiserver.v1.CreateCourseRequest
+	5, // 6: apiserver.v1.CourseService.UpdateCourse:input_type -> apiserver.v1.UpdateCourseRequest
+	6, // 7: apiserver.v1.CourseService.DeleteCourse:input_type -> apiserver.v1.DeleteCourseRequest
+	2, // 8: apiserver.v1.CourseService.ListCourses:output_type -> apiserver.v1.ListCoursesResponse
+	0, // 9: apiserver.v1.CourseService.GetCourse:output_type -> apiserver.v1.Course
+	0, // 10: apiserver.v1.CourseService.CreateCourse:output_type -> apiserver.v1.Course
+	0, // 11: apiserver.v1.CourseService.UpdateCourse:output_type -> apiserver.v1.Course
+	7, // 12: apiserver.v1.CourseService.DeleteCourse:output_type -> google.protobuf.Empty
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_apiserver_v1_course_proto_init() }
+func file_apiserver_v1_course_proto_init() {
+	if File_apiserver_v1_course_proto != nil {
+		return
+	}
+	file_apiserver_v1_course_proto_msgTypes[0].OneofWrappers = []any{}
+	file_apiserver_v1_course_proto_msgTypes[5].OneofWrappers = []any{}
+	file_apiserver_v1_course_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apiserver_v1_course_proto_rawDesc), len(file_apiserver_v1_course_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_apiserver_v1_course_proto_goTypes,
+		DependencyIndexes: file_apiserver_v1_course_proto_depIdxs,
+		MessageInfos:      file_apiserver_v1_course_proto_msgTypes,
+	}.Build()
+	File_apiserver_v1_course_proto = out.File
+	file_apiserver_v1_course_proto_goTypes = nil
+	file_apiserver_v1_course_proto_depIdxs = nil
+}
@@ -0,0 +1,488 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: apiserver/v1/instructor.proto
+
+package apiserverv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Instructor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	DateCreated   string                 `protobuf:"bytes,4,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	Version       int64                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Instructor) Reset() {
+	*x = Instructor{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Instructor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Instructor) ProtoMessage() {}
+
+func (x *Instructor) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Instructor.ProtoReflect.Descriptor instead.
+func (*Instructor) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Instructor) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Instructor) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Instructor) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Instructor) GetDateCreated() string {
+	if x != nil {
+		return x.DateCreated
+	}
+	return ""
+}
+
+func (x *Instructor) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListInstructorsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInstructorsRequest) Reset() {
+	*x = ListInstructorsRequest{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstructorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstructorsRequest) ProtoMessage() {}
+
+func (x *ListInstructorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstructorsRequest.ProtoReflect.Descriptor instead.
+func (*ListInstructorsRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{1}
+}
+
+type ListInstructorsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instructors   []*Instructor          `protobuf:"bytes,1,rep,name=instructors,proto3" json:"instructors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInstructorsResponse) Reset() {
+	*x = ListInstructorsResponse{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstructorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstructorsResponse) ProtoMessage() {}
+
+func (x *ListInstructorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstructorsResponse.ProtoReflect.Descriptor instead.
+func (*ListInstructorsResponse) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListInstructorsResponse) GetInstructors() []*Instructor {
+	if x != nil {
+		return x.Instructors
+	}
+	return nil
+}
+
+type GetInstructorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInstructorRequest) Reset() {
+	*x = GetInstructorRequest{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInstructorRequest) ProtoMessage() {}
+
+func (x *GetInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInstructorRequest.ProtoReflect.Descriptor instead.
+func (*GetInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetInstructorRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateInstructorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Instructor    *Instructor            `protobuf:"bytes,1,opt,name=instructor,proto3" json:"instructor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateInstructorRequest) Reset() {
+	*x = CreateInstructorRequest{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateInstructorRequest) ProtoMessage() {}
+
+func (x *CreateInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateInstructorRequest.ProtoReflect.Descriptor instead.
+func (*CreateInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateInstructorRequest) GetInstructor() *Instructor {
+	if x != nil {
+		return x.Instructor
+	}
+	return nil
+}
+
+type UpdateInstructorRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Instructor      *Instructor            `protobuf:"bytes,2,opt,name=instructor,proto3" json:"instructor,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateInstructorRequest) Reset() {
+	*x = UpdateInstructorRequest{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInstructorRequest) ProtoMessage() {}
+
+func (x *UpdateInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInstructorRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateInstructorRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateInstructorRequest) GetInstructor() *Instructor {
+	if x != nil {
+		return x.Instructor
+	}
+	return nil
+}
+
+func (x *UpdateInstructorRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteInstructorRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteInstructorRequest) Reset() {
+	*x = DeleteInstructorRequest{}
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteInstructorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteInstructorRequest) ProtoMessage() {}
+
+func (x *DeleteInstructorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_instructor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteInstructorRequest.ProtoReflect.Descriptor instead.
+func (*DeleteInstructorRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_instructor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteInstructorRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteInstructorRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+var File_apiserver_v1_instructor_proto protoreflect.FileDescriptor
+
+const file_apiserver_v1_instructor_proto_rawDesc = "" +
+	"\n" +
+	"\x1dapiserver/v1/instructor.proto\x12\fapiserver.v1\x1a\x1bgoogle/protobuf/empty.proto\"\x86\x01\n" +
+	"\n" +
+	"Instructor\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12!\n" +
+	"\fdate_created\x18\x04 \x01(\tR\vdateCreated\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\"\x18\n" +
+	"\x16ListInstructorsRequest\"U\n" +
+	"\x17ListInstructorsResponse\x12:\n" +
+	"\vinstructors\x18\x01 \x03(\v2\x18.apiserver.v1.InstructorR\vinstructors\"&\n" +
+	"\x14GetInstructorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"S\n" +
+	"\x17CreateInstructorRequest\x128\n" +
+	"\n" +
+	"instructor\x18\x01 \x01(\v2\x18.apiserver.v1.InstructorR\n" +
+	"instructor\"\xa8\x01\n" +
+	"\x17UpdateInstructorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x128\n" +
+	"\n" +
+	"instructor\x18\x02 \x01(\v2\x18.apiserver.v1.InstructorR\n" +
+	"instructor\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"n\n" +
+	"\x17DeleteInstructorRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version2\xbf\x03\n" +
+	"\x11InstructorService\x12^\n" +
+	"\x0fListInstructors\x12$.apiserver.v1.ListInstructorsRequest\x1a%.apiserver.v1.ListInstructorsResponse\x12M\n" +
+	"\rGetInstructor\x12\".apiserver.v1.GetInstructorRequest\x1a\x18.apiserver.v1.Instructor\x12S\n" +
+	"\x10CreateInstructor\x12%.apiserver.v1.CreateInstructorRequest\x1a\x18.apiserver.v1.Instructor\x12S\n" +
+	"\x10UpdateInstructor\x12%.apiserver.v1.UpdateInstructorRequest\x1a\x18.apiserver.v1.Instructor\x12Q\n" +
+	"\x10DeleteInstructor\x12%.apiserver.v1.DeleteInstructorRequest\x1a\x16.google.protobuf.EmptyB0Z.api-server/internal/pb/apiserverv1;apiserverv1b\x06proto3"
+
+var (
+	file_apiserver_v1_instructor_proto_rawDescOnce sync.Once
+	file_apiserver_v1_instructor_proto_rawDescData []byte
+)
+
+func file_apiserver_v1_instructor_proto_rawDescGZIP() []byte {
+	file_apiserver_v1_instructor_proto_rawDescOnce.Do(func() {
+		file_apiserver_v1_instructor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apiserver_v1_instructor_proto_rawDesc), len(file_apiserver_v1_instructor_proto_rawDesc)))
+	})
+	return file_apiserver_v1_instructor_proto_rawDescData
+}
+
+var file_apiserver_v1_instructor_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_apiserver_v1_instructor_proto_goTypes = []any{
+	(*Instructor)(nil),              // 0: apiserver.v1.Instructor
+	(*ListInstructorsRequest)(nil),  // 1: apiserver.v1.ListInstructorsRequest
+	(*ListInstructorsResponse)(nil), // 2: apiserver.v1.ListInstructorsResponse
+	(*GetInstructorRequest)(nil),    // 3: apiserver.v1.GetInstructorRequest
+	(*CreateInstructorRequest)(nil), // 4: apiserver.v1.CreateInstructorRequest
+	(*UpdateInstructorRequest)(nil), // 5: apiserver.v1.UpdateInstructorRequest
+	(*DeleteInstructorRequest)(nil), // 6: apiserver.v1.DeleteInstructorRequest
+	(*emptypb.Empty)(nil),           // 7: google.protobuf.Empty
+}
+var file_apiserver_v1_instructor_proto_depIdxs = []int32{
+	0, // 0: apiserver.v1.ListInstructorsResponse.instructors:type_name -> apiserver.v1.Instructor
+	0, // 1: apiserver.v1.CreateInstructorRequest.instructor:type_name -> apiserver.v1.Instructor
+	0, // 2: apiserver.v1.UpdateInstructorRequest.instructor:type_name -> apiserver.v1.Instructor
+	1, // 3: apiserver.v1.InstructorService.ListInstructors:input_type -> apiserver.v1.ListInstructorsRequest
+	3, // 4: apiserver.v1.InstructorService.GetInstructor:input_type -> apiserver.v1.GetInstructorRequest
+	4, // 5: apiserver.v1.InstructorService.CreateInstructor:input_type -> apiserver.v1.CreateInstructorRequest
+	5, // 6: apiserver.v1.InstructorService.UpdateInstructor:input_type -> apiserver.v1.UpdateInstructorRequest
+	6, // 7: apiserver.v1.InstructorService.DeleteInstructor:input_type -> apiserver.v1.DeleteInstructorRequest
+	2, // 8: apiserver.v1.InstructorService.ListInstructors:output_type -> apiserver.v1.ListInstructorsResponse
+	0, // 9: apiserver.v1.InstructorService.GetInstructor:output_type -> apiserver.v1.Instructor
+	0, // 10: apiserver.v1.InstructorService.CreateInstructor:output_type -> apiserver.v1.Instructor
+	0, // 11: apiserver.v1.InstructorService.UpdateInstructor:output_type -> apiserver.v1.Instructor
+	7, // 12: apiserver.v1.InstructorService.DeleteInstructor:output_type -> google.protobuf.Empty
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_apiserver_v1_instructor_proto_init() }
+func file_apiserver_v1_instructor_proto_init() {
+	if File_apiserver_v1_instructor_proto != nil {
+		return
+	}
+	file_apiserver_v1_instructor_proto_msgTypes[5].OneofWrappers = []any{}
+	file_apiserver_v1_instructor_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apiserver_v1_instructor_proto_rawDesc), len(file_apiserver_v1_instructor_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_apiserver_v1_instructor_proto_goTypes,
+		DependencyIndexes: file_apiserver_v1_instructor_proto_depIdxs,
+		MessageInfos:      file_apiserver_v1_instructor_proto_msgTypes,
+	}.Build()
+	File_apiserver_v1_instructor_proto = out.File
+	file_apiserver_v1_instructor_proto_goTypes = nil
+	file_apiserver_v1_instructor_proto_depIdxs = nil
+}
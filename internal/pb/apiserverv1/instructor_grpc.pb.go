@@ -0,0 +1,280 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: apiserver/v1/instructor.proto
+
+package apiserverv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	InstructorService_ListInstructors_FullMethodName  = "/apiserver.v1.InstructorService/ListInstructors"
+	InstructorService_GetInstructor_FullMethodName    = "/apiserver.v1.InstructorService/GetInstructor"
+	InstructorService_CreateInstructor_FullMethodName = "/apiserver.v1.InstructorService/CreateInstructor"
+	InstructorService_UpdateInstructor_FullMethodName = "/apiserver.v1.InstructorService/UpdateInstructor"
+	InstructorService_DeleteInstructor_FullMethodName = "/apiserver.v1.InstructorService/DeleteInstructor"
+)
+
+// InstructorServiceClient is the client API for InstructorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// InstructorService manages instructors. It mirrors /instructors in the
+// REST API.
+type InstructorServiceClient interface {
+	ListInstructors(ctx context.Context, in *ListInstructorsRequest, opts ...grpc.CallOption) (*ListInstructorsResponse, error)
+	GetInstructor(ctx context.Context, in *GetInstructorRequest, opts ...grpc.CallOption) (*Instructor, error)
+	CreateInstructor(ctx context.Context, in *CreateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error)
+	UpdateInstructor(ctx context.Context, in *UpdateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error)
+	DeleteInstructor(ctx context.Context, in *DeleteInstructorRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type instructorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInstructorServiceClient(cc grpc.ClientConnInterface) InstructorServiceClient {
+	return &instructorServiceClient{cc}
+}
+
+func (c *instructorServiceClient) ListInstructors(ctx context.Context, in *ListInstructorsRequest, opts ...grpc.CallOption) (*ListInstructorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInstructorsResponse)
+	err := c.cc.Invoke(ctx, InstructorService_ListInstructors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instructorServiceClient) GetInstructor(ctx context.Context, in *GetInstructorRequest, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, InstructorService_GetInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instructorServiceClient) CreateInstructor(ctx context.Context, in *CreateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, InstructorService_CreateInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instructorServiceClient) UpdateInstructor(ctx context.Context, in *UpdateInstructorRequest, opts ...grpc.CallOption) (*Instructor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Instructor)
+	err := c.cc.Invoke(ctx, InstructorService_UpdateInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instructorServiceClient) DeleteInstructor(ctx context.Context, in *DeleteInstructorRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, InstructorService_DeleteInstructor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InstructorServiceServer is the server API for InstructorService service.
+// All implementations must embed UnimplementedInstructorServiceServer
+// for forward compatibility.
+//
+// InstructorService manages instructors. It mirrors /instructors in the
+// REST API.
+type InstructorServiceServer interface {
+	ListInstructors(context.Context, *ListInstructorsRequest) (*ListInstructorsResponse, error)
+	GetInstructor(context.Context, *GetInstructorRequest) (*Instructor, error)
+	CreateInstructor(context.Context, *CreateInstructorRequest) (*Instructor, error)
+	UpdateInstructor(context.Context, *UpdateInstructorRequest) (*Instructor, error)
+	DeleteInstructor(context.Context, *DeleteInstructorRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedInstructorServiceServer()
+}
+
+// UnimplementedInstructorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInstructorServiceServer struct{}
+
+func (UnimplementedInstructorServiceServer) ListInstructors(context.Context, *ListInstructorsRequest) (*ListInstructorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInstructors not implemented")
+}
+func (UnimplementedInstructorServiceServer) GetInstructor(context.Context, *GetInstructorRequest) (*Instructor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInstructor not implemented")
+}
+func (UnimplementedInstructorServiceServer) CreateInstructor(context.Context, *CreateInstructorRequest) (*Instructor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateInstructor not implemented")
+}
+func (UnimplementedInstructorServiceServer) UpdateInstructor(context.Context, *UpdateInstructorRequest) (*Instructor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateInstructor not implemented")
+}
+func (UnimplementedInstructorServiceServer) DeleteInstructor(context.Context, *DeleteInstructorRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteInstructor not implemented")
+}
+func (UnimplementedInstructorServiceServer) mustEmbedUnimplementedInstructorServiceServer() {}
+func (UnimplementedInstructorServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeInstructorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InstructorServiceServer will
+// result in compilation errors.
+type UnsafeInstructorServiceServer interface {
+	mustEmbedUnimplementedInstructorServiceServer()
+}
+
+func RegisterInstructorServiceServer(s grpc.ServiceRegistrar, srv InstructorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedInstructorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&InstructorService_ServiceDesc, srv)
+}
+
+func _InstructorService_ListInstructors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInstructorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstructorServiceServer).ListInstructors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstructorService_ListInstructors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstructorServiceServer).ListInstructors(ctx, req.(*ListInstructorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstructorService_GetInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstructorServiceServer).GetInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstructorService_GetInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstructorServiceServer).GetInstructor(ctx, req.(*GetInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstructorService_CreateInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstructorServiceServer).CreateInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstructorService_CreateInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstructorServiceServer).CreateInstructor(ctx, req.(*CreateInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstructorService_UpdateInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstructorServiceServer).UpdateInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstructorService_UpdateInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstructorServiceServer).UpdateInstructor(ctx, req.(*UpdateInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstructorService_DeleteInstructor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteInstructorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstructorServiceServer).DeleteInstructor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstructorService_DeleteInstructor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstructorServiceServer).DeleteInstructor(ctx, req.(*DeleteInstructorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// InstructorService_ServiceDesc is the grpc.ServiceDesc for InstructorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InstructorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "apiserver.v1.InstructorService",
+	HandlerType: (*InstructorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListInstructors",
+			Handler:    _InstructorService_ListInstructors_Handler,
+		},
+		{
+			MethodName: "GetInstructor",
+			Handler:    _InstructorService_GetInstructor_Handler,
+		},
+		{
+			MethodName: "CreateInstructor",
+			Handler:    _InstructorService_CreateInstructor_Handler,
+		},
+		{
+			MethodName: "UpdateInstructor",
+			Handler:    _InstructorService_UpdateInstructor_Handler,
+		},
+		{
+			MethodName: "DeleteInstructor",
+			Handler:    _InstructorService_DeleteInstructor_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "apiserver/v1/instructor.proto",
+}
@@ -0,0 +1,625 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: apiserver/v1/trace.proto
+
+package apiserverv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Trace is an uploaded file. status and status_error are set by the trace
+// processor and ignored in requests.
+type Trace struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	DateCreated   string                 `protobuf:"bytes,4,opt,name=date_created,json=dateCreated,proto3" json:"date_created,omitempty"`
+	BucketPath    string                 `protobuf:"bytes,5,opt,name=bucket_path,json=bucketPath,proto3" json:"bucket_path,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	StatusError   string                 `protobuf:"bytes,7,opt,name=status_error,json=statusError,proto3" json:"status_error,omitempty"`
+	Version       int64                  `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trace) Reset() {
+	*x = Trace{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trace) ProtoMessage() {}
+
+func (x *Trace) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trace.ProtoReflect.Descriptor instead.
+func (*Trace) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Trace) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Trace) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Trace) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *Trace) GetDateCreated() string {
+	if x != nil {
+		return x.DateCreated
+	}
+	return ""
+}
+
+func (x *Trace) GetBucketPath() string {
+	if x != nil {
+		return x.BucketPath
+	}
+	return ""
+}
+
+func (x *Trace) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Trace) GetStatusError() string {
+	if x != nil {
+		return x.StatusError
+	}
+	return ""
+}
+
+func (x *Trace) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListTracesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTracesRequest) Reset() {
+	*x = ListTracesRequest{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTracesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTracesRequest) ProtoMessage() {}
+
+func (x *ListTracesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTracesRequest.ProtoReflect.Descriptor instead.
+func (*ListTracesRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{1}
+}
+
+type ListTracesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Traces        []*Trace               `protobuf:"bytes,1,rep,name=traces,proto3" json:"traces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTracesResponse) Reset() {
+	*x = ListTracesResponse{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTracesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTracesResponse) ProtoMessage() {}
+
+func (x *ListTracesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTracesResponse.ProtoReflect.Descriptor instead.
+func (*ListTracesResponse) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTracesResponse) GetTraces() []*Trace {
+	if x != nil {
+		return x.Traces
+	}
+	return nil
+}
+
+type GetTraceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTraceRequest) Reset() {
+	*x = GetTraceRequest{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTraceRequest) ProtoMessage() {}
+
+func (x *GetTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTraceRequest.ProtoReflect.Descriptor instead.
+func (*GetTraceRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetTraceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type UploadTraceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*UploadTraceRequest_Metadata_
+	//	*UploadTraceRequest_Chunk
+	Data          isUploadTraceRequest_Data `protobuf_oneof:"data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadTraceRequest) Reset() {
+	*x = UploadTraceRequest{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadTraceRequest) ProtoMessage() {}
+
+func (x *UploadTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadTraceRequest.ProtoReflect.Descriptor instead.
+func (*UploadTraceRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UploadTraceRequest) GetData() isUploadTraceRequest_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *UploadTraceRequest) GetMetadata() *UploadTraceRequest_Metadata {
+	if x != nil {
+		if x, ok := x.Data.(*UploadTraceRequest_Metadata_); ok {
+			return x.Metadata
+		}
+	}
+	return nil
+}
+
+func (x *UploadTraceRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Data.(*UploadTraceRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadTraceRequest_Data interface {
+	isUploadTraceRequest_Data()
+}
+
+type UploadTraceRequest_Metadata_ struct {
+	Metadata *UploadTraceRequest_Metadata `protobuf:"bytes,1,opt,name=metadata,proto3,oneof"`
+}
+
+type UploadTraceRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*UploadTraceRequest_Metadata_) isUploadTraceRequest_Data() {}
+
+func (*UploadTraceRequest_Chunk) isUploadTraceRequest_Data() {}
+
+type UpdateTraceRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Trace           *Trace                 `protobuf:"bytes,2,opt,name=trace,proto3" json:"trace,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateTraceRequest) Reset() {
+	*x = UpdateTraceRequest{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTraceRequest) ProtoMessage() {}
+
+func (x *UpdateTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTraceRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTraceRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateTraceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateTraceRequest) GetTrace() *Trace {
+	if x != nil {
+		return x.Trace
+	}
+	return nil
+}
+
+func (x *UpdateTraceRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteTraceRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedVersion *int64                 `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteTraceRequest) Reset() {
+	*x = DeleteTraceRequest{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTraceRequest) ProtoMessage() {}
+
+func (x *DeleteTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTraceRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTraceRequest) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteTraceRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteTraceRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
+type UploadTraceRequest_Metadata struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// file_name must end in .pdf.
+	FileName string `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	// content_type defaults to application/pdf.
+	ContentType   string `protobuf:"bytes,3,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadTraceRequest_Metadata) Reset() {
+	*x = UploadTraceRequest_Metadata{}
+	mi := &file_apiserver_v1_trace_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadTraceRequest_Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadTraceRequest_Metadata) ProtoMessage() {}
+
+func (x *UploadTraceRequest_Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_apiserver_v1_trace_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadTraceRequest_Metadata.ProtoReflect.Descriptor instead.
+func (*UploadTraceRequest_Metadata) Descriptor() ([]byte, []int) {
+	return file_apiserver_v1_trace_proto_rawDescGZIP(), []int{4, 0}
+}
+
+func (x *UploadTraceRequest_Metadata) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UploadTraceRequest_Metadata) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *UploadTraceRequest_Metadata) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+var File_apiserver_v1_trace_proto protoreflect.FileDescriptor
+
+const file_apiserver_v1_trace_proto_rawDesc = "" +
+	"\n" +
+	"\x18apiserver/v1/trace.proto\x12\fapiserver.v1\x1a\x1bgoogle/protobuf/empty.proto\"\xe6\x01\n" +
+	"\x05Trace\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12!\n" +
+	"\fdate_created\x18\x04 \x01(\tR\vdateCreated\x12\x1f\n" +
+	"\vbucket_path\x18\x05 \x01(\tR\n" +
+	"bucketPath\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12!\n" +
+	"\fstatus_error\x18\a \x01(\tR\vstatusError\x12\x18\n" +
+	"\aversion\x18\b \x01(\x03R\aversion\"\x13\n" +
+	"\x11ListTracesRequest\"A\n" +
+	"\x12ListTracesResponse\x12+\n" +
+	"\x06traces\x18\x01 \x03(\v2\x13.apiserver.v1.TraceR\x06traces\"!\n" +
+	"\x0fGetTraceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xe2\x01\n" +
+	"\x12UploadTraceRequest\x12G\n" +
+	"\bmetadata\x18\x01 \x01(\v2).apiserver.v1.UploadTraceRequest.MetadataH\x00R\bmetadata\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunk\x1ac\n" +
+	"\bMetadata\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tfile_name\x18\x02 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x03 \x01(\tR\vcontentTypeB\x06\n" +
+	"\x04data\"\x94\x01\n" +
+	"\x12UpdateTraceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
+	"\x05trace\x18\x02 \x01(\v2\x13.apiserver.v1.TraceR\x05trace\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"i\n" +
+	"\x12DeleteTraceRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x10expected_version\x18\x02 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version2\xf6\x02\n" +
+	"\fTraceService\x12O\n" +
+	"\n" +
+	"ListTraces\x12\x1f.apiserver.v1.ListTracesRequest\x1a .apiserver.v1.ListTracesResponse\x12>\n" +
+	"\bGetTrace\x12\x1d.apiserver.v1.GetTraceRequest\x1a\x13.apiserver.v1.Trace\x12F\n" +
+	"\vUploadTrace\x12 .apiserver.v1.UploadTraceRequest\x1a\x13.apiserver.v1.Trace(\x01\x12D\n" +
+	"\vUpdateTrace\x12 .apiserver.v1.UpdateTraceRequest\x1a\x13.apiserver.v1.Trace\x12G\n" +
+	"\vDeleteTrace\x12 .apiserver.v1.DeleteTraceRequest\x1a\x16.google.protobuf.EmptyB0Z.api-server/internal/pb/apiserverv1;apiserverv1b\x06proto3"
+
+var (
+	file_apiserver_v1_trace_proto_rawDescOnce sync.Once
+	file_apiserver_v1_trace_proto_rawDescData []byte
+)
+
+func file_apiserver_v1_trace_proto_rawDescGZIP() []byte {
+	file_apiserver_v1_trace_proto_rawDescOnce.Do(func() {
+		file_apiserver_v1_trace_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_apiserver_v1_trace_proto_rawDesc), len(file_apiserver_v1_trace_proto_rawDesc)))
+	})
+	return file_apiserver_v1_trace_proto_rawDescData
+}
+
+var file_apiserver_v1_trace_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_apiserver_v1_trace_proto_goTypes = []any{
+	(*Trace)(nil),                       // 0: apiserver.v1.Trace
+	(*ListTracesRequest)(nil),           // 1: apiserver.v1.ListTracesRequest
+	(*ListTracesResponse)(nil),          // 2: apiserver.v1.ListTracesResponse
+	(*GetTraceRequest)(nil),             // 3: apiserver.v1.GetTraceRequest
+	(*UploadTraceRequest)(nil),          // 4: apiserver.v1.UploadTraceRequest
+	(*UpdateTraceRequest)(nil),          // 5: apiserver.v1.UpdateTraceRequest
+	(*DeleteTraceRequest)(nil),          // 6: apiserver.v1.DeleteTraceRequest
+	(*UploadTraceRequest_Metadata)(nil), // 7: apiserver.v1.UploadTraceRequest.Metadata
+	(*emptypb.Empty)(nil),               // 8: google.protobuf.Empty
+}
+var file_apiserver_v1_trace_proto_depIdxs = []int32{
+	0, // 0: apiserver.v1.ListTracesResponse.traces:type_name -> apiserver.v1.Trace
+	7, // 1: apiserver.v1.UploadTraceRequest.metadata:type_name -> apiserver.v1.UploadTraceRequest.Metadata
+	0, // 2: apiserver.v1.UpdateTraceRequest.trace:type_name -> apiserver.v1.Trace
+	1, // 3: apiserver.v1.TraceService.ListTraces:input_type -> apiserver.v1.ListTracesRequest
+	3, // 4: apiserver.v1.TraceService.GetTrace:input_type -> apiserver.v1.GetTraceRequest
+	4, // 5: apiserver.v1.TraceService.UploadTrace:input_type -> apiserver.v1.UploadTraceRequest
+	5, // 6: apiserver.v1.TraceService.UpdateTrace:input_type -> apiserver.v1.UpdateTraceRequest
+	6, // 7: apiserver.v1.TraceService.DeleteTrace:input_type -> apiserver.v1.DeleteTraceRequest
+	2, // 8: apiserver.v1.TraceService.ListTraces:output_type -> apiserver.v1.ListTracesResponse
+	0, // 9: apiserver.v1.TraceService.GetTrace:output_type -> apiserver.v1.Trace
+	0, // 10: apiserver.v1.TraceService.UploadTrace:output_type -> apiserver.v1.Trace
+	0, // 11: apiserver.v1.TraceService.UpdateTrace:output_type -> apiserver.v1.Trace
+	8, // 12: apiserver.v1.TraceService.DeleteTrace:output_type -> google.protobuf.Empty
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_apiserver_v1_trace_proto_init() }
+func file_apiserver_v1_trace_proto_init() {
+	if File_apiserver_v1_trace_proto != nil {
+		return
+	}
+	file_apiserver_v1_trace_proto_msgTypes[4].OneofWrappers = []any{
+		(*UploadTraceRequest_Metadata_)(nil),
+		(*UploadTraceRequest_Chunk)(nil),
+	}
+	file_apiserver_v1_trace_proto_msgTypes[5].OneofWrappers = []any{}
+	file_apiserver_v1_trace_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apiserver_v1_trace_proto_rawDesc), len(file_apiserver_v1_trace_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_apiserver_v1_trace_proto_goTypes,
+		DependencyIndexes: file_apiserver_v1_trace_proto_depIdxs,
+		MessageInfos:      file_apiserver_v1_trace_proto_msgTypes,
+	}.Build()
+	File_apiserver_v1_trace_proto = out.File
+	file_apiserver_v1_trace_proto_goTypes = nil
+	file_apiserver_v1_trace_proto_depIdxs = nil
+}
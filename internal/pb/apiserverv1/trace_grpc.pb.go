@@ -0,0 +1,280 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: apiserver/v1/trace.proto
+
+package apiserverv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TraceService_ListTraces_FullMethodName  = "/apiserver.v1.TraceService/ListTraces"
+	TraceService_GetTrace_FullMethodName    = "/apiserver.v1.TraceService/GetTrace"
+	TraceService_UploadTrace_FullMethodName = "/apiserver.v1.TraceService/UploadTrace"
+	TraceService_UpdateTrace_FullMethodName = "/apiserver.v1.TraceService/UpdateTrace"
+	TraceService_DeleteTrace_FullMethodName = "/apiserver.v1.TraceService/DeleteTrace"
+)
+
+// TraceServiceClient is the client API for TraceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// TraceService manages traces, PDF files uploaded by users. It mirrors
+// /traces in the REST API.
+type TraceServiceClient interface {
+	ListTraces(ctx context.Context, in *ListTracesRequest, opts ...grpc.CallOption) (*ListTracesResponse, error)
+	GetTrace(ctx context.Context, in *GetTraceRequest, opts ...grpc.CallOption) (*Trace, error)
+	// UploadTrace stores a PDF as a new trace. The first message carries the
+	// metadata and the rest the file, in order. The trace is returned as
+	// uploaded; it is processed in the background.
+	UploadTrace(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadTraceRequest, Trace], error)
+	UpdateTrace(ctx context.Context, in *UpdateTraceRequest, opts ...grpc.CallOption) (*Trace, error)
+	DeleteTrace(ctx context.Context, in *DeleteTraceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type traceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTraceServiceClient(cc grpc.ClientConnInterface) TraceServiceClient {
+	return &traceServiceClient{cc}
+}
+
+func (c *traceServiceClient) ListTraces(ctx context.Context, in *ListTracesRequest, opts ...grpc.CallOption) (*ListTracesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTracesResponse)
+	err := c.cc.Invoke(ctx, TraceService_ListTraces_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traceServiceClient) GetTrace(ctx context.Context, in *GetTraceRequest, opts ...grpc.CallOption) (*Trace, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Trace)
+	err := c.cc.Invoke(ctx, TraceService_GetTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traceServiceClient) UploadTrace(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[UploadTraceRequest, Trace], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TraceService_ServiceDesc.Streams[0], TraceService_UploadTrace_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UploadTraceRequest, Trace]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TraceService_UploadTraceClient = grpc.ClientStreamingClient[UploadTraceRequest, Trace]
+
+func (c *traceServiceClient) UpdateTrace(ctx context.Context, in *UpdateTraceRequest, opts ...grpc.CallOption) (*Trace, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Trace)
+	err := c.cc.Invoke(ctx, TraceService_UpdateTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *traceServiceClient) DeleteTrace(ctx context.Context, in *DeleteTraceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, TraceService_DeleteTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TraceServiceServer is the server API for TraceService service.
+// All implementations must embed UnimplementedTraceServiceServer
+// for forward compatibility.
+//
+// TraceService manages traces, PDF files uploaded by users. It mirrors
+// /traces in the REST API.
+type TraceServiceServer interface {
+	ListTraces(context.Context, *ListTracesRequest) (*ListTracesResponse, error)
+	GetTrace(context.Context, *GetTraceRequest) (*Trace, error)
+	// UploadTrace stores a PDF as a new trace. The first message carries the
+	// metadata and the rest the file, in order. The trace is returned as
+	// uploaded; it is processed in the background.
+	UploadTrace(grpc.ClientStreamingServer[UploadTraceRequest, Trace]) error
+	UpdateTrace(context.Context, *UpdateTraceRequest) (*Trace, error)
+	DeleteTrace(context.Context, *DeleteTraceRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedTraceServiceServer()
+}
+
+// UnimplementedTraceServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTraceServiceServer struct{}
+
+func (UnimplementedTraceServiceServer) ListTraces(context.Context, *ListTracesRequest) (*ListTracesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTraces not implemented")
+}
+func (UnimplementedTraceServiceServer) GetTrace(context.Context, *GetTraceRequest) (*Trace, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTrace not implemented")
+}
+func (UnimplementedTraceServiceServer) UploadTrace(grpc.ClientStreamingServer[UploadTraceRequest, Trace]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadTrace not implemented")
+}
+func (UnimplementedTraceServiceServer) UpdateTrace(context.Context, *UpdateTraceRequest) (*Trace, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTrace not implemented")
+}
+func (UnimplementedTraceServiceServer) DeleteTrace(context.Context, *DeleteTraceRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTrace not implemented")
+}
+func (UnimplementedTraceServiceServer) mustEmbedUnimplementedTraceServiceServer() {}
+func (UnimplementedTraceServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeTraceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TraceServiceServer will
+// result in compilation errors.
+type UnsafeTraceServiceServer interface {
+	mustEmbedUnimplementedTraceServiceServer()
+}
+
+func RegisterTraceServiceServer(s grpc.ServiceRegistrar, srv TraceServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTraceServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TraceService_ServiceDesc, srv)
+}
+
+func _TraceService_ListTraces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTracesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraceServiceServer).ListTraces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraceService_ListTraces_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraceServiceServer).ListTraces(ctx, req.(*ListTracesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraceService_GetTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraceServiceServer).GetTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraceService_GetTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraceServiceServer).GetTrace(ctx, req.(*GetTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraceService_UploadTrace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TraceServiceServer).UploadTrace(&grpc.GenericServerStream[UploadTraceRequest, Trace]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TraceService_UploadTraceServer = grpc.ClientStreamingServer[UploadTraceRequest, Trace]
+
+func _TraceService_UpdateTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraceServiceServer).UpdateTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraceService_UpdateTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraceServiceServer).UpdateTrace(ctx, req.(*UpdateTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TraceService_DeleteTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TraceServiceServer).DeleteTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TraceService_DeleteTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TraceServiceServer).DeleteTrace(ctx, req.(*DeleteTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TraceService_ServiceDesc is the grpc.ServiceDesc for TraceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TraceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "apiserver.v1.TraceService",
+	HandlerType: (*TraceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTraces",
+			Handler:    _TraceService_ListTraces_Handler,
+		},
+		{
+			MethodName: "GetTrace",
+			Handler:    _TraceService_GetTrace_Handler,
+		},
+		{
+			MethodName: "UpdateTrace",
+			Handler:    _TraceService_UpdateTrace_Handler,
+		},
+		{
+			MethodName: "DeleteTrace",
+			Handler:    _TraceService_DeleteTrace_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadTrace",
+			Handler:       _TraceService_UploadTrace_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "apiserver/v1/trace.proto",
+}
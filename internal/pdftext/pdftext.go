@@ -0,0 +1,127 @@
+// Package pdftext extracts the text of PDF documents for search indexing.
+//
+// Documents are parsed with github.com/ledongthuc/pdf, which reads content
+// streams through any of the standard filters and decodes text drawn with the
+// standard, WinAnsi, MacRoman and PDFDoc encodings, Differences arrays and
+// ToUnicode maps. Extraction is best effort: text in images, encrypted
+// documents and pages the parser rejects are not recovered.
+package pdftext
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// wordGap is the negative TJ adjustment, in thousandths of an em, from which
+// a gap between two strings is read as a space.
+const wordGap = -200
+
+// Extract returns the text of the PDF document data with whitespace
+// collapsed to single spaces, truncated to at most limit bytes.
+func Extract(data []byte, limit int) string {
+	r, pages, err := open(data)
+	if err != nil {
+		return ""
+	}
+	var text strings.Builder
+	for i := 1; i <= pages && text.Len() <= limit*2; i++ {
+		showText(&text, r.Page(i))
+		text.WriteByte('\n')
+	}
+	return truncate(strings.Join(strings.Fields(text.String()), " "), limit)
+}
+
+// open parses data and returns its page count. The parser reports some
+// malformed documents by panicking; those become errors.
+func open(data []byte) (r *pdf.Reader, pages int, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("pdftext: %v", p)
+		}
+	}()
+	r, err = pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, 0, err
+	}
+	return r, r.NumPage(), nil
+}
+
+// showText appends the strings shown on page p. Operators that move to a new
+// line, and large negative kerning inside TJ arrays, become spaces. A page
+// the parser rejects partway contributes the text read before the error.
+func showText(text *strings.Builder, p pdf.Page) {
+	defer func() { recover() }()
+	if p.V.IsNull() || p.V.Key("Contents").IsNull() {
+		return
+	}
+
+	encoders := map[string]pdf.TextEncoding{}
+	var enc pdf.TextEncoding
+	show := func(s pdf.Value) {
+		if enc == nil {
+			text.WriteString(s.RawString())
+			return
+		}
+		text.WriteString(enc.Decode(s.RawString()))
+	}
+	pdf.Interpret(p.V.Key("Contents"), func(stk *pdf.Stack, op string) {
+		args := make([]pdf.Value, stk.Len())
+		for i := len(args) - 1; i >= 0; i-- {
+			args[i] = stk.Pop()
+		}
+		switch op {
+		case "Tf":
+			if len(args) != 2 {
+				return
+			}
+			name := args[0].Name()
+			e, ok := encoders[name]
+			if !ok {
+				e = p.Font(name).Encoder()
+				encoders[name] = e
+			}
+			enc = e
+		case "Tj":
+			if len(args) == 1 {
+				show(args[0])
+			}
+		case "'", `"`:
+			if len(args) > 0 {
+				text.WriteByte('\n')
+				show(args[len(args)-1])
+			}
+		case "TJ":
+			if len(args) != 1 {
+				return
+			}
+			for i := range args[0].Len() {
+				switch v := args[0].Index(i); v.Kind() {
+				case pdf.String:
+					show(v)
+				case pdf.Integer, pdf.Real:
+					if v.Float64() < wordGap {
+						text.WriteByte(' ')
+					}
+				}
+			}
+		case "ET":
+			text.WriteByte('\n')
+		case "T*", "Td", "TD", "Tm":
+			text.WriteByte(' ')
+		}
+	})
+}
+
+// truncate cuts s to at most limit bytes without splitting a UTF-8 sequence.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && s[limit]&0xC0 == 0x80 {
+		limit--
+	}
+	return s[:limit]
+}
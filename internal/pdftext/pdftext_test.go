@@ -0,0 +1,64 @@
+package pdftext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//go:generate go run testdata/generate.go
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"plain.pdf", "Course syllabus Office hours Mondays"},
+		{"winansi.pdf", "“Café” costs €5"},
+		{"macroman.pdf", "Résumé Müller"},
+		{"differences.pdf", "Façade café"},
+		{"tounicode.pdf", "TÜBC"},
+		{"pages.pdf", "first page second page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Extract(data, 1000); got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTruncates(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "winansi.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The limit falls inside the three-byte opening quote.
+	if got := Extract(data, 2); got != "" {
+		t.Errorf("Extract = %q, want no partial UTF-8 sequence", got)
+	}
+	if got := Extract(data, 8); got != "“Café" {
+		t.Errorf("Extract = %q, want %q", got, "“Café")
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "plain.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"not a PDF": []byte("GIF89a"),
+		"truncated": data[:len(data)/2],
+	} {
+		if got := Extract(data, 1000); got != "" {
+			t.Errorf("%s: Extract = %q, want no text", name, got)
+		}
+	}
+}
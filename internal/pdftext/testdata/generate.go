@@ -0,0 +1,134 @@
+//go:build ignore
+
+// Generate writes the fixture PDFs of the pdftext tests. Run it with
+// go generate in internal/pdftext.
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// stream is a stream object with its extra dictionary entries.
+type stream struct {
+	dict  string
+	data  []byte
+	flate bool
+}
+
+// document lays out objects 1..n as a PDF with a classic xref table.
+// Objects are strings or streams; object 1 must be the catalog.
+func document(objects ...any) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		switch obj := obj.(type) {
+		case string:
+			b.WriteString(obj)
+		case stream:
+			data, filter := obj.data, ""
+			if obj.flate {
+				var z bytes.Buffer
+				w := zlib.NewWriter(&z)
+				w.Write(data)
+				w.Close()
+				data, filter = z.Bytes(), " /Filter /FlateDecode"
+			}
+			fmt.Fprintf(&b, "<< /Length %d%s %s>>\nstream\n", len(data), filter, obj.dict)
+			b.Write(data)
+			b.WriteString("\nendstream")
+		}
+		b.WriteString("\nendobj\n")
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// page returns the objects of a one-page document showing content with
+// font as /F1. The font is object 5 and may be followed by more objects.
+func page(content stream, font string, more ...any) []any {
+	return append([]any{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		content,
+		font,
+	}, more...)
+}
+
+func main() {
+	fixtures := map[string][]byte{
+		// Text placed with Td and TJ kerning, in an uncompressed stream.
+		"plain.pdf": document(page(
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (Course) Tj 60 0 Td (syllabus) Tj ET\nBT /F1 12 Tf 72 700 Td [(Office)-250(hours)] TJ T* (Mondays) ' ET")},
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		)...),
+		// WinAnsi bytes for curly quotes, e acute and the euro sign, in a
+		// Flate-compressed stream.
+		"winansi.pdf": document(page(
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (\x93Caf\xe9\x94 costs \x805) Tj ET"), flate: true},
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		)...),
+		// MacRoman bytes for e acute and u umlaut.
+		"macroman.pdf": document(page(
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (R\x8esum\x8e M\x9fller) Tj ET"), flate: true},
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Times-Roman /Encoding /MacRomanEncoding >>",
+		)...),
+		// A Differences array remapping codes 1 and 2 to e acute and c cedilla.
+		"differences.pdf": document(page(
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (Fa\x02ade caf\x01) Tj ET")},
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences [1 /eacute /ccedilla] >> >>",
+		)...),
+		// Two-byte glyph IDs mapped to Unicode by a compressed ToUnicode CMap.
+		"tounicode.pdf": document(page(
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td <0001000200030004> Tj ET"), flate: true},
+			"<< /Type /Font /Subtype /Type0 /BaseFont /NotoSans /Encoding /Identity-H /DescendantFonts [] /ToUnicode 6 0 R >>",
+			stream{data: []byte(`/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+2 beginbfchar
+<0001> <0054>
+<0002> <00DC>
+endbfchar
+1 beginbfrange
+<0003> <0004> <0042>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`), flate: true},
+		)...),
+		// Two pages sharing one font.
+		"pages.pdf": document(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 6 0 R >> >> /Contents 5 0 R >>",
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 6 0 R >> >> /Contents 7 0 R >>",
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (first page) Tj ET"), flate: true},
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+			stream{data: []byte("BT /F1 12 Tf 72 720 Td (second page) Tj ET"), flate: true},
+		),
+	}
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join("testdata", name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
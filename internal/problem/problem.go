@@ -0,0 +1,89 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"api-server/internal/apperr"
+	"api-server/internal/requestid"
+)
+
+// ContentType is the media type defined by RFC 7807.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. RequestID is an extension
+// member that lets clients quote the failing request when reporting issues;
+// Dependents lists the records that block a delete and Errors lists every
+// invalid field of a rejected request body.
+type Problem struct {
+	Type       string                  `json:"type"`
+	Title      string                  `json:"title"`
+	Status     int                     `json:"status"`
+	Detail     string                  `json:"detail,omitempty"`
+	Instance   string                  `json:"instance,omitempty"`
+	RequestID  string                  `json:"request_id,omitempty"`
+	Dependents []apperr.Dependent      `json:"dependents,omitempty"`
+	Errors     []apperr.FieldViolation `json:"errors,omitempty"`
+}
+
+// New returns a problem for status with the given client-facing detail.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write sends p as the response, filling in the instance and request ID.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = requestid.FromContext(r.Context())
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error writes a problem with the given status and detail.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
+
+// WriteError maps err to a problem response. Errors from the apperr package
+// keep their client-safe message; anything else is logged and reported as a
+// 500 without details so that driver or SQL errors never reach clients.
+func WriteError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, apperr.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, apperr.ErrValidation):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, apperr.ErrStale):
+		status = http.StatusPreconditionFailed
+	}
+
+	if status == http.StatusInternalServerError {
+		logger.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		Error(w, r, status, "An unexpected error occurred.")
+		return
+	}
+
+	logger.DebugContext(r.Context(), "request rejected", "method", r.Method, "path", r.URL.Path, "status", status, "error", err)
+	p := New(status, apperr.Message(err))
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		p.Dependents = appErr.Dependents
+		p.Errors = appErr.Violations
+	}
+	Write(w, r, p)
+}
@@ -0,0 +1,225 @@
+// Package processing processes uploaded traces in the background.
+//
+// A trace is stored as uploaded. A Processor claims it and moves it to
+// scanning, where the stored object is checked to be a PDF, then to parsing,
+// where its text is extracted for search, and finally to ready, or to failed
+// with the reason. Every move is announced; see package tracestatus.
+package processing
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/events"
+	"api-server/internal/metrics"
+	"api-server/internal/model"
+	"api-server/internal/pdftext"
+	"api-server/internal/repository"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+)
+
+// Limits on text extraction. Larger uploads are stored but only found by
+// file name; extracted text is cut to keep its tsvector under PostgreSQL's
+// 1 MB limit.
+const (
+	maxExtractSize = 32 << 20
+	maxTraceText   = 256 << 10
+)
+
+const (
+	// batchSize is the number of traces claimed at a time. It is small so
+	// that claimed traces do not wait long behind each other.
+	batchSize = 5
+	// lease is how long a trace may stay claimed before another processor
+	// takes it over.
+	lease = 10 * time.Minute
+	// headerWindow is how far into a file the PDF header may start.
+	headerWindow = 1024
+)
+
+// Config controls how often the processor looks for uploaded traces. An
+// Interval of zero disables it, leaving new traces uploaded.
+type Config struct {
+	Interval time.Duration
+}
+
+// LoadConfig reads TRACE_PROCESS_INTERVAL (default 2s) as a Go duration.
+func LoadConfig() (Config, error) {
+	cfg := Config{Interval: 2 * time.Second}
+	if v := strings.TrimSpace(os.Getenv("TRACE_PROCESS_INTERVAL")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("invalid TRACE_PROCESS_INTERVAL %q: must be a non-negative duration such as 2s", v)
+		}
+		cfg.Interval = d
+	}
+	return cfg, nil
+}
+
+// Processor processes uploaded traces. Replicas may run one each; a trace
+// is claimed by one of them at a time.
+type Processor struct {
+	tr     *repository.TraceRepository
+	cfg    Config
+	client *storage.Client
+	logger *slog.Logger
+}
+
+// New returns a processor of the traces in db whose objects are read with
+// client.
+func New(db *sql.DB, cfg Config, client *storage.Client, logger *slog.Logger) *Processor {
+	return &Processor{
+		tr:     repository.NewTraceRepository(db),
+		cfg:    cfg,
+		client: client,
+		logger: logger.With("job", "trace_processing"),
+	}
+}
+
+// Run processes uploaded traces every Interval until ctx is cancelled. It
+// returns immediately if the processor is disabled.
+func (p *Processor) Run(ctx context.Context) {
+	if p.cfg.Interval == 0 {
+		return
+	}
+	p.logger.Info("trace processor started", "interval", p.cfg.Interval.String())
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Process(ctx); err != nil {
+			p.logger.ErrorContext(ctx, "trace processing failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process processes every uploaded trace, a batch at a time.
+func (p *Processor) Process(ctx context.Context) error {
+	ctx = actor.NewContext(ctx, actor.System)
+	for ctx.Err() == nil {
+		traces, err := p.tr.ClaimUploadedTraces(ctx, batchSize, lease)
+		if err != nil {
+			return err
+		}
+		for i := range traces {
+			p.process(ctx, &traces[i])
+		}
+		if len(traces) < batchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// process takes a claimed trace from scanning to ready or failed. A trace
+// whose object cannot be read for a transient reason is left scanning and
+// claimed again once its lease ends.
+func (p *Processor) process(ctx context.Context, trace *model.Trace) {
+	logger := p.logger.With("trace_record_id", trace.ID)
+
+	data, err := p.download(ctx, trace.BucketPath)
+	switch {
+	case errors.Is(err, storage.ErrObjectNotExist):
+		p.fail(ctx, logger, trace.ID, model.TraceScanning, "the uploaded file is missing from storage")
+		return
+	case err != nil:
+		logger.ErrorContext(ctx, "failed to read trace object, will retry", "bucket_path", trace.BucketPath, "error", err)
+		return
+	}
+
+	if reason := scan(data); reason != "" {
+		p.fail(ctx, logger, trace.ID, model.TraceScanning, reason)
+		return
+	}
+	if !p.advance(ctx, logger, trace.ID, model.TraceScanning, model.TraceParsing, "") {
+		return
+	}
+
+	var text string
+	if len(data) > maxExtractSize {
+		logger.InfoContext(ctx, "trace too large for text extraction", "max_size", maxExtractSize)
+	} else {
+		text = pdftext.Extract(data, maxTraceText)
+	}
+	parsed, err := events.New(ctx, "trace.parsed", trace.ID.String(), map[string]any{
+		"id":          trace.ID,
+		"user_id":     trace.UserID,
+		"text_length": len(text),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to build trace event", "error", err)
+		return
+	}
+	ok, err := p.tr.CompleteTrace(ctx, trace.ID, text, parsed)
+	switch {
+	case err != nil:
+		logger.ErrorContext(ctx, "failed to complete trace, will retry", "error", err)
+	case ok:
+		metrics.TracesProcessed.WithLabelValues(model.TraceReady).Inc()
+		logger.InfoContext(ctx, "trace processed", "text_length", len(text))
+	}
+}
+
+// download reads the object at bucketPath, up to one byte more than
+// maxExtractSize.
+func (p *Processor) download(ctx context.Context, bucketPath string) ([]byte, error) {
+	bucket, object, ok := strings.Cut(strings.TrimPrefix(bucketPath, "gs://"), "/")
+	if !ok || bucket == "" || object == "" {
+		return nil, fmt.Errorf("%w: invalid bucket path %q", storage.ErrObjectNotExist, bucketPath)
+	}
+	r, err := p.client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, maxExtractSize+1))
+}
+
+// scan returns why data is not an acceptable trace, or "" if it is.
+func scan(data []byte) string {
+	if len(data) == 0 {
+		return "the uploaded file is empty"
+	}
+	if !bytes.Contains(data[:min(len(data), headerWindow)], []byte("%PDF-")) {
+		return "the uploaded file is not a PDF document"
+	}
+	return ""
+}
+
+// advance moves a trace from status from to status to and reports whether
+// it did.
+func (p *Processor) advance(ctx context.Context, logger *slog.Logger, id uuid.UUID, from, to, reason string) bool {
+	ok, err := p.tr.AdvanceTrace(ctx, id, from, to, reason)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to update trace status, will retry", "status", to, "error", err)
+		return false
+	}
+	if !ok {
+		logger.InfoContext(ctx, "trace deleted or claimed elsewhere, abandoning", "status", from)
+	}
+	return ok
+}
+
+// fail moves a trace from status from to failed.
+func (p *Processor) fail(ctx context.Context, logger *slog.Logger, id uuid.UUID, from, reason string) {
+	if p.advance(ctx, logger, id, from, model.TraceFailed, reason) {
+		metrics.TracesProcessed.WithLabelValues(model.TraceFailed).Inc()
+		logger.WarnContext(ctx, "trace processing failed", "reason", reason)
+	}
+}
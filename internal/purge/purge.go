@@ -0,0 +1,217 @@
+package purge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"api-server/internal/metrics"
+	"api-server/internal/repository"
+
+	"cloud.google.com/go/storage"
+)
+
+// lockKey identifies the PostgreSQL advisory lock that keeps replicas from
+// purging at the same time.
+const lockKey = 0x70757267 // "purg"
+
+// Config controls how long soft-deleted records are kept and how often the
+// job looks for expired ones. An Interval of zero disables the job.
+type Config struct {
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// LoadConfig reads PURGE_RETENTION (default 720h) and PURGE_INTERVAL
+// (default 1h) as Go durations.
+func LoadConfig() (Config, error) {
+	cfg := Config{Retention: 30 * 24 * time.Hour, Interval: time.Hour}
+	for key, dst := range map[string]*time.Duration{
+		"PURGE_RETENTION": &cfg.Retention,
+		"PURGE_INTERVAL":  &cfg.Interval,
+	} {
+		v := strings.TrimSpace(os.Getenv(key))
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("invalid %s %q: must be a non-negative duration such as 720h", key, v)
+		}
+		*dst = d
+	}
+	return cfg, nil
+}
+
+// Job permanently removes soft-deleted records, and the trace objects they
+// point at, once they are older than the retention window. Completed webhook
+// deliveries and published events are kept for the same window.
+type Job struct {
+	db      *sql.DB
+	cfg     Config
+	client  *storage.Client
+	bucket  string
+	logger  *slog.Logger
+	enrolls *repository.EnrollmentRepository
+	assigns *repository.CourseInstructorRepository
+	meets   *repository.ScheduleRepository
+	courses *repository.CourseRepository
+	terms   *repository.TermRepository
+	instrs  *repository.InstructorRepository
+	users   *repository.UserRepository
+	traces  *repository.TraceRepository
+	keys    *repository.IdempotencyRepository
+	hooks   *repository.WebhookRepository
+	outbox  *repository.OutboxRepository
+}
+
+// New returns a purge job. Trace objects are deleted from bucket with client.
+func New(db *sql.DB, cfg Config, client *storage.Client, bucket string, logger *slog.Logger) *Job {
+	return &Job{
+		db:      db,
+		cfg:     cfg,
+		client:  client,
+		bucket:  bucket,
+		logger:  logger.With("job", "purge"),
+		enrolls: repository.NewEnrollmentRepository(db),
+		assigns: repository.NewCourseInstructorRepository(db),
+		meets:   repository.NewScheduleRepository(db),
+		courses: repository.NewCourseRepository(db),
+		terms:   repository.NewTermRepository(db),
+		instrs:  repository.NewInstructorRepository(db),
+		users:   repository.NewUserRepository(db),
+		traces:  repository.NewTraceRepository(db),
+		keys:    repository.NewIdempotencyRepository(db),
+		hooks:   repository.NewWebhookRepository(db),
+		outbox:  repository.NewOutboxRepository(db),
+	}
+}
+
+// Run purges every Interval until ctx is cancelled. It returns immediately if
+// the job is disabled.
+func (j *Job) Run(ctx context.Context) {
+	if j.cfg.Interval == 0 {
+		return
+	}
+	j.logger.Info("purge job started", "retention", j.cfg.Retention.String(), "interval", j.cfg.Interval.String())
+
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := j.Purge(ctx); err != nil {
+			j.logger.ErrorContext(ctx, "purge failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes records deleted before the retention window. Only one replica
+// purges at a time; the others skip the run.
+func (j *Job) Purge(ctx context.Context) error {
+	conn, err := j.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		j.logger.DebugContext(ctx, "another replica is purging, skipping")
+		return nil
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	cutoff := time.Now().Add(-j.cfg.Retention)
+
+	// Children go first so that their parents are no longer referenced.
+	var errs []error
+	if err := j.purgeTraces(ctx, cutoff); err != nil {
+		errs = append(errs, err)
+	}
+	for _, step := range []struct {
+		resource string
+		purge    func(time.Time) (int64, error)
+	}{
+		{"enrollment", j.enrolls.PurgeDeletedEnrollments},
+		{"course_instructor", j.assigns.PurgeDeletedCourseInstructors},
+		{"meeting", j.meets.PurgeDeletedMeetings},
+		{"course", j.courses.PurgeDeletedCourses},
+		{"term", j.terms.PurgeDeletedTerms},
+		{"instructor", j.instrs.PurgeDeletedInstructors},
+		{"user", j.users.PurgeDeletedUsers},
+		{"webhook_delivery", j.hooks.PurgeWebhookDeliveries},
+		{"webhook", j.hooks.PurgeDeletedWebhooks},
+		{"event_outbox", j.outbox.PurgeOutboxEvents},
+	} {
+		n, err := step.purge(cutoff)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("purge %ss: %w", step.resource, err))
+			continue
+		}
+		j.record(ctx, step.resource, n)
+	}
+
+	// Idempotency keys expire on their own schedule, not the retention window.
+	n, err := j.keys.PurgeExpiredIdempotencyKeys(time.Now())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("purge idempotency keys: %w", err))
+	} else {
+		j.record(ctx, "idempotency_key", n)
+	}
+	return errors.Join(errs...)
+}
+
+// purgeTraces deletes each expired trace's object before its row, so a
+// failed object delete leaves the row in place for the next run.
+func (j *Job) purgeTraces(ctx context.Context, cutoff time.Time) error {
+	traces, err := j.traces.DeletedTracesBefore(cutoff)
+	if err != nil {
+		return fmt.Errorf("list deleted traces: %w", err)
+	}
+
+	var n int64
+	for _, trace := range traces {
+		if object := objectName(trace.BucketPath); object != "" {
+			err := j.client.Bucket(j.bucket).Object(object).Delete(ctx)
+			if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				j.logger.WarnContext(ctx, "failed to delete trace object", "trace_record_id", trace.ID, "bucket", j.bucket, "object", object, "error", err)
+				continue
+			}
+		}
+		if err := j.traces.PurgeTrace(trace.ID); err != nil {
+			return fmt.Errorf("purge trace %s: %w", trace.ID, err)
+		}
+		n++
+	}
+	j.record(ctx, "trace", n)
+	return nil
+}
+
+func (j *Job) record(ctx context.Context, resource string, n int64) {
+	if n == 0 {
+		return
+	}
+	metrics.PurgedRecords.WithLabelValues(resource).Add(float64(n))
+	j.logger.InfoContext(ctx, "purged deleted records", "resource", resource, "count", n)
+}
+
+// objectName returns the object part of a gs://bucket/object path.
+func objectName(bucketPath string) string {
+	parts := strings.Split(bucketPath, "/")
+	if len(parts) > 2 {
+		return parts[len(parts)-1]
+	}
+	return ""
+}
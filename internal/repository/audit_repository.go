@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+const auditColumns = "id, occurred_at, actor, resource, resource_id, action, changes, COALESCE(request_id, ''), COALESCE(source_ip, '')"
+
+// AuditRepository appends to and reads from api.audit_log. The table is
+// append-only; a trigger rejects UPDATE and DELETE.
+type AuditRepository struct {
+	db dbtx
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// WithTx returns a copy of ar that runs its statements in tx.
+func (ar *AuditRepository) WithTx(tx *sql.Tx) *AuditRepository {
+	return &AuditRepository{db: tx}
+}
+
+// AppendAuditEvent stores event and sets its ID and OccurredAt.
+func (ar *AuditRepository) AppendAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+	return ar.db.QueryRowContext(ctx, "INSERT INTO api.audit_log (actor, resource, resource_id, action, changes, request_id, source_ip) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, '')) RETURNING id, occurred_at",
+		event.Actor, event.Resource, event.ResourceID, event.Action, changes, event.RequestID, event.SourceIP).Scan(&event.ID, &event.OccurredAt)
+}
+
+// ListAuditEvents returns events matching filter, newest first.
+func (ar *AuditRepository) ListAuditEvents(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, error) {
+	events := []model.AuditEvent{}
+	err := ar.StreamAuditEvents(ctx, filter, func(event *model.AuditEvent) error {
+		events = append(events, *event)
+		return nil
+	})
+	return events, err
+}
+
+// StreamAuditEvents calls fn for every event matching filter, newest first,
+// without loading them all into memory.
+func (ar *AuditRepository) StreamAuditEvents(ctx context.Context, filter model.AuditFilter, fn func(*model.AuditEvent) error) error {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Resource != "" {
+		add("resource = $%d", filter.Resource)
+	}
+	if filter.ResourceID != uuid.Nil {
+		add("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		add("occurred_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("occurred_at < $%d", filter.To)
+	}
+	if filter.BeforeID > 0 {
+		add("id < $%d", filter.BeforeID)
+	}
+
+	query := "SELECT " + auditColumns + " FROM api.audit_log"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := ar.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			event   model.AuditEvent
+			changes []byte
+		)
+		err := rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.Resource, &event.ResourceID, &event.Action, &changes, &event.RequestID, &event.SourceIP)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return err
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// courseInstructorReferences lists the columns that point at
+// api.course_instructor.
+var courseInstructorReferences []reference
+
+// courseInstructorColumns lists the columns of courseInstructorFrom in the
+// order rows are scanned.
+const courseInstructorColumns = "ci.id, ci.course_id, ci.instructor_id, i.name, ci.role, to_char(ci.start_date, 'YYYY-MM-DD'), to_char(ci.end_date, 'YYYY-MM-DD'), ci.date_created, ci.date_last_updated, ci.version"
+
+// courseInstructorFrom joins each assignment with its instructor.
+const courseInstructorFrom = " FROM api.course_instructor ci JOIN api.instructor i ON i.id = ci.instructor_id"
+
+// roleOrder sorts assignments lead first.
+const roleOrder = "CASE ci.role WHEN 'lead' THEN 0 WHEN 'co-instructor' THEN 1 ELSE 2 END"
+
+type CourseInstructorRepository struct {
+	db dbtx
+}
+
+func NewCourseInstructorRepository(db *sql.DB) *CourseInstructorRepository {
+	return &CourseInstructorRepository{db: db}
+}
+
+// WithTx returns a copy of cir that runs its statements in tx.
+func (cir *CourseInstructorRepository) WithTx(tx *sql.Tx) *CourseInstructorRepository {
+	return &CourseInstructorRepository{db: tx}
+}
+
+func scanCourseInstructor(row interface{ Scan(...any) error }, ci *model.CourseInstructor) error {
+	return row.Scan(&ci.ID, &ci.CourseID, &ci.InstructorID, &ci.InstructorName, &ci.Role, &ci.StartDate, &ci.EndDate, &ci.DateCreated, &ci.DateLastUpdated, &ci.Version)
+}
+
+// CheckReferences reports the instructor ID of ci if it does not match an
+// existing row.
+func (cir *CourseInstructorRepository) CheckReferences(ci *model.CourseInstructor) ([]apperr.FieldViolation, error) {
+	return checkForeignKeys(cir.db, foreignKey{field: "instructor_id", table: "api.instructor", id: ci.InstructorID})
+}
+
+// GetCourseInstructors returns the instructors assigned to a course, lead
+// first.
+func (cir *CourseInstructorRepository) GetCourseInstructors(courseID uuid.UUID) ([]model.CourseInstructor, error) {
+	if err := checkLive(cir.db, "api.course", "course", courseID); err != nil {
+		return nil, err
+	}
+	rows, err := cir.db.Query("SELECT "+courseInstructorColumns+courseInstructorFrom+
+		" WHERE ci.course_id = $1 AND ci.deleted_at IS NULL ORDER BY "+roleOrder+", ci.start_date NULLS FIRST, i.name", courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instructors := []model.CourseInstructor{}
+	for rows.Next() {
+		var ci model.CourseInstructor
+		if err := scanCourseInstructor(rows, &ci); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, ci)
+	}
+	return instructors, rows.Err()
+}
+
+// GetCourseInstructorsByCourseIDs returns the instructors assigned to the
+// courses with ids, each course's lead first as in GetCourseInstructors.
+// Unlike GetCourseInstructors it does not check that the courses exist.
+func (cir *CourseInstructorRepository) GetCourseInstructorsByCourseIDs(ctx context.Context, courseIDs []uuid.UUID) ([]model.CourseInstructor, error) {
+	rows, err := cir.db.QueryContext(ctx, "SELECT "+courseInstructorColumns+courseInstructorFrom+
+		" WHERE ci.course_id = ANY($1::uuid[]) AND ci.deleted_at IS NULL ORDER BY ci.course_id, "+roleOrder+", ci.start_date NULLS FIRST, i.name", idArray(courseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructors []model.CourseInstructor
+	for rows.Next() {
+		var ci model.CourseInstructor
+		if err := scanCourseInstructor(rows, &ci); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, ci)
+	}
+	return instructors, rows.Err()
+}
+
+func (cir *CourseInstructorRepository) GetCourseInstructorByID(courseID, id uuid.UUID) (*model.CourseInstructor, error) {
+	row := cir.db.QueryRow("SELECT "+courseInstructorColumns+courseInstructorFrom+
+		" WHERE ci.id = $1 AND ci.course_id = $2 AND ci.deleted_at IS NULL", id, courseID)
+	var ci model.CourseInstructor
+	if err := scanCourseInstructor(row, &ci); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("course_instructor", id)
+		}
+		return nil, err
+	}
+	return &ci, nil
+}
+
+// GetInstructorCourses returns the live courses an instructor is assigned
+// to, latest term first.
+func (cir *CourseInstructorRepository) GetInstructorCourses(instructorID uuid.UUID) ([]model.InstructorCourse, error) {
+	if err := checkLive(cir.db, "api.instructor", "instructor", instructorID); err != nil {
+		return nil, err
+	}
+	rows, err := cir.db.Query("SELECT ci.id, ci.role, to_char(ci.start_date, 'YYYY-MM-DD'), to_char(ci.end_date, 'YYYY-MM-DD'), "+courseColumns+courseFrom+
+		" JOIN api.course_instructor ci ON ci.course_id = c.id"+
+		" WHERE ci.instructor_id = $1 AND ci.deleted_at IS NULL AND c.deleted_at IS NULL ORDER BY t.start_date DESC, c.code, "+roleOrder, instructorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := []model.InstructorCourse{}
+	for rows.Next() {
+		var ic model.InstructorCourse
+		c := &ic.Course
+		err := rows.Scan(&ic.AssignmentID, &ic.Role, &ic.StartDate, &ic.EndDate,
+			&c.ID, &c.Code, &c.Name, &c.Section, &c.Description, &c.SemesterTerm, &c.Manufacturer, &c.CreditHours, &c.SemesterYear, &c.DateAdded, &c.DateLastUpdated, &c.OwnerUserID, &c.TermID, &c.Capacity, &c.Version)
+		if err != nil {
+			return nil, err
+		}
+		courses = append(courses, ic)
+	}
+	return courses, rows.Err()
+}
+
+// CreateCourseInstructor assigns ci.InstructorID to the course ci.CourseID,
+// which must be live. An assignment that double-books the instructor, because
+// the course meets at the same time as another of their courses, is a
+// conflict.
+func (cir *CourseInstructorRepository) CreateCourseInstructor(ci *model.CourseInstructor) error {
+	tx, err := begin(context.Background(), cir.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", scheduleLockKey); err != nil {
+		return err
+	}
+	if err := checkLive(tx, "api.course", "course", ci.CourseID); err != nil {
+		return err
+	}
+	if err := instructorConflicts(tx, ci.CourseID, ci.InstructorID); err != nil {
+		return err
+	}
+	if ci.ID == uuid.Nil {
+		ci.ID = uuid.New()
+	}
+	_, err = tx.Exec("INSERT INTO api.course_instructor (id, course_id, instructor_id, role, start_date, end_date) VALUES ($1, $2, $3, $4, $5, $6)",
+		ci.ID, ci.CourseID, ci.InstructorID, ci.Role, ci.StartDate, ci.EndDate)
+	if err != nil {
+		return translateCourseInstructorError(err)
+	}
+	ci.Version = 1
+	return tx.Commit()
+}
+
+// PatchCourseInstructor writes the listed fields of ci, leaving other
+// columns untouched. On success ci.Version holds the new version.
+func (cir *CourseInstructorRepository) PatchCourseInstructor(id uuid.UUID, ci *model.CourseInstructor, fields []string, ifMatch []int64) error {
+	version, err := patchByID(cir.db, "api.course_instructor", "course_instructor", id, fields, []column{
+		{field: "role", name: "role", value: ci.Role},
+		{field: "start_date", name: "start_date", value: ci.StartDate},
+		{field: "end_date", name: "end_date", value: ci.EndDate},
+	}, "date_last_updated", ifMatch)
+	if err != nil {
+		return translateCourseInstructorError(err)
+	}
+	ci.Version = version
+	return nil
+}
+
+// DeleteCourseInstructor soft-deletes an assignment.
+func (cir *CourseInstructorRepository) DeleteCourseInstructor(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(cir.db, "api.course_instructor", "course_instructor", id, ifMatch, courseInstructorReferences)
+}
+
+// PurgeDeletedCourseInstructors permanently removes assignments deleted
+// before cutoff and returns how many were removed.
+func (cir *CourseInstructorRepository) PurgeDeletedCourseInstructors(cutoff time.Time) (int64, error) {
+	return purgeDeleted(cir.db, "api.course_instructor", cutoff, courseInstructorReferences)
+}
+
+// translateCourseInstructorError reports the one-role-per-instructor rule in
+// terms clients understand before falling back to the generic translation.
+func translateCourseInstructorError(err error) error {
+	if isUniqueViolation(err) {
+		return apperr.Conflict("instructor already has this role on the course", err)
+	}
+	return translateError(err, "course_instructor")
+}
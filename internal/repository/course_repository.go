@@ -1,27 +1,71 @@
 package repository
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
-	"errors"
-	"fmt"
+	"time"
 
+	"api-server/internal/apperr"
 	"api-server/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// courseReferences lists the columns that point at api.course.
+var courseReferences = []reference{
+	{resource: "enrollment", table: "api.enrollment", column: "course_id"},
+	{resource: "course_instructor", table: "api.course_instructor", column: "course_id"},
+	{resource: "meeting", table: "api.course_meeting", column: "course_id"},
+}
+
+// courseColumns lists the columns of courseFrom in the order rows are scanned.
+const courseColumns = "c.id, c.code, c.name, c.section, c.description, t.semester_term, c.manufacturer, c.credithours, t.semester_year, c.date_added, c.date_last_updated, c.owner_user_id, c.term_id, c.capacity, c.version"
+
+// courseFrom joins each course with the term it is offered in.
+const courseFrom = " FROM api.course c JOIN api.term t ON t.id = c.term_id"
+
 type CourseRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewCourseRepository(db *sql.DB) *CourseRepository {
 	return &CourseRepository{db: db}
 }
 
-func (cr *CourseRepository) GetAllCourses() ([]model.Course, error) {
-	rows, err := cr.db.Query(`SELECT * FROM "api"."course"`)
+// WithTx returns a copy of cr that runs its statements in tx.
+func (cr *CourseRepository) WithTx(tx *sql.Tx) *CourseRepository {
+	return &CourseRepository{db: tx}
+}
+
+// CheckReferences reports the owner and term IDs of course that do not match
+// an existing row.
+func (cr *CourseRepository) CheckReferences(course *model.Course) ([]apperr.FieldViolation, error) {
+	return checkForeignKeys(cr.db,
+		foreignKey{field: "owner_user_id", table: "api.user", id: course.OwnerUserID},
+		foreignKey{field: "term_id", table: "api.term", id: course.TermID},
+	)
+}
+
+func scanCourse(row interface{ Scan(...any) error }, c *model.Course) error {
+	return row.Scan(&c.ID, &c.Code, &c.Name, &c.Section, &c.Description, &c.SemesterTerm, &c.Manufacturer, &c.CreditHours, &c.SemesterYear, &c.DateAdded, &c.DateLastUpdated, &c.OwnerUserID, &c.TermID, &c.Capacity, &c.Version)
+}
+
+// coursesMatching selects the live courses that match a filter of term ID
+// ($1) and term status ($2).
+var coursesMatching = "SELECT " + courseColumns + courseFrom + ` WHERE c.deleted_at IS NULL
+	AND ($1::uuid IS NULL OR c.term_id = $1) AND ($2 = '' OR ` + termStatus + ` = $2)`
+
+func courseFilterArgs(filter model.CourseFilter) []any {
+	return []any{uuid.NullUUID{UUID: filter.TermID, Valid: filter.TermID != uuid.Nil}, filter.TermStatus}
+}
+
+// GetAllCourses returns the live courses that match filter, for example the
+// courses of the current term.
+func (cr *CourseRepository) GetAllCourses(filter model.CourseFilter) ([]model.Course, error) {
+	rows, err := cr.db.Query(coursesMatching, courseFilterArgs(filter)...)
 	if err != nil {
-		fmt.Println("ERROR", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -29,8 +73,7 @@ func (cr *CourseRepository) GetAllCourses() ([]model.Course, error) {
 	var courses []model.Course
 	for rows.Next() {
 		var course model.Course
-		err = rows.Scan(&course.ID, &course.Code, &course.Name, &course.Description, &course.SemesterTerm, &course.Manufacturer, &course.CreditHours, &course.SemesterYear, &course.DateAdded, &course.DateLastUpdated, &course.OwnerUserID, &course.InstructorID)
-		if err != nil {
+		if err := scanCourse(rows, &course); err != nil {
 			return nil, err
 		}
 		courses = append(courses, course)
@@ -38,35 +81,140 @@ func (cr *CourseRepository) GetAllCourses() ([]model.Course, error) {
 	return courses, nil
 }
 
+// StreamCourses calls fn for every live course that matches filter, ordered
+// by term and code, reading them through a cursor; see streamRows.
+func (cr *CourseRepository) StreamCourses(ctx context.Context, filter model.CourseFilter, fn func(*model.Course) error) error {
+	return streamRows(ctx, cr.db, coursesMatching+" ORDER BY t.start_date, c.code, c.section", courseFilterArgs(filter), func(rows *sql.Rows) error {
+		var course model.Course
+		if err := scanCourse(rows, &course); err != nil {
+			return err
+		}
+		return fn(&course)
+	})
+}
+
 func (cr *CourseRepository) GetCourseByID(id uuid.UUID) (*model.Course, error) {
-	row := cr.db.QueryRow("SELECT * FROM api.course WHERE id = $1", id)
+	row := cr.db.QueryRow("SELECT "+courseColumns+courseFrom+" WHERE c.id = $1 AND c.deleted_at IS NULL", id)
 	var course model.Course
-	err := row.Scan(&course.ID, &course.Code, &course.Name, &course.Description, &course.SemesterTerm, &course.Manufacturer, &course.CreditHours, &course.SemesterYear, &course.DateAdded, &course.DateLastUpdated, &course.OwnerUserID, &course.InstructorID)
-	if err != nil {
+	if err := scanCourse(row, &course); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("course not found")
+			return nil, apperr.NotFound("course", id)
 		}
 		return nil, err
 	}
 	return &course, nil
 }
 
+// insertCourse and updateCourse write the columns of a course; updateCourse
+// returns the new version.
+var (
+	insertCourse = "INSERT INTO api.course (id, code, name, section, description, manufacturer, credithours, date_added, date_last_updated, owner_user_id, term_id, capacity) VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $8, $9, $10)"
+	updateCourse = "UPDATE api.course SET code = $1, name = $2, section = $3, description = $4, manufacturer = $5, credithours = $6, owner_user_id = $7, term_id = $8, capacity = $9, date_last_updated = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $10 AND deleted_at IS NULL AND " + versionMatches(11) + " RETURNING version"
+)
+
 func (cr *CourseRepository) CreateCourse(course *model.Course) error {
 	if course.ID == uuid.Nil {
 		course.ID = uuid.New()
 	}
-	_, err := cr.db.Exec("INSERT INTO api.course (id, code, name, description, semesterterm, manufacturer, credithours, semesteryear, date_added, date_last_updated, owner_user_id, instructorid) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $9, $10)",
-		course.ID, course.Code, course.Name, course.Description, course.SemesterTerm, course.Manufacturer, course.CreditHours, course.SemesterYear, course.OwnerUserID, course.InstructorID)
-	return err
+	if course.Section == "" {
+		course.Section = model.DefaultSection
+	}
+	_, err := cr.db.Exec(insertCourse,
+		course.ID, course.Code, course.Name, course.Section, course.Description, course.Manufacturer, course.CreditHours, course.OwnerUserID, course.TermID, course.Capacity)
+	course.Version = 1
+	return translateCourseError(err)
+}
+
+// UpdateCourse replaces the writable columns of course. A non-empty ifMatch
+// restricts the write to those row versions. On success course.Version
+// holds the new version.
+func (cr *CourseRepository) UpdateCourse(id uuid.UUID, course *model.Course, ifMatch []int64) error {
+	if course.Section == "" {
+		course.Section = model.DefaultSection
+	}
+	err := cr.db.QueryRow(updateCourse,
+		course.Code, course.Name, course.Section, course.Description, course.Manufacturer, course.CreditHours, course.OwnerUserID, course.TermID, course.Capacity, id, pq.Array(ifMatch)).Scan(&course.Version)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(cr.db, "api.course", "course", id)
+	}
+	return translateCourseError(err)
+}
+
+// PatchCourse writes the listed fields of course, leaving other columns
+// untouched. On success course.Version holds the new version.
+func (cr *CourseRepository) PatchCourse(id uuid.UUID, course *model.Course, fields []string, ifMatch []int64) error {
+	version, err := patchByID(cr.db, "api.course", "course", id, fields, []column{
+		{field: "code", name: "code", value: course.Code},
+		{field: "name", name: "name", value: course.Name},
+		{field: "section", name: "section", value: cmp.Or(course.Section, model.DefaultSection)},
+		{field: "description", name: "description", value: course.Description},
+		{field: "manufacturer", name: "manufacturer", value: course.Manufacturer},
+		{field: "credit_hours", name: "credithours", value: course.CreditHours},
+		{field: "owner_user_id", name: "owner_user_id", value: course.OwnerUserID},
+		{field: "term_id", name: "term_id", value: course.TermID},
+		{field: "capacity", name: "capacity", value: course.Capacity},
+	}, "date_last_updated", ifMatch)
+	if err != nil {
+		return translateCourseError(err)
+	}
+	course.Version = version
+	return nil
 }
 
-func (cr *CourseRepository) UpdateCourse(id uuid.UUID, course *model.Course) error {
-	_, err := cr.db.Exec("UPDATE api.course SET code = $1, name = $2, description = $3, semesterterm = $4, manufacturer = $5, credithours = $6, semesteryear = $7, date_last_updated = CURRENT_TIMESTAMP WHERE id = $8",
-		course.Code, course.Name, course.Description, course.SemesterTerm, course.Manufacturer, course.CreditHours, course.SemesterYear, id)
-	return err
+// DeleteCourse soft-deletes the course; see RestoreCourse.
+func (cr *CourseRepository) DeleteCourse(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(cr.db, "api.course", "course", id, ifMatch, courseReferences)
 }
 
-func (cr *CourseRepository) DeleteCourse(id uuid.UUID) error {
-	_, err := cr.db.Exec("DELETE FROM api.course WHERE id = $1", id)
-	return err
+// RestoreCourse undeletes a soft-deleted course and returns its new version.
+func (cr *CourseRepository) RestoreCourse(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(cr.db, "api.course", "course", id, ifMatch, []parent{
+		{field: "owner_user_id", column: "owner_user_id", table: "api.user"},
+		{field: "term_id", column: "term_id", table: "api.term"},
+	})
+	return version, translateCourseError(err)
+}
+
+// PurgeDeletedCourses permanently removes courses deleted before cutoff and
+// returns how many were removed.
+func (cr *CourseRepository) PurgeDeletedCourses(cutoff time.Time) (int64, error) {
+	return purgeDeleted(cr.db, "api.course", cutoff, courseReferences)
+}
+
+// translateCourseError reports a duplicate offering in terms clients
+// understand before falling back to the generic translation.
+func translateCourseError(err error) error {
+	if isUniqueViolation(err) {
+		return apperr.Conflict("a course with this code and section already exists in the term", err)
+	}
+	return translateError(err, "course")
+}
+
+// ImportCourses upserts courses in one transaction, matching existing
+// offerings on code, term and section. It reports whether the transaction
+// was committed; see importRows.
+func (cr *CourseRepository) ImportCourses(ctx context.Context, courses []model.Course, opts model.ImportOptions) ([]ImportResult[model.Course], bool, error) {
+	return importRows(ctx, cr.db, opts, courses, func(tx dbtx, course *model.Course) ImportResult[model.Course] {
+		if course.Section == "" {
+			course.Section = model.DefaultSection
+		}
+		var before model.Course
+		err := scanCourse(tx.QueryRowContext(ctx, "SELECT "+courseColumns+courseFrom+
+			" WHERE c.code = $1 AND c.term_id = $2 AND c.section = $3 AND c.deleted_at IS NULL FOR UPDATE OF c",
+			course.Code, course.TermID, course.Section), &before)
+		switch {
+		case err == sql.ErrNoRows:
+			course.ID = uuid.New()
+			_, err = tx.ExecContext(ctx, insertCourse,
+				course.ID, course.Code, course.Name, course.Section, course.Description, course.Manufacturer, course.CreditHours, course.OwnerUserID, course.TermID, course.Capacity)
+			course.Version = 1
+			return ImportResult[model.Course]{ID: course.ID, Err: translateCourseError(err)}
+		case err != nil:
+			return ImportResult[model.Course]{Err: err}
+		}
+		course.ID = before.ID
+		err = tx.QueryRowContext(ctx, updateCourse,
+			course.Code, course.Name, course.Section, course.Description, course.Manufacturer, course.CreditHours, course.OwnerUserID, course.TermID, course.Capacity, course.ID, pq.Array([]int64(nil))).Scan(&course.Version)
+		return ImportResult[model.Course]{ID: course.ID, Before: &before, Err: translateCourseError(err)}
+	})
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// cursorBatch is the number of rows fetched from a cursor at a time.
+const cursorBatch = 1000
+
+// fetchBatch fetches the next batch; FETCH does not accept a parameter for
+// the count.
+var fetchBatch = "FETCH FORWARD " + strconv.Itoa(cursorBatch) + " FROM export"
+
+// streamRows runs query through a server-side cursor and calls scan for each
+// row, fetching cursorBatch rows at a time, so that large results never sit
+// in memory on either side. The rows come from a single read-only snapshot.
+// An error from scan stops the stream and is returned.
+func streamRows(ctx context.Context, db dbtx, query string, args []any, scan func(*sql.Rows) error) error {
+	tx, err := begin(ctx, db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return err
+	}
+	for {
+		rows, err := tx.QueryContext(ctx, fetchBatch)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for rows.Next() {
+			n++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if n < cursorBatch {
+			return tx.Commit()
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// enrollmentReferences lists the columns that point at api.enrollment.
+var enrollmentReferences []reference
+
+// enrollmentColumns lists the columns of enrollmentRows in the order rows are
+// scanned.
+const enrollmentColumns = "id, course_id, user_id, term_id, status, waitlist_position, date_created, status_changed_at, version"
+
+// enrollmentRows selects live enrollments with their waitlist position,
+// aliased as w. The position is computed per course over the rows matched by
+// the inner condition, so callers narrow the result with an outer WHERE.
+const enrollmentRows = `(
+	SELECT e.*, CASE WHEN e.status = 'waitlisted'
+		THEN row_number() OVER (PARTITION BY e.course_id, e.status ORDER BY e.status_changed_at, e.id)
+		ELSE 0 END AS waitlist_position
+	FROM api.enrollment e
+	WHERE e.deleted_at IS NULL AND %s
+) w`
+
+// enrollmentTransitions lists the states an enrollment may move to from each
+// state. Dropped students rejoin by enrolling again.
+var enrollmentTransitions = map[string][]string{
+	model.EnrollmentEnrolled:   {model.EnrollmentDropped, model.EnrollmentCompleted},
+	model.EnrollmentWaitlisted: {model.EnrollmentEnrolled, model.EnrollmentDropped},
+}
+
+type EnrollmentRepository struct {
+	db dbtx
+}
+
+func NewEnrollmentRepository(db *sql.DB) *EnrollmentRepository {
+	return &EnrollmentRepository{db: db}
+}
+
+// WithTx returns a copy of er that runs its statements in tx.
+func (er *EnrollmentRepository) WithTx(tx *sql.Tx) *EnrollmentRepository {
+	return &EnrollmentRepository{db: tx}
+}
+
+func scanEnrollment(row interface{ Scan(...any) error }, e *model.Enrollment) error {
+	return row.Scan(&e.ID, &e.CourseID, &e.UserID, &e.TermID, &e.Status, &e.WaitlistPosition, &e.DateCreated, &e.StatusChangedAt, &e.Version)
+}
+
+// GetCourseEnrollments returns the roster of a course, optionally limited to
+// one status. Waitlisted students are listed in promotion order.
+func (er *EnrollmentRepository) GetCourseEnrollments(courseID uuid.UUID, status string) ([]model.Enrollment, error) {
+	if err := checkLive(er.db, "api.course", "course", courseID); err != nil {
+		return nil, err
+	}
+	query := "SELECT " + enrollmentColumns + " FROM " + fmt.Sprintf(enrollmentRows, "e.course_id = $1") +
+		" WHERE $2 = '' OR status = $2 ORDER BY status, waitlist_position, date_created"
+	rows, err := er.db.Query(query, courseID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []model.Enrollment{}
+	for rows.Next() {
+		var e model.Enrollment
+		if err := scanEnrollment(rows, &e); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+func (er *EnrollmentRepository) GetEnrollmentByID(courseID, id uuid.UUID) (*model.Enrollment, error) {
+	query := "SELECT " + enrollmentColumns + " FROM " + fmt.Sprintf(enrollmentRows, "e.course_id = $1") + " WHERE id = $2"
+	var e model.Enrollment
+	if err := scanEnrollment(er.db.QueryRow(query, courseID, id), &e); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("enrollment", id)
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetUserEnrollments returns a student's enrollments across all courses,
+// latest term first.
+func (er *EnrollmentRepository) GetUserEnrollments(userID uuid.UUID) ([]model.StudentEnrollment, error) {
+	if err := checkLive(er.db, "api.user", "user", userID); err != nil {
+		return nil, err
+	}
+	query := "SELECT w.id, w.course_id, w.user_id, w.term_id, w.status, w.waitlist_position, w.date_created, w.status_changed_at, w.version, c.code, c.name, t.name FROM " +
+		fmt.Sprintf(enrollmentRows, "e.course_id IN (SELECT course_id FROM api.enrollment WHERE user_id = $1)") +
+		" JOIN api.course c ON c.id = w.course_id JOIN api.term t ON t.id = w.term_id WHERE w.user_id = $1 ORDER BY t.start_date DESC, c.code"
+	rows, err := er.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []model.StudentEnrollment{}
+	for rows.Next() {
+		var se model.StudentEnrollment
+		e := &se.Enrollment
+		err := rows.Scan(&e.ID, &e.CourseID, &e.UserID, &e.TermID, &e.Status, &e.WaitlistPosition, &e.DateCreated, &e.StatusChangedAt, &e.Version, &se.CourseCode, &se.CourseName, &se.TermName)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, se)
+	}
+	return enrollments, rows.Err()
+}
+
+// GetInstructorRoster returns the enrolled and waitlisted students of every
+// live course an instructor is assigned to, in any role.
+func (er *EnrollmentRepository) GetInstructorRoster(instructorID uuid.UUID) ([]model.CourseRoster, error) {
+	if err := checkLive(er.db, "api.instructor", "instructor", instructorID); err != nil {
+		return nil, err
+	}
+
+	rows, err := er.db.Query(`SELECT c.id, c.code, c.name, c.term_id, t.semester_term, t.semester_year, c.capacity
+		FROM api.course c JOIN api.term t ON t.id = c.term_id
+		WHERE c.id IN (SELECT course_id FROM api.course_instructor WHERE instructor_id = $1 AND deleted_at IS NULL)
+		AND c.deleted_at IS NULL ORDER BY t.start_date DESC, c.code`, instructorID)
+	if err != nil {
+		return nil, err
+	}
+	rosters := []model.CourseRoster{}
+	index := map[uuid.UUID]int{}
+	for rows.Next() {
+		r := model.CourseRoster{Enrolled: []model.RosterEntry{}, Waitlisted: []model.RosterEntry{}}
+		if err := rows.Scan(&r.CourseID, &r.CourseCode, &r.CourseName, &r.TermID, &r.SemesterTerm, &r.SemesterYear, &r.Capacity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[r.CourseID] = len(rosters)
+		rosters = append(rosters, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT w.id, w.course_id, w.status, w.waitlist_position, u.id, u.first_name, u.last_name, u.username FROM " +
+		fmt.Sprintf(enrollmentRows, "e.course_id IN (SELECT ci.course_id FROM api.course_instructor ci JOIN api.course c ON c.id = ci.course_id WHERE ci.instructor_id = $1 AND ci.deleted_at IS NULL AND c.deleted_at IS NULL)") +
+		" JOIN api.user u ON u.id = w.user_id WHERE w.status IN ('enrolled', 'waitlisted') ORDER BY w.waitlist_position, u.last_name, u.first_name"
+	rows, err = er.db.Query(query, instructorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			entry    model.RosterEntry
+			courseID uuid.UUID
+			status   string
+		)
+		err := rows.Scan(&entry.EnrollmentID, &courseID, &status, &entry.WaitlistPosition, &entry.UserID, &entry.FirstName, &entry.LastName, &entry.Username)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[courseID]
+		if !ok {
+			continue // course assigned or deleted since the first query
+		}
+		r := &rosters[i]
+		if status == model.EnrollmentWaitlisted {
+			r.Waitlisted = append(r.Waitlisted, entry)
+		} else {
+			r.Enrolled = append(r.Enrolled, entry)
+		}
+	}
+	return rosters, rows.Err()
+}
+
+// Enroll adds a student to a course, or waitlists them if the course is full.
+// A student who dropped the course is enrolled again on the same record.
+// Enrolling is only possible during the registration window of the course's
+// term.
+func (er *EnrollmentRepository) Enroll(courseID, userID uuid.UUID) (*model.Enrollment, error) {
+	violations, err := checkForeignKeys(er.db, foreignKey{field: "user_id", table: "api.user", id: userID})
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+
+	tx, err := begin(context.Background(), er.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	course, err := lockCourse(tx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	waitlist, err := course.waitlist(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !course.registrationOpen {
+		return nil, apperr.Conflict(fmt.Sprintf("registration for term %v is closed", course.term), nil)
+	}
+	status := model.EnrollmentEnrolled
+	if full, err := course.full(tx); err != nil {
+		return nil, err
+	} else if full {
+		status = model.EnrollmentWaitlisted
+	}
+
+	var (
+		id       uuid.UUID
+		existing string
+	)
+	err = tx.QueryRow(`SELECT id, status FROM api.enrollment
+		WHERE course_id = $1 AND user_id = $2 AND term_id = $3 AND deleted_at IS NULL FOR UPDATE`,
+		courseID, userID, course.term).Scan(&id, &existing)
+	switch {
+	case err == sql.ErrNoRows:
+		id = uuid.New()
+		_, err = tx.Exec(`INSERT INTO api.enrollment (id, course_id, user_id, term_id, status)
+			VALUES ($1, $2, $3, $4, $5)`, id, courseID, userID, course.term, status)
+		if err != nil {
+			return nil, translateEnrollmentError(err)
+		}
+	case err != nil:
+		return nil, err
+	case existing != model.EnrollmentDropped:
+		return nil, apperr.Conflict(fmt.Sprintf("user %v is already %s in this course", userID, existing), nil)
+	default:
+		_, err = tx.Exec(`UPDATE api.enrollment SET status = $2, status_changed_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE id = $1`, id, status)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := course.touchMoved(tx, waitlist); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return er.GetEnrollmentByID(courseID, id)
+}
+
+// ChangeEnrollmentStatus moves an enrollment to status if the transition is
+// allowed and its version is one of ifMatch. Freeing a seat promotes the
+// next waitlisted students. It returns the IDs of promoted enrollments.
+func (er *EnrollmentRepository) ChangeEnrollmentStatus(courseID, id uuid.UUID, status string, ifMatch []int64) ([]uuid.UUID, error) {
+	return er.withEnrollment(courseID, id, ifMatch, func(tx dbtx, course *lockedCourse, current string) error {
+		if current == status {
+			return nil
+		}
+		if !slices.Contains(enrollmentTransitions[current], status) {
+			return apperr.Conflict(fmt.Sprintf("enrollment cannot change from %s to %s", current, status), nil)
+		}
+		if status == model.EnrollmentEnrolled {
+			full, err := course.full(tx)
+			if err != nil {
+				return err
+			}
+			if full {
+				return apperr.Conflict("course is full", nil)
+			}
+		}
+		_, err := tx.Exec(`UPDATE api.enrollment SET status = $2, status_changed_at = CURRENT_TIMESTAMP, version = version + 1
+			WHERE id = $1`, id, status)
+		return err
+	})
+}
+
+// DeleteEnrollment soft-deletes an enrollment record. Deleting an enrolled
+// student promotes the next waitlisted students, whose IDs are returned.
+func (er *EnrollmentRepository) DeleteEnrollment(courseID, id uuid.UUID, ifMatch []int64) ([]uuid.UUID, error) {
+	return er.withEnrollment(courseID, id, ifMatch, func(tx dbtx, _ *lockedCourse, _ string) error {
+		_, err := tx.Exec("UPDATE api.enrollment SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1", id)
+		return err
+	})
+}
+
+// PromoteWaitlisted enrolls waitlisted students while the course has room,
+// for example after its capacity was raised. It returns the promoted IDs.
+func (er *EnrollmentRepository) PromoteWaitlisted(courseID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := begin(context.Background(), er.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	course, err := lockCourse(tx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	waitlist, err := course.waitlist(tx)
+	if err != nil {
+		return nil, err
+	}
+	promoted, err := course.promote(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := course.touchMoved(tx, waitlist); err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
+
+// PurgeDeletedEnrollments permanently removes enrollments deleted before
+// cutoff and returns how many were removed.
+func (er *EnrollmentRepository) PurgeDeletedEnrollments(cutoff time.Time) (int64, error) {
+	return purgeDeleted(er.db, "api.enrollment", cutoff, enrollmentReferences)
+}
+
+// withEnrollment runs change on a locked enrollment and its course, then
+// promotes waitlisted students into any seat the change freed.
+func (er *EnrollmentRepository) withEnrollment(courseID, id uuid.UUID, ifMatch []int64, change func(dbtx, *lockedCourse, string) error) ([]uuid.UUID, error) {
+	tx, err := begin(context.Background(), er.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	course, err := lockCourse(tx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		status  string
+		version int64
+	)
+	err = tx.QueryRow("SELECT status, version FROM api.enrollment WHERE id = $1 AND course_id = $2 AND deleted_at IS NULL FOR UPDATE",
+		id, courseID).Scan(&status, &version)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("enrollment", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ifMatch) > 0 && !slices.Contains(ifMatch, version) {
+		return nil, apperr.Stale("enrollment", id)
+	}
+
+	waitlist, err := course.waitlist(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(tx, course, status); err != nil {
+		return nil, err
+	}
+	promoted, err := course.promote(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := course.touchMoved(tx, waitlist); err != nil {
+		return nil, err
+	}
+	return promoted, tx.Commit()
+}
+
+// lockedCourse is a course row locked FOR UPDATE, which serializes capacity
+// checks and promotions for the course.
+type lockedCourse struct {
+	id               uuid.UUID
+	term             uuid.UUID
+	capacity         sql.NullInt64
+	registrationOpen bool
+}
+
+func lockCourse(tx dbtx, id uuid.UUID) (*lockedCourse, error) {
+	c := &lockedCourse{id: id}
+	err := tx.QueryRow(`SELECT c.term_id, c.capacity, CURRENT_DATE BETWEEN t.registration_opens AND t.registration_closes
+		FROM api.course c JOIN api.term t ON t.id = c.term_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL FOR UPDATE OF c`, id).
+		Scan(&c.term, &c.capacity, &c.registrationOpen)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("course", id)
+	}
+	return c, err
+}
+
+func (c *lockedCourse) enrolled(tx dbtx) (int64, error) {
+	var n int64
+	err := tx.QueryRow("SELECT count(*) FROM api.enrollment WHERE course_id = $1 AND status = 'enrolled' AND deleted_at IS NULL", c.id).Scan(&n)
+	return n, err
+}
+
+func (c *lockedCourse) full(tx dbtx) (bool, error) {
+	if !c.capacity.Valid {
+		return false, nil
+	}
+	n, err := c.enrolled(tx)
+	return n >= c.capacity.Int64, err
+}
+
+// promote enrolls waitlisted students in the order they joined until the
+// course is full.
+func (c *lockedCourse) promote(tx dbtx) ([]uuid.UUID, error) {
+	var seats sql.NullInt64 // NULL is no LIMIT
+	if c.capacity.Valid {
+		n, err := c.enrolled(tx)
+		if err != nil {
+			return nil, err
+		}
+		if n >= c.capacity.Int64 {
+			return nil, nil
+		}
+		seats = sql.NullInt64{Int64: c.capacity.Int64 - n, Valid: true}
+	}
+
+	rows, err := tx.Query(`UPDATE api.enrollment SET status = 'enrolled', status_changed_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id IN (
+			SELECT id FROM api.enrollment
+			WHERE course_id = $1 AND status = 'waitlisted' AND deleted_at IS NULL
+			ORDER BY status_changed_at, id LIMIT $2
+		) RETURNING id`, c.id, seats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promoted []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, id)
+	}
+	return promoted, rows.Err()
+}
+
+// waitlist returns the IDs of the course's waitlisted enrollments in
+// promotion order.
+func (c *lockedCourse) waitlist(tx dbtx) ([]uuid.UUID, error) {
+	rows, err := tx.Query(`SELECT id FROM api.enrollment
+		WHERE course_id = $1 AND status = 'waitlisted' AND deleted_at IS NULL
+		ORDER BY status_changed_at, id`, c.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// touchMoved bumps the version of waitlisted enrollments whose position
+// differs from their index in before, so that their ETags change with
+// waitlist_position. Enrollments that joined or left the waitlist were
+// bumped by the write that moved them.
+func (c *lockedCourse) touchMoved(tx dbtx, before []uuid.UUID) error {
+	after, err := c.waitlist(tx)
+	if err != nil {
+		return err
+	}
+	positions := make(map[uuid.UUID]int, len(before))
+	for i, id := range before {
+		positions[id] = i
+	}
+	var moved []uuid.UUID
+	for i, id := range after {
+		if p, ok := positions[id]; ok && p != i {
+			moved = append(moved, id)
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	_, err = tx.Exec("UPDATE api.enrollment SET version = version + 1 WHERE id = ANY($1::uuid[])", idArray(moved))
+	return err
+}
+
+// checkLive returns a not found error unless table has a live row with id.
+func checkLive(db queryRower, table, resource string, id uuid.UUID) error {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperr.NotFound(resource, id)
+	}
+	return nil
+}
+
+// translateEnrollmentError reports the one-enrollment-per-term rule in terms
+// clients understand before falling back to the generic translation.
+func translateEnrollmentError(err error) error {
+	if isUniqueViolation(err) {
+		return apperr.Conflict("user is already enrolled in this course for the term", err)
+	}
+	return translateError(err, "enrollment")
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"api-server/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+var enrollmentRowColumns = []string{"id", "course_id", "user_id", "term_id", "status", "waitlist_position", "date_created", "status_changed_at", "version"}
+
+// expectLockCourse expects a course with capacity to be locked during its
+// registration window.
+func expectLockCourse(mock sqlmock.Sqlmock, courseID, termID uuid.UUID, capacity int64) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM api.course c JOIN api.term t ON t.id = c.term_id")).
+		WithArgs(courseID).
+		WillReturnRows(sqlmock.NewRows([]string{"term_id", "capacity", "open"}).AddRow(termID, capacity, true))
+}
+
+func expectWaitlist(mock sqlmock.Sqlmock, courseID uuid.UUID, ids ...uuid.UUID) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery("SELECT id FROM api.enrollment\\s+WHERE course_id = \\$1 AND status = 'waitlisted'").
+		WithArgs(courseID).
+		WillReturnRows(rows)
+}
+
+func expectEnrolledCount(mock sqlmock.Sqlmock, courseID uuid.UUID, n int64) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM api.enrollment WHERE course_id = $1 AND status = 'enrolled'")).
+		WithArgs(courseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(n))
+}
+
+func TestEnrollWaitlistsWhenCourseIsFull(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	courseID, userID, termID := uuid.New(), uuid.New(), uuid.New()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM api.user")).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	expectLockCourse(mock, courseID, termID, 2)
+	expectWaitlist(mock, courseID)
+	expectEnrolledCount(mock, courseID, 2)
+	mock.ExpectQuery("SELECT id, status FROM api.enrollment").
+		WithArgs(courseID, userID, termID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO api.enrollment").
+		WithArgs(sqlmock.AnyArg(), courseID, userID, termID, model.EnrollmentWaitlisted).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWaitlist(mock, courseID)
+	mock.ExpectCommit()
+	now := time.Now()
+	mock.ExpectQuery("SELECT " + regexp.QuoteMeta(enrollmentColumns)).
+		WillReturnRows(sqlmock.NewRows(enrollmentRowColumns).
+			AddRow(uuid.New(), courseID, userID, termID, model.EnrollmentWaitlisted, 1, now, now, 1))
+
+	e, err := NewEnrollmentRepository(db).Enroll(courseID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Status != model.EnrollmentWaitlisted || e.WaitlistPosition != 1 {
+		t.Errorf("enrollment is %s at position %d, want waitlisted at 1", e.Status, e.WaitlistPosition)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDropPromotesWaitlisted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	courseID, termID, id := uuid.New(), uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	expectLockCourse(mock, courseID, termID, 1)
+	mock.ExpectQuery("SELECT status, version FROM api.enrollment").
+		WithArgs(id, courseID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "version"}).AddRow(model.EnrollmentEnrolled, 3))
+	expectWaitlist(mock, courseID, first, second)
+	mock.ExpectExec("UPDATE api.enrollment SET status = \\$2").
+		WithArgs(id, model.EnrollmentDropped).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The freed seat goes to the student who joined the waitlist first.
+	expectEnrolledCount(mock, courseID, 0)
+	mock.ExpectQuery("UPDATE api.enrollment SET status = 'enrolled'.*LIMIT \\$2").
+		WithArgs(courseID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(first))
+	// The student behind them moves up and gets a new version.
+	expectWaitlist(mock, courseID, second)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE api.enrollment SET version = version + 1 WHERE id = ANY($1::uuid[])")).
+		WithArgs(idArray([]uuid.UUID{second})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	promoted, err := NewEnrollmentRepository(db).ChangeEnrollmentStatus(courseID, id, model.EnrollmentDropped, []int64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uuid.UUID{first}; !reflect.DeepEqual(promoted, want) {
+		t.Errorf("promoted %v, want %v", promoted, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"api-server/internal/apperr"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes the repositories translate into domain errors.
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqNotNullViolation    = "23502"
+	pqCheckViolation      = "23514"
+	pqInvalidTextRepr     = "22P02"
+	pqStringTooLong       = "22001"
+)
+
+// translateError converts driver errors into apperr kinds so handlers can
+// report them without exposing SQL details. Unknown errors are returned as-is.
+func translateError(err error, resource string) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case pqUniqueViolation:
+		return apperr.Conflict(fmt.Sprintf("%s conflicts with an existing record", resource), err)
+	case pqForeignKeyViolation:
+		if col := keyColumn(pqErr.Detail); col != "" {
+			return apperr.Validation(fmt.Sprintf("%s references a %s that does not exist", resource, col), err)
+		}
+		return apperr.Validation(fmt.Sprintf("%s references a record that does not exist", resource), err)
+	case pqNotNullViolation:
+		return apperr.Validation(fmt.Sprintf("%s is missing a required field", resource), err)
+	case pqCheckViolation, pqInvalidTextRepr, pqStringTooLong:
+		return apperr.Validation(fmt.Sprintf("%s contains an invalid value", resource), err)
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation, for
+// repositories that explain their own unique rules.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
+
+// keyDetail matches the DETAIL of foreign key violations, for example
+// `Key (instructor_id)=(...) is not present in table "instructor".`
+var keyDetail = regexp.MustCompile(`^Key \(([a-z_]+)\)=`)
+
+func keyColumn(detail string) string {
+	if m := keyDetail.FindStringSubmatch(detail); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// reference is a column in another table that points at a resource.
+type reference struct {
+	resource string
+	table    string
+	column   string
+}
+
+// queryRower is satisfied by *sql.DB and *sql.Tx, for helpers that run inside
+// or outside a transaction.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// versionMatches returns a WHERE condition on the row version. The int8[]
+// parameter n lists the versions from If-Match; NULL accepts any version.
+func versionMatches(n int) string {
+	return fmt.Sprintf("($%[1]d::int8[] IS NULL OR version = ANY($%[1]d::int8[]))", n)
+}
+
+// idArray passes ids as a uuid[] parameter, which pq cannot encode from
+// []uuid.UUID directly.
+func idArray(ids []uuid.UUID) any {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return pq.Array(s)
+}
+
+// staleOrMissing explains why a conditional write matched no rows: either the
+// row does not exist (or is soft-deleted) or its version no longer matches
+// If-Match.
+func staleOrMissing(db queryRower, table, resource string, id uuid.UUID) error {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperr.Stale(resource, id)
+	}
+	return apperr.NotFound(resource, id)
+}
+
+// foreignKey is a column of the row being written that must point at an
+// existing row in table.
+type foreignKey struct {
+	field string // JSON field name reported to clients
+	table string
+	id    uuid.UUID
+}
+
+// checkForeignKeys reports a violation for every key whose target row does not
+// exist or is soft-deleted. Zero IDs are skipped; required-ness is checked by request validation.
+func checkForeignKeys(db dbtx, keys ...foreignKey) ([]apperr.FieldViolation, error) {
+	var violations []apperr.FieldViolation
+	for _, key := range keys {
+		if key.id == uuid.Nil {
+			continue
+		}
+		var exists bool
+		err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+key.table+" WHERE id = $1 AND deleted_at IS NULL)", key.id).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			violations = append(violations, apperr.FieldViolation{Field: key.field, Message: "does not exist"})
+		}
+	}
+	return violations, nil
+}
+
+// column pairs a JSON field with the column it is stored in and the value to
+// write when the field is patched.
+type column struct {
+	field string
+	name  string
+	value any
+}
+
+// patchByID updates only the columns whose fields are listed, plus the
+// optional touched timestamp column, and returns the new row version. The
+// version is only incremented when a column changes. Fields that are not
+// patchable are reported as violations and nothing is written. Driver errors
+// are returned untranslated so callers can apply resource-specific
+// translations.
+func patchByID(db queryRower, table, resource string, id uuid.UUID, fields []string, columns []column, touched string, ifMatch []int64) (int64, error) {
+	requested := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		requested[f] = true
+	}
+
+	var (
+		sets []string
+		args []any
+	)
+	for _, c := range columns {
+		if !requested[c.field] {
+			continue
+		}
+		delete(requested, c.field)
+		args = append(args, c.value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", c.name, len(args)))
+	}
+	if len(requested) > 0 {
+		var violations []apperr.FieldViolation
+		for _, f := range fields {
+			if requested[f] {
+				violations = append(violations, apperr.FieldViolation{Field: f, Message: "cannot be changed"})
+			}
+		}
+		return 0, apperr.Invalid(violations)
+	}
+	if len(sets) == 0 {
+		// An empty patch changes nothing but must still honor If-Match.
+		sets = append(sets, "version = version")
+	} else {
+		if touched != "" {
+			sets = append(sets, touched+" = CURRENT_TIMESTAMP")
+		}
+		sets = append(sets, "version = version + 1")
+	}
+
+	args = append(args, id, pq.Array(ifMatch))
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND deleted_at IS NULL AND %s RETURNING version",
+		table, strings.Join(sets, ", "), len(args)-1, versionMatches(len(args)))
+	var version int64
+	err := db.QueryRow(query, args...).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, staleOrMissing(db, table, resource, id)
+	}
+	return version, err
+}
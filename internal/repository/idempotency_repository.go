@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrLockLost reports that a request no longer holds its idempotency key
+// because its lease lapsed and a retry took the key over.
+var ErrLockLost = errors.New("idempotency key lock lost")
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// ClaimIdempotencyKey reserves key for a request with fingerprint until ttl
+// has passed, locking it for owner for lease. If the key is already taken
+// it returns the stored response instead, which has a zero Status while the
+// first request is in progress. An expired key is released and claimed
+// again, and a key whose request is in progress but whose lease has lapsed
+// is taken over by a request with the same fingerprint.
+func (ir *IdempotencyRepository) ClaimIdempotencyKey(ctx context.Context, actor, key, fingerprint string, owner uuid.UUID, ttl, lease time.Duration) (*model.IdempotentResponse, error) {
+	for {
+		if _, err := ir.db.ExecContext(ctx, "DELETE FROM api.idempotency_key WHERE actor = $1 AND key = $2 AND expires_at <= CURRENT_TIMESTAMP", actor, key); err != nil {
+			return nil, err
+		}
+		res, err := ir.db.ExecContext(ctx, `INSERT INTO api.idempotency_key (actor, key, fingerprint, locked_by, locked_until, expires_at)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5), CURRENT_TIMESTAMP + make_interval(secs => $6))
+			ON CONFLICT (actor, key) DO UPDATE SET locked_by = EXCLUDED.locked_by, locked_until = EXCLUDED.locked_until
+			WHERE idempotency_key.status IS NULL AND idempotency_key.locked_until <= CURRENT_TIMESTAMP
+				AND idempotency_key.fingerprint = EXCLUDED.fingerprint`,
+			actor, key, fingerprint, owner, lease.Seconds(), ttl.Seconds())
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 1 {
+			return nil, err
+		}
+
+		var (
+			stored  model.IdempotentResponse
+			status  sql.NullInt64
+			headers []byte
+		)
+		err = ir.db.QueryRowContext(ctx, "SELECT fingerprint, status, headers, body FROM api.idempotency_key WHERE actor = $1 AND key = $2",
+			actor, key).Scan(&stored.Fingerprint, &status, &headers, &stored.Body)
+		if err == sql.ErrNoRows {
+			// The first request failed and released the key in between.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		stored.Status = int(status.Int64)
+		if headers != nil {
+			if err := json.Unmarshal(headers, &stored.Header); err != nil {
+				return nil, err
+			}
+		}
+		return &stored, nil
+	}
+}
+
+// RenewIdempotencyLock extends the lease owner holds on key by lease. It
+// returns ErrLockLost if owner no longer holds the key because another
+// request took it over.
+func (ir *IdempotencyRepository) RenewIdempotencyLock(ctx context.Context, actor, key string, owner uuid.UUID, lease time.Duration) error {
+	res, err := ir.db.ExecContext(ctx, `UPDATE api.idempotency_key SET locked_until = CURRENT_TIMESTAMP + make_interval(secs => $1)
+		WHERE actor = $2 AND key = $3 AND locked_by = $4 AND status IS NULL`, lease.Seconds(), actor, key, owner)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return cmp.Or(err, ErrLockLost)
+	}
+	return nil
+}
+
+// SaveIdempotentResponse stores the response to the request that claimed
+// key for owner and unlocks the key. It returns ErrLockLost if owner no
+// longer holds the key.
+func (ir *IdempotencyRepository) SaveIdempotentResponse(ctx context.Context, actor, key string, owner uuid.UUID, resp *model.IdempotentResponse) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	res, err := ir.db.ExecContext(ctx, `UPDATE api.idempotency_key SET status = $1, headers = $2, body = $3, locked_by = NULL, locked_until = NULL
+		WHERE actor = $4 AND key = $5 AND locked_by = $6`,
+		resp.Status, headers, resp.Body, actor, key, owner)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return cmp.Or(err, ErrLockLost)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets key if owner holds it, so that a retry is
+// processed again.
+func (ir *IdempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, actor, key string, owner uuid.UUID) error {
+	_, err := ir.db.ExecContext(ctx, "DELETE FROM api.idempotency_key WHERE actor = $1 AND key = $2 AND locked_by = $3", actor, key, owner)
+	return err
+}
+
+// PurgeExpiredIdempotencyKeys removes keys that expired before now and
+// returns how many were removed.
+func (ir *IdempotencyRepository) PurgeExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	res, err := ir.db.Exec("DELETE FROM api.idempotency_key WHERE expires_at < $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ImportResult is the outcome of upserting one imported row. Before holds
+// the row as it was when the import updated it and is nil when the row was
+// inserted. Err is set when the row was rejected and nothing was written.
+type ImportResult[T any] struct {
+	ID     uuid.UUID
+	Before *T
+	Err    error
+}
+
+// importRows upserts rows in a single transaction. Each row runs under a
+// savepoint, so a rejected row is rolled back on its own and every row is
+// tried. The transaction commits when opts allow it: never on a dry run,
+// and in atomic mode only if no row failed. importRows reports whether it
+// committed. Errors are returned only when the transaction itself fails.
+func importRows[T any](ctx context.Context, db dbtx, opts model.ImportOptions, rows []T, upsert func(tx dbtx, row *T) ImportResult[T]) ([]ImportResult[T], bool, error) {
+	tx, err := begin(ctx, db, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	results := make([]ImportResult[T], len(rows))
+	failed := false
+	for i := range rows {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+			return nil, false, err
+		}
+		results[i] = upsert(tx, &rows[i])
+		release := "RELEASE SAVEPOINT import_row"
+		if results[i].Err != nil {
+			failed = true
+			release = "ROLLBACK TO SAVEPOINT import_row"
+		}
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if opts.DryRun || (failed && opts.Mode == model.ImportAtomic) {
+		return results, false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
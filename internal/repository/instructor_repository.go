@@ -1,24 +1,46 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
+	"time"
 
+	"api-server/internal/apperr"
 	"api-server/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// instructorReferences lists the columns that point at api.instructor.
+var instructorReferences = []reference{
+	{resource: "course_instructor", table: "api.course_instructor", column: "instructor_id"},
+}
+
+// instructorColumns lists the columns of api.instructor in the order rows are scanned.
+const instructorColumns = "id, user_id, name, date_created, version"
+
 type InstructorRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewInstructorRepository(db *sql.DB) *InstructorRepository {
 	return &InstructorRepository{db: db}
 }
 
+// WithTx returns a copy of ir that runs its statements in tx.
+func (ir *InstructorRepository) WithTx(tx *sql.Tx) *InstructorRepository {
+	return &InstructorRepository{db: tx}
+}
+
+// CheckReferences reports the user ID of instructor if it does not match an
+// existing row.
+func (ir *InstructorRepository) CheckReferences(instructor *model.Instructor) ([]apperr.FieldViolation, error) {
+	return checkForeignKeys(ir.db, foreignKey{field: "user_id", table: "api.user", id: instructor.UserID})
+}
+
 func (ir *InstructorRepository) GetAllInstructors() ([]model.Instructor, error) {
-	rows, err := ir.db.Query(`SELECT * FROM "api"."instructor"`)
+	rows, err := ir.db.Query("SELECT " + instructorColumns + " FROM api.instructor WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +49,7 @@ func (ir *InstructorRepository) GetAllInstructors() ([]model.Instructor, error)
 	var instructors []model.Instructor
 	for rows.Next() {
 		var instructor model.Instructor
-		err = rows.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated)
+		err = rows.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated, &instructor.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -36,35 +58,142 @@ func (ir *InstructorRepository) GetAllInstructors() ([]model.Instructor, error)
 	return instructors, nil
 }
 
+// StreamInstructors calls fn for every live instructor, ordered by name,
+// reading them through a cursor; see streamRows.
+func (ir *InstructorRepository) StreamInstructors(ctx context.Context, fn func(*model.Instructor) error) error {
+	return streamRows(ctx, ir.db, "SELECT "+instructorColumns+" FROM api.instructor WHERE deleted_at IS NULL ORDER BY name, id", nil, func(rows *sql.Rows) error {
+		var instructor model.Instructor
+		if err := rows.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated, &instructor.Version); err != nil {
+			return err
+		}
+		return fn(&instructor)
+	})
+}
+
 func (ir *InstructorRepository) GetInstructorByID(id uuid.UUID) (*model.Instructor, error) {
-	row := ir.db.QueryRow("SELECT * FROM api.instructor WHERE id = $1", id)
+	row := ir.db.QueryRow("SELECT "+instructorColumns+" FROM api.instructor WHERE id = $1 AND deleted_at IS NULL", id)
 	var instructor model.Instructor
-	err := row.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated)
+	err := row.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated, &instructor.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("instructor not found")
+			return nil, apperr.NotFound("instructor", id)
 		}
 		return nil, err
 	}
 	return &instructor, nil
 }
 
+// GetInstructorsByIDs returns the live instructors among ids, in no
+// particular order.
+func (ir *InstructorRepository) GetInstructorsByIDs(ctx context.Context, ids []uuid.UUID) ([]model.Instructor, error) {
+	rows, err := ir.db.QueryContext(ctx, "SELECT "+instructorColumns+" FROM api.instructor WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL", idArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instructors []model.Instructor
+	for rows.Next() {
+		var instructor model.Instructor
+		if err := rows.Scan(&instructor.ID, &instructor.UserID, &instructor.Name, &instructor.DateCreated, &instructor.Version); err != nil {
+			return nil, err
+		}
+		instructors = append(instructors, instructor)
+	}
+	return instructors, rows.Err()
+}
+
 func (ir *InstructorRepository) CreateInstructor(instructor *model.Instructor) error {
 	if instructor.ID == uuid.Nil {
 		instructor.ID = uuid.New()
 	}
 	_, err := ir.db.Exec("INSERT INTO api.instructor (id, user_id, name) VALUES ($1, $2, $3)",
 		instructor.ID, instructor.UserID, instructor.Name)
-	return err
+	instructor.Version = 1
+	return translateError(err, "instructor")
+}
+
+// UpdateInstructor replaces the writable columns of instructor. A non-empty ifMatch
+// restricts the write to those row versions. On success instructor.Version
+// holds the new version.
+func (ir *InstructorRepository) UpdateInstructor(id uuid.UUID, instructor *model.Instructor, ifMatch []int64) error {
+	err := ir.db.QueryRow("UPDATE api.instructor SET user_id = $1, name = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL AND "+versionMatches(4)+" RETURNING version",
+		instructor.UserID, instructor.Name, id, pq.Array(ifMatch)).Scan(&instructor.Version)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(ir.db, "api.instructor", "instructor", id)
+	}
+	return translateError(err, "instructor")
+}
+
+// PatchInstructor writes the listed fields of instructor, leaving other
+// columns untouched. On success instructor.Version holds the new version.
+func (ir *InstructorRepository) PatchInstructor(id uuid.UUID, instructor *model.Instructor, fields []string, ifMatch []int64) error {
+	version, err := patchByID(ir.db, "api.instructor", "instructor", id, fields, []column{
+		{field: "user_id", name: "user_id", value: instructor.UserID},
+		{field: "name", name: "name", value: instructor.Name},
+	}, "", ifMatch)
+	if err != nil {
+		return translateError(err, "instructor")
+	}
+	instructor.Version = version
+	return nil
 }
 
-func (ir *InstructorRepository) UpdateInstructor(id uuid.UUID, instructor *model.Instructor) error {
-	_, err := ir.db.Exec("UPDATE api.instructor SET user_id = $1, name = $2 WHERE id = $3",
-		instructor.UserID, instructor.Name, id)
-	return err
+// DeleteInstructor soft-deletes the instructor; see RestoreInstructor.
+func (ir *InstructorRepository) DeleteInstructor(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(ir.db, "api.instructor", "instructor", id, ifMatch, instructorReferences)
 }
 
-func (ir *InstructorRepository) DeleteInstructor(id uuid.UUID) error {
-	_, err := ir.db.Exec("DELETE FROM api.instructor WHERE id = $1", id)
-	return err
+// RestoreInstructor undeletes a soft-deleted instructor and returns its new version.
+func (ir *InstructorRepository) RestoreInstructor(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(ir.db, "api.instructor", "instructor", id, ifMatch, []parent{{field: "user_id", column: "user_id", table: "api.user"}})
+	return version, translateError(err, "instructor")
+}
+
+// PurgeDeletedInstructors permanently removes instructors deleted before cutoff and
+// returns how many were removed.
+func (ir *InstructorRepository) PurgeDeletedInstructors(cutoff time.Time) (int64, error) {
+	return purgeDeleted(ir.db, "api.instructor", cutoff, instructorReferences)
+}
+
+// ImportInstructors upserts instructors in one transaction, matching the
+// existing instructor of each user. Users with more than one instructor
+// record are ambiguous and their rows are rejected. It reports whether the
+// transaction was committed; see importRows.
+func (ir *InstructorRepository) ImportInstructors(ctx context.Context, instructors []model.Instructor, opts model.ImportOptions) ([]ImportResult[model.Instructor], bool, error) {
+	return importRows(ctx, ir.db, opts, instructors, func(tx dbtx, instructor *model.Instructor) ImportResult[model.Instructor] {
+		rows, err := tx.QueryContext(ctx, "SELECT "+instructorColumns+" FROM api.instructor WHERE user_id = $1 AND deleted_at IS NULL LIMIT 2 FOR UPDATE", instructor.UserID)
+		if err != nil {
+			return ImportResult[model.Instructor]{Err: err}
+		}
+		var existing []model.Instructor
+		for rows.Next() {
+			var i model.Instructor
+			if err := rows.Scan(&i.ID, &i.UserID, &i.Name, &i.DateCreated, &i.Version); err != nil {
+				rows.Close()
+				return ImportResult[model.Instructor]{Err: err}
+			}
+			existing = append(existing, i)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return ImportResult[model.Instructor]{Err: err}
+		}
+
+		switch len(existing) {
+		case 0:
+			instructor.ID = uuid.New()
+			_, err = tx.ExecContext(ctx, "INSERT INTO api.instructor (id, user_id, name) VALUES ($1, $2, $3)",
+				instructor.ID, instructor.UserID, instructor.Name)
+			instructor.Version = 1
+			return ImportResult[model.Instructor]{ID: instructor.ID, Err: translateError(err, "instructor")}
+		case 1:
+			before := existing[0]
+			instructor.ID = before.ID
+			err = tx.QueryRowContext(ctx, "UPDATE api.instructor SET name = $1, version = version + 1 WHERE id = $2 RETURNING version",
+				instructor.Name, instructor.ID).Scan(&instructor.Version)
+			return ImportResult[model.Instructor]{ID: instructor.ID, Before: &before, Err: translateError(err, "instructor")}
+		}
+		return ImportResult[model.Instructor]{Err: apperr.Conflict("user has more than one instructor; update them individually", nil)}
+	})
 }
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"api-server/internal/model"
+
+	"github.com/lib/pq"
+)
+
+// relayLockKey identifies the PostgreSQL advisory lock that lets one replica
+// at a time publish the outbox, so that events leave in the order they were
+// written.
+const relayLockKey = 0x6f757462 // "outb"
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type OutboxRepository struct {
+	db dbtx
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// WithTx returns a copy of or that runs its statements in tx.
+func (or *OutboxRepository) WithTx(tx *sql.Tx) *OutboxRepository {
+	return &OutboxRepository{db: tx}
+}
+
+// AppendEvents writes events to the outbox.
+func (or *OutboxRepository) AppendEvents(ctx context.Context, events ...*model.CloudEvent) error {
+	return appendEvents(ctx, or.db, events)
+}
+
+// appendEvents writes events to the outbox through db, so that they can be
+// written in the transaction of the change they describe.
+func appendEvents(ctx context.Context, db execer, events []*model.CloudEvent) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = db.ExecContext(ctx, "INSERT INTO api.event_outbox (event_id, event_type, payload) VALUES ($1, $2, $3)", event.ID, event.Type, payload)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishOutbox passes up to limit unpublished events to publish, oldest
+// first, and marks those it accepts as published. It stops at the first
+// event publish rejects, recording the error, so that later events are not
+// published ahead of it. It returns how many events were published; if
+// another replica holds the relay lock it returns 0 without publishing.
+func (or *OutboxRepository) PublishOutbox(ctx context.Context, limit int, publish func(*model.CloudEvent) error) (int, error) {
+	tx, err := begin(ctx, or.db, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil || !locked {
+		return 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, payload FROM api.event_outbox WHERE published_at IS NULL ORDER BY id LIMIT $1", limit)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		id      int64
+		payload []byte
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var (
+		published  []int64
+		publishErr error
+	)
+	for _, p := range batch {
+		var event model.CloudEvent
+		if publishErr = json.Unmarshal(p.payload, &event); publishErr == nil {
+			publishErr = publish(&event)
+		}
+		if publishErr != nil {
+			_, err := tx.ExecContext(ctx, "UPDATE api.event_outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2", publishErr.Error(), p.id)
+			if err != nil {
+				return 0, err
+			}
+			break
+		}
+		published = append(published, p.id)
+	}
+	if len(published) > 0 {
+		_, err := tx.ExecContext(ctx, "UPDATE api.event_outbox SET published_at = CURRENT_TIMESTAMP, last_error = '' WHERE id = ANY($1)", pq.Array(published))
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(published), publishErr
+}
+
+// PurgeOutboxEvents permanently removes events published before cutoff and
+// returns how many were removed. Unpublished events are kept.
+func (or *OutboxRepository) PurgeOutboxEvents(cutoff time.Time) (int64, error) {
+	res, err := or.db.Exec("DELETE FROM api.event_outbox WHERE published_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
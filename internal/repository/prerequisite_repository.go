@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// prerequisiteLockKey identifies the PostgreSQL advisory lock that serializes
+// requisite inserts, so two concurrent inserts cannot close a cycle that
+// neither sees alone.
+const prerequisiteLockKey = 0x70726571 // "preq"
+
+// prerequisiteCycle finds a chain of prerequisites leading from $1 back to
+// $2. Adding "$2 requires $1" would close that chain into a cycle. Paths
+// stop at codes they have already visited, so existing cycles cannot make
+// the query loop.
+const prerequisiteCycle = `
+WITH RECURSIVE chain (code, path) AS (
+	SELECT $1::varchar, ARRAY[$1::varchar]
+	UNION ALL
+	SELECT p.prerequisite_code, c.path || p.prerequisite_code
+	FROM api.course_prerequisite p JOIN chain c ON p.course_code = c.code
+	WHERE p.kind = 'prerequisite' AND NOT p.prerequisite_code = ANY(c.path)
+)
+SELECT path FROM chain WHERE code = $2 LIMIT 1`
+
+// transitivePrerequisites lists every course reachable from $1 through
+// prerequisite links, with the length of the shortest chain to it.
+const transitivePrerequisites = `
+WITH RECURSIVE chain (code, depth, path) AS (
+	SELECT prerequisite_code, 1, ARRAY[course_code, prerequisite_code]
+	FROM api.course_prerequisite WHERE course_code = $1 AND kind = 'prerequisite'
+	UNION ALL
+	SELECT p.prerequisite_code, c.depth + 1, c.path || p.prerequisite_code
+	FROM api.course_prerequisite p JOIN chain c ON p.course_code = c.code
+	WHERE p.kind = 'prerequisite' AND NOT p.prerequisite_code = ANY(c.path)
+)
+SELECT code, min(depth) FROM chain GROUP BY code ORDER BY min(depth), code`
+
+type PrerequisiteRepository struct {
+	db dbtx
+}
+
+func NewPrerequisiteRepository(db *sql.DB) *PrerequisiteRepository {
+	return &PrerequisiteRepository{db: db}
+}
+
+// WithTx returns a copy of pr that runs its statements in tx.
+func (pr *PrerequisiteRepository) WithTx(tx *sql.Tx) *PrerequisiteRepository {
+	return &PrerequisiteRepository{db: tx}
+}
+
+// courseCode returns the code of the live course with id.
+func courseCode(q queryRower, id uuid.UUID) (string, error) {
+	var code string
+	err := q.QueryRow("SELECT code FROM api.course WHERE id = $1 AND deleted_at IS NULL", id).Scan(&code)
+	if err == sql.ErrNoRows {
+		return "", apperr.NotFound("course", id)
+	}
+	return code, err
+}
+
+// GetPrerequisites returns the direct prerequisites and corequisites of a
+// course.
+func (pr *PrerequisiteRepository) GetPrerequisites(courseID uuid.UUID) ([]model.Requisite, error) {
+	code, err := courseCode(pr.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pr.db.Query("SELECT id, prerequisite_code, kind, date_created FROM api.course_prerequisite WHERE course_code = $1 ORDER BY kind DESC, prerequisite_code", code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requisites := []model.Requisite{}
+	for rows.Next() {
+		var req model.Requisite
+		if err := rows.Scan(&req.ID, &req.Code, &req.Kind, &req.DateCreated); err != nil {
+			return nil, err
+		}
+		requisites = append(requisites, req)
+	}
+	return requisites, rows.Err()
+}
+
+// GetTransitivePrerequisites returns every course that must be completed,
+// directly or through a chain of prerequisites, before a course.
+// Corequisites are not followed.
+func (pr *PrerequisiteRepository) GetTransitivePrerequisites(courseID uuid.UUID) ([]model.TransitivePrerequisite, error) {
+	code, err := courseCode(pr.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pr.db.Query(transitivePrerequisites, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prerequisites := []model.TransitivePrerequisite{}
+	for rows.Next() {
+		var p model.TransitivePrerequisite
+		if err := rows.Scan(&p.Code, &p.Depth); err != nil {
+			return nil, err
+		}
+		prerequisites = append(prerequisites, p)
+	}
+	return prerequisites, rows.Err()
+}
+
+// AddPrerequisite records that a course requires req.Code. A prerequisite
+// that would make a course require itself, directly or transitively, is a
+// conflict.
+func (pr *PrerequisiteRepository) AddPrerequisite(courseID uuid.UUID, req *model.Requisite) error {
+	tx, err := begin(context.Background(), pr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", prerequisiteLockKey); err != nil {
+		return err
+	}
+	code, err := courseCode(tx, courseID)
+	if err != nil {
+		return err
+	}
+	if req.Code == code {
+		return apperr.Invalid([]apperr.FieldViolation{{Field: "code", Message: "must not be the course's own code"}})
+	}
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM api.course WHERE code = $1 AND deleted_at IS NULL)", req.Code).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return apperr.Invalid([]apperr.FieldViolation{{Field: "code", Message: "does not exist"}})
+	}
+
+	if req.Kind == model.RequisitePrerequisite {
+		var path []string
+		err := tx.QueryRow(prerequisiteCycle, req.Code, code).Scan(pq.Array(&path))
+		if err == nil {
+			return apperr.Conflict(fmt.Sprintf("adding prerequisite %s would create a cycle: %s -> %s", req.Code, code, strings.Join(path, " -> ")), nil)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	if req.ID == uuid.Nil {
+		req.ID = uuid.New()
+	}
+	err = tx.QueryRow("INSERT INTO api.course_prerequisite (id, course_code, prerequisite_code, kind) VALUES ($1, $2, $3, $4) RETURNING date_created",
+		req.ID, code, req.Code, req.Kind).Scan(&req.DateCreated)
+	if isUniqueViolation(err) {
+		return apperr.Conflict(fmt.Sprintf("%s is already a requisite of %s", req.Code, code), err)
+	}
+	if err != nil {
+		return translateError(err, "course_prerequisite")
+	}
+	return tx.Commit()
+}
+
+// RemovePrerequisite deletes the requisite with prerequisiteCode from a
+// course and returns it.
+func (pr *PrerequisiteRepository) RemovePrerequisite(courseID uuid.UUID, prerequisiteCode string) (*model.Requisite, error) {
+	code, err := courseCode(pr.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	var req model.Requisite
+	err = pr.db.QueryRow("DELETE FROM api.course_prerequisite WHERE course_code = $1 AND prerequisite_code = $2 RETURNING id, prerequisite_code, kind, date_created",
+		code, prerequisiteCode).Scan(&req.ID, &req.Code, &req.Kind, &req.DateCreated)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("requisite", prerequisiteCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// CheckEligibility compares a course's direct requisites with the user's
+// enrollments. Prerequisites must have been completed in an offering of the
+// required course; corequisites may instead be enrolled in. Prerequisites of
+// prerequisites are not checked again, since completing a course implies
+// they were met.
+func (pr *PrerequisiteRepository) CheckEligibility(courseID, userID uuid.UUID) (*model.Eligibility, error) {
+	code, err := courseCode(pr.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLive(pr.db, "api.user", "user", userID); err != nil {
+		return nil, err
+	}
+	rows, err := pr.db.Query(`SELECT p.id, p.prerequisite_code, p.kind, p.date_created
+		FROM api.course_prerequisite p
+		WHERE p.course_code = $1 AND NOT EXISTS (
+			SELECT 1 FROM api.enrollment e JOIN api.course c ON c.id = e.course_id
+			WHERE e.user_id = $2 AND e.deleted_at IS NULL AND c.code = p.prerequisite_code
+			AND (e.status = 'completed' OR (p.kind = 'corequisite' AND e.status = 'enrolled'))
+		)
+		ORDER BY p.kind DESC, p.prerequisite_code`, code, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eligibility := &model.Eligibility{CourseID: courseID, UserID: userID, Missing: []model.Requisite{}}
+	for rows.Next() {
+		var req model.Requisite
+		if err := rows.Scan(&req.ID, &req.Code, &req.Kind, &req.DateCreated); err != nil {
+			return nil, err
+		}
+		eligibility.Missing = append(eligibility.Missing, req)
+	}
+	eligibility.Eligible = len(eligibility.Missing) == 0
+	return eligibility, rows.Err()
+}
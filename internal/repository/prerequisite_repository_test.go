@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+// expectRequisiteCourse expects the requisite lock and the lookup of the
+// course being given a requisite.
+func expectRequisiteCourse(mock sqlmock.Sqlmock, courseID uuid.UUID, code string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(prerequisiteLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT code FROM api.course WHERE id = $1")).
+		WithArgs(courseID).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow(code))
+}
+
+func expectRequisiteExists(mock sqlmock.Sqlmock, code string) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM api.course WHERE code = $1")).
+		WithArgs(code).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+}
+
+func TestAddPrerequisiteRejectsSelf(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	courseID := uuid.New()
+	expectRequisiteCourse(mock, courseID, "CSYE 7125")
+	mock.ExpectRollback()
+
+	err = NewPrerequisiteRepository(db).AddPrerequisite(courseID, &model.Requisite{Code: "CSYE 7125", Kind: model.RequisitePrerequisite})
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) || !errors.Is(err, apperr.ErrValidation) {
+		t.Fatalf("AddPrerequisite = %v, want a validation error", err)
+	}
+	if want := []apperr.FieldViolation{{Field: "code", Message: "must not be the course's own code"}}; !reflect.DeepEqual(appErr.Violations, want) {
+		t.Errorf("violations = %v, want %v", appErr.Violations, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddPrerequisiteRejectsCycle(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"direct", "{CSYE 6225,CSYE 7125}", "adding prerequisite CSYE 6225 would create a cycle: CSYE 7125 -> CSYE 6225 -> CSYE 7125"},
+		{"transitive", "{CSYE 6225,INFO 6150,CSYE 7125}", "adding prerequisite CSYE 6225 would create a cycle: CSYE 7125 -> CSYE 6225 -> INFO 6150 -> CSYE 7125"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			// CSYE 7125 is already a prerequisite of CSYE 6225, directly or
+			// through INFO 6150, so the chain from CSYE 6225 leads back to it.
+			courseID := uuid.New()
+			expectRequisiteCourse(mock, courseID, "CSYE 7125")
+			expectRequisiteExists(mock, "CSYE 6225")
+			mock.ExpectQuery("WITH RECURSIVE chain").
+				WithArgs("CSYE 6225", "CSYE 7125").
+				WillReturnRows(sqlmock.NewRows([]string{"path"}).AddRow(tt.path))
+			mock.ExpectRollback()
+
+			err = NewPrerequisiteRepository(db).AddPrerequisite(courseID, &model.Requisite{Code: "CSYE 6225", Kind: model.RequisitePrerequisite})
+			if !errors.Is(err, apperr.ErrConflict) {
+				t.Fatalf("AddPrerequisite = %v, want a conflict", err)
+			}
+			if got := apperr.Message(err); got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAddCorequisiteSkipsCycleCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	courseID := uuid.New()
+	created := "2025-09-01T12:00:00Z"
+	expectRequisiteCourse(mock, courseID, "CSYE 7125")
+	expectRequisiteExists(mock, "CSYE 6225")
+	mock.ExpectQuery("INSERT INTO api.course_prerequisite").
+		WithArgs(sqlmock.AnyArg(), "CSYE 7125", "CSYE 6225", model.RequisiteCorequisite).
+		WillReturnRows(sqlmock.NewRows([]string{"date_created"}).AddRow(created))
+	mock.ExpectCommit()
+
+	req := &model.Requisite{Code: "CSYE 6225", Kind: model.RequisiteCorequisite}
+	if err := NewPrerequisiteRepository(db).AddPrerequisite(courseID, req); err != nil {
+		t.Fatal(err)
+	}
+	if req.ID == uuid.Nil || req.DateCreated != created {
+		t.Errorf("requisite has id %v, date_created %q; want a new id and %q", req.ID, req.DateCreated, created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddPrerequisiteWithoutCycle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	courseID := uuid.New()
+	expectRequisiteCourse(mock, courseID, "CSYE 7125")
+	expectRequisiteExists(mock, "CSYE 6225")
+	mock.ExpectQuery("WITH RECURSIVE chain").
+		WithArgs("CSYE 6225", "CSYE 7125").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery("INSERT INTO api.course_prerequisite").
+		WithArgs(sqlmock.AnyArg(), "CSYE 7125", "CSYE 6225", model.RequisitePrerequisite).
+		WillReturnRows(sqlmock.NewRows([]string{"date_created"}).AddRow("2025-09-01T12:00:00Z"))
+	mock.ExpectCommit()
+
+	err = NewPrerequisiteRepository(db).AddPrerequisite(courseID, &model.Requisite{Code: "CSYE 6225", Kind: model.RequisitePrerequisite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
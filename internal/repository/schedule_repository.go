@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// scheduleLockKey identifies the PostgreSQL advisory lock that serializes
+// schedule changes, so two concurrent writes cannot book the same slot.
+const scheduleLockKey = 0x73636864 // "schd"
+
+// meetingReferences lists the columns that point at api.course_meeting.
+var meetingReferences []reference
+
+// meetingColumns lists the columns of api.course_meeting, aliased m, in the
+// order rows are scanned.
+const meetingColumns = "m.id, m.course_id, m.days, to_char(m.start_time, 'HH24:MI'), to_char(m.end_time, 'HH24:MI'), m.room, m.modality, m.date_created, m.date_last_updated, m.version"
+
+// scheduledMeetingRows selects live meetings of live courses with their term
+// dates, for calendars.
+const scheduledMeetingRows = "SELECT " + meetingColumns + ", c.code, c.name, to_char(t.start_date, 'YYYY-MM-DD'), to_char(t.end_date, 'YYYY-MM-DD')" +
+	" FROM api.course_meeting m JOIN api.course c ON c.id = m.course_id JOIN api.term t ON t.id = c.term_id" +
+	" WHERE m.deleted_at IS NULL AND c.deleted_at IS NULL"
+
+// overlappingMeetings selects live meetings, aliased om with course oc,
+// that share a day and time with a slot of course $1 in an overlapping term.
+// Callers supply the slot as $2 days, $3 start and $4 end time.
+const overlappingMeetings = `
+	FROM api.course_meeting om
+	JOIN api.course oc ON oc.id = om.course_id AND oc.deleted_at IS NULL
+	JOIN api.term ot ON ot.id = oc.term_id
+	JOIN api.term pt ON pt.id = (SELECT term_id FROM api.course WHERE id = $1)
+	WHERE om.deleted_at IS NULL
+	AND om.days && $2::varchar[] AND om.start_time < $4::time AND $3::time < om.end_time
+	AND ot.start_date <= pt.end_date AND pt.start_date <= ot.end_date`
+
+// sharedInstructor matches meetings of other courses that have an
+// instructor in common with course $1.
+const sharedInstructor = `om.course_id <> $1 AND EXISTS (
+	SELECT 1 FROM api.course_instructor a JOIN api.course_instructor b ON b.instructor_id = a.instructor_id
+	WHERE a.course_id = $1 AND b.course_id = om.course_id AND a.deleted_at IS NULL AND b.deleted_at IS NULL)`
+
+type ScheduleRepository struct {
+	db dbtx
+}
+
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// WithTx returns a copy of sr that runs its statements in tx.
+func (sr *ScheduleRepository) WithTx(tx *sql.Tx) *ScheduleRepository {
+	return &ScheduleRepository{db: tx}
+}
+
+func scanMeeting(row interface{ Scan(...any) error }, m *model.Meeting, extra ...any) error {
+	return row.Scan(append([]any{&m.ID, &m.CourseID, pq.Array(&m.Days), &m.StartTime, &m.EndTime, &m.Room, &m.Modality, &m.DateCreated, &m.DateLastUpdated, &m.Version}, extra...)...)
+}
+
+// GetCourseMeetings returns the weekly meetings of a course.
+func (sr *ScheduleRepository) GetCourseMeetings(courseID uuid.UUID) ([]model.Meeting, error) {
+	if err := checkLive(sr.db, "api.course", "course", courseID); err != nil {
+		return nil, err
+	}
+	rows, err := sr.db.Query("SELECT "+meetingColumns+" FROM api.course_meeting m WHERE m.course_id = $1 AND m.deleted_at IS NULL ORDER BY m.start_time, m.id", courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	meetings := []model.Meeting{}
+	for rows.Next() {
+		var m model.Meeting
+		if err := scanMeeting(rows, &m); err != nil {
+			return nil, err
+		}
+		meetings = append(meetings, m)
+	}
+	return meetings, rows.Err()
+}
+
+func (sr *ScheduleRepository) GetMeetingByID(courseID, id uuid.UUID) (*model.Meeting, error) {
+	row := sr.db.QueryRow("SELECT "+meetingColumns+" FROM api.course_meeting m WHERE m.id = $1 AND m.course_id = $2 AND m.deleted_at IS NULL", id, courseID)
+	var m model.Meeting
+	if err := scanMeeting(row, &m); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("meeting", id)
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateMeeting adds a meeting to the course m.CourseID. A meeting that
+// double-books its room or one of the course's instructors is a conflict.
+func (sr *ScheduleRepository) CreateMeeting(m *model.Meeting) error {
+	tx, err := begin(context.Background(), sr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", scheduleLockKey); err != nil {
+		return err
+	}
+	if err := checkLive(tx, "api.course", "course", m.CourseID); err != nil {
+		return err
+	}
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if err := meetingConflicts(tx, m); err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO api.course_meeting (id, course_id, days, start_time, end_time, room, modality) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		m.ID, m.CourseID, pq.Array(m.Days), m.StartTime, m.EndTime, m.Room, m.Modality)
+	if err != nil {
+		return translateError(err, "meeting")
+	}
+	m.Version = 1
+	return tx.Commit()
+}
+
+// PatchMeeting writes the listed fields of m, leaving other columns
+// untouched, after checking the resulting meeting for conflicts. On success
+// m.Version holds the new version.
+func (sr *ScheduleRepository) PatchMeeting(id uuid.UUID, m *model.Meeting, fields []string, ifMatch []int64) error {
+	tx, err := begin(context.Background(), sr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", scheduleLockKey); err != nil {
+		return err
+	}
+	if err := meetingConflicts(tx, m); err != nil {
+		return err
+	}
+	version, err := patchByID(tx, "api.course_meeting", "meeting", id, fields, []column{
+		{field: "days", name: "days", value: pq.Array(m.Days)},
+		{field: "start_time", name: "start_time", value: m.StartTime},
+		{field: "end_time", name: "end_time", value: m.EndTime},
+		{field: "room", name: "room", value: m.Room},
+		{field: "modality", name: "modality", value: m.Modality},
+	}, "date_last_updated", ifMatch)
+	if err != nil {
+		return translateError(err, "meeting")
+	}
+	m.Version = version
+	return tx.Commit()
+}
+
+// DeleteMeeting soft-deletes a meeting.
+func (sr *ScheduleRepository) DeleteMeeting(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(sr.db, "api.course_meeting", "meeting", id, ifMatch, meetingReferences)
+}
+
+// PurgeDeletedMeetings permanently removes meetings deleted before cutoff
+// and returns how many were removed.
+func (sr *ScheduleRepository) PurgeDeletedMeetings(cutoff time.Time) (int64, error) {
+	return purgeDeleted(sr.db, "api.course_meeting", cutoff, meetingReferences)
+}
+
+// GetCourseSchedule returns the meetings of a course for its calendar.
+func (sr *ScheduleRepository) GetCourseSchedule(courseID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	if err := checkLive(sr.db, "api.course", "course", courseID); err != nil {
+		return nil, err
+	}
+	return sr.scheduledMeetings(" AND c.id = $1", courseID)
+}
+
+// GetInstructorSchedule returns the meetings of every course an instructor
+// is assigned to.
+func (sr *ScheduleRepository) GetInstructorSchedule(instructorID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	if err := checkLive(sr.db, "api.instructor", "instructor", instructorID); err != nil {
+		return nil, err
+	}
+	return sr.scheduledMeetings(" AND c.id IN (SELECT course_id FROM api.course_instructor WHERE instructor_id = $1 AND deleted_at IS NULL)", instructorID)
+}
+
+// GetStudentSchedule returns the meetings of every course a user is
+// enrolled in. Waitlisted, dropped and completed courses are left out.
+func (sr *ScheduleRepository) GetStudentSchedule(userID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	if err := checkLive(sr.db, "api.user", "user", userID); err != nil {
+		return nil, err
+	}
+	return sr.scheduledMeetings(" AND c.id IN (SELECT course_id FROM api.enrollment WHERE user_id = $1 AND status = 'enrolled' AND deleted_at IS NULL)", userID)
+}
+
+func (sr *ScheduleRepository) scheduledMeetings(where string, args ...any) ([]model.ScheduledMeeting, error) {
+	rows, err := sr.db.Query(scheduledMeetingRows+where+" ORDER BY t.start_date, c.code, m.start_time", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var meetings []model.ScheduledMeeting
+	for rows.Next() {
+		var sm model.ScheduledMeeting
+		if err := scanMeeting(rows, &sm.Meeting, &sm.CourseCode, &sm.CourseName, &sm.TermStart, &sm.TermEnd); err != nil {
+			return nil, err
+		}
+		meetings = append(meetings, sm)
+	}
+	return meetings, rows.Err()
+}
+
+// meetingConflicts reports, as a single conflict error, every live meeting
+// that overlaps m and either uses the same room or is taught by one of the
+// same instructors. Online meetings never conflict over rooms.
+func meetingConflicts(tx dbtx, m *model.Meeting) error {
+	var room sql.NullString
+	if m.Room != nil && m.Modality != model.ModalityOnline {
+		room = sql.NullString{String: *m.Room, Valid: true}
+	}
+	rows, err := tx.Query(`SELECT CASE WHEN $6::varchar IS NOT NULL AND om.modality <> 'online' AND lower(om.room) = lower($6) THEN 'room ' || om.room ELSE 'instructor' END,
+		oc.code, array_to_string(om.days, ','), to_char(om.start_time, 'HH24:MI'), to_char(om.end_time, 'HH24:MI')`+
+		overlappingMeetings+` AND om.id <> $5
+		AND (($6::varchar IS NOT NULL AND om.modality <> 'online' AND lower(om.room) = lower($6)) OR `+sharedInstructor+`)
+		ORDER BY oc.code, om.start_time`,
+		m.CourseID, pq.Array(m.Days), m.StartTime, m.EndTime, m.ID, room)
+	if err != nil {
+		return err
+	}
+	return conflictError(rows)
+}
+
+// instructorConflicts reports meetings of courses already taught by
+// instructorID that overlap meetings of courseID.
+func instructorConflicts(tx dbtx, courseID, instructorID uuid.UUID) error {
+	rows, err := tx.Query(`SELECT 'instructor', oc.code, array_to_string(om.days, ','), to_char(om.start_time, 'HH24:MI'), to_char(om.end_time, 'HH24:MI')
+		FROM api.course_meeting m
+		JOIN api.course_meeting om ON om.deleted_at IS NULL AND om.course_id <> m.course_id
+			AND om.days && m.days AND om.start_time < m.end_time AND m.start_time < om.end_time
+		JOIN api.course oc ON oc.id = om.course_id AND oc.deleted_at IS NULL
+		JOIN api.term ot ON ot.id = oc.term_id
+		JOIN api.term pt ON pt.id = (SELECT term_id FROM api.course WHERE id = $1)
+		WHERE m.course_id = $1 AND m.deleted_at IS NULL
+		AND ot.start_date <= pt.end_date AND pt.start_date <= ot.end_date
+		AND om.course_id IN (SELECT course_id FROM api.course_instructor WHERE instructor_id = $2 AND deleted_at IS NULL)
+		ORDER BY oc.code, om.start_time`, courseID, instructorID)
+	if err != nil {
+		return err
+	}
+	return conflictError(rows)
+}
+
+// conflictError turns rows of (what, course code, days, start, end) into a
+// conflict error describing each clash, or nil if there are none.
+func conflictError(rows *sql.Rows) error {
+	defer rows.Close()
+	var clashes []string
+	for rows.Next() {
+		var what, code, days, start, end string
+		if err := rows.Scan(&what, &code, &days, &start, &end); err != nil {
+			return err
+		}
+		clashes = append(clashes, fmt.Sprintf("%s is booked for %s on %s %s-%s", what, code, days, start, end))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(clashes) == 0 {
+		return nil
+	}
+	return apperr.Conflict("schedule conflict: "+strings.Join(clashes, "; "), nil)
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var conflictColumns = []string{"what", "code", "days", "start", "end"}
+
+// expectMeetingChecks expects the schedule lock, the course lookup and the
+// conflict query of CreateMeeting for m, which returns rows.
+func expectMeetingChecks(mock sqlmock.Sqlmock, m *model.Meeting, room any, rows *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(scheduleLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM api.course")).
+		WithArgs(m.CourseID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	// Meetings clash when they share a day, their times overlap (meetings
+	// that end as the other starts do not) and their terms overlap.
+	mock.ExpectQuery(regexp.QuoteMeta("om.days && $2::varchar[] AND om.start_time < $4::time AND $3::time < om.end_time")+
+		".*"+regexp.QuoteMeta("ot.start_date <= pt.end_date AND pt.start_date <= ot.end_date")).
+		WithArgs(m.CourseID, pq.Array(m.Days), m.StartTime, m.EndTime, sqlmock.AnyArg(), room).
+		WillReturnRows(rows)
+}
+
+func TestCreateMeetingReportsRoomConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	room := "Snell 108"
+	m := &model.Meeting{CourseID: uuid.New(), Days: []string{"MO", "WE"}, StartTime: "10:00", EndTime: "11:30", Room: &room, Modality: model.ModalityInPerson}
+	// INFO 6150 meets in the room on Wednesdays in a term that overlaps the
+	// course's.
+	expectMeetingChecks(mock, m, room, sqlmock.NewRows(conflictColumns).
+		AddRow("room Snell 108", "INFO 6150", "WE,FR", "11:00", "12:30"))
+	mock.ExpectRollback()
+
+	err = NewScheduleRepository(db).CreateMeeting(m)
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Fatalf("CreateMeeting = %v, want a conflict", err)
+	}
+	if got, want := apperr.Message(err), "schedule conflict: room Snell 108 is booked for INFO 6150 on WE,FR 11:00-12:30"; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateMeetingBackToBack(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The room is booked until 10:00, when this meeting starts.
+	room := "Snell 108"
+	m := &model.Meeting{CourseID: uuid.New(), Days: []string{"MO"}, StartTime: "10:00", EndTime: "11:30", Room: &room, Modality: model.ModalityInPerson}
+	expectMeetingChecks(mock, m, room, sqlmock.NewRows(conflictColumns))
+	mock.ExpectExec("INSERT INTO api.course_meeting").
+		WithArgs(sqlmock.AnyArg(), m.CourseID, pq.Array(m.Days), "10:00", "11:30", room, model.ModalityInPerson).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewScheduleRepository(db).CreateMeeting(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOnlineMeetingIgnoresRoom(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	room := "Zoom"
+	m := &model.Meeting{CourseID: uuid.New(), Days: []string{"TU"}, StartTime: "18:00", EndTime: "20:40", Room: &room, Modality: model.ModalityOnline}
+	// Only instructors are checked, so the room is passed as NULL.
+	expectMeetingChecks(mock, m, nil, sqlmock.NewRows(conflictColumns).
+		AddRow("instructor", "CSYE 6225", "TU", "19:00", "21:00"))
+	mock.ExpectRollback()
+
+	err = NewScheduleRepository(db).CreateMeeting(m)
+	if got, want := apperr.Message(err), "schedule conflict: instructor is booked for CSYE 6225 on TU 19:00-21:00"; got != want {
+		t.Errorf("CreateMeeting = %q, want %q", got, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"api-server/internal/model"
+
+	"github.com/lib/pq"
+)
+
+// searchQuery ranks live courses, instructors and traces of the types in $2
+// against the web search query $1 and keeps the best $3. Headlines are only
+// computed for the rows kept, since ts_headline re-parses the whole text.
+// Ranks are normalized by document length so that long trace texts do not
+// outrank short course descriptions simply by repeating a word.
+const searchQuery = `
+WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query),
+hits AS (
+	SELECT 'course' AS type, c.id, c.code || ' ' || c.name AS title, coalesce(c.description, '') AS body, ts_rank(c.search, q.query, 1) AS rank
+	FROM api.course c, q
+	WHERE 'course' = ANY($2) AND c.deleted_at IS NULL AND c.search @@ q.query
+	UNION ALL
+	SELECT 'instructor', i.id, i.name, '', ts_rank(i.search, q.query, 1)
+	FROM api.instructor i, q
+	WHERE 'instructor' = ANY($2) AND i.deleted_at IS NULL AND i.search @@ q.query
+	UNION ALL
+	SELECT 'trace', tr.id, tr.file_name, tr.content_text, ts_rank(tr.search, q.query, 1)
+	FROM api.trace tr, q
+	WHERE 'trace' = ANY($2) AND tr.deleted_at IS NULL AND tr.search @@ q.query
+	ORDER BY rank DESC, title
+	LIMIT $3
+)
+SELECT h.type, h.id,
+	ts_headline('english', h.title, q.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+	CASE WHEN h.body = '' THEN '' ELSE ts_headline('english', h.body, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=8') END,
+	h.rank
+FROM hits h, q
+ORDER BY h.rank DESC, h.title`
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// Search returns the resources matching query, best match first. A query
+// made only of stop words matches nothing.
+func (sr *SearchRepository) Search(ctx context.Context, query model.SearchQuery) ([]model.SearchResult, error) {
+	rows, err := sr.db.QueryContext(ctx, searchQuery, query.Query, pq.Array(query.Types), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var res model.SearchResult
+		if err := rows.Scan(&res.Type, &res.ID, &res.Title, &res.Snippet, &res.Rank); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"api-server/internal/apperr"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Rows are soft-deleted by setting deleted_at. Every query filters on
+// deleted_at IS NULL so deleted rows behave as missing until they are either
+// restored or purged once the retention window has passed.
+
+// deleteByID soft-deletes the row with id from table if its version is one of
+// ifMatch, or unconditionally if ifMatch is empty. A row that live records
+// still reference is reported as a conflict listing those references.
+//
+// The row is locked before its references are counted. Inserting a row that
+// references it takes a key share lock on it for the foreign key check, so
+// the count waits for such inserts to commit and cannot miss them.
+func deleteByID(db dbtx, table, resource string, id uuid.UUID, ifMatch []int64, refs []reference) error {
+	tx, err := begin(context.Background(), db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int64
+	err = tx.QueryRow("SELECT version FROM "+table+" WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return apperr.NotFound(resource, id)
+	}
+	if err != nil {
+		return err
+	}
+	if len(ifMatch) > 0 && !slices.Contains(ifMatch, version) {
+		return apperr.Stale(resource, id)
+	}
+
+	deps, err := countDependents(tx, id, refs)
+	if err != nil {
+		return err
+	}
+	if len(deps) > 0 {
+		return apperr.InUse(resource, id, deps, nil)
+	}
+
+	if _, err := tx.Exec("UPDATE "+table+" SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1", id); err != nil {
+		return translateError(err, resource)
+	}
+	return tx.Commit()
+}
+
+// countDependents counts the live rows that reference id.
+func countDependents(db queryRower, id uuid.UUID, refs []reference) ([]apperr.Dependent, error) {
+	var deps []apperr.Dependent
+	for _, ref := range refs {
+		var n int
+		err := db.QueryRow("SELECT count(*) FROM "+ref.table+" WHERE "+ref.column+" = $1 AND deleted_at IS NULL", id).Scan(&n)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			deps = append(deps, apperr.Dependent{Resource: ref.resource, Count: n})
+		}
+	}
+	return deps, nil
+}
+
+// parent is a column of table that points at a row in another table. A row
+// can only be restored while its parents are live.
+type parent struct {
+	field  string // JSON field name reported to clients
+	column string
+	table  string
+}
+
+// restoreByID clears deleted_at on the row with id if its version is one of
+// ifMatch, and returns the new version. Restoring a live row is a conflict,
+// as is restoring a row whose parents are deleted.
+func restoreByID(db dbtx, table, resource string, id uuid.UUID, ifMatch []int64, parents []parent) (int64, error) {
+	var deleted bool
+	err := db.QueryRow("SELECT deleted_at IS NOT NULL FROM "+table+" WHERE id = $1", id).Scan(&deleted)
+	if err == sql.ErrNoRows {
+		return 0, apperr.NotFound(resource, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !deleted {
+		return 0, apperr.Conflict(fmt.Sprintf("%s %v is not deleted", resource, id), nil)
+	}
+
+	var missing []string
+	for _, p := range parents {
+		var live bool
+		err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+p.table+" p JOIN "+table+" c ON p.id = c."+p.column+" WHERE c.id = $1 AND p.deleted_at IS NULL)", id).Scan(&live)
+		if err != nil {
+			return 0, err
+		}
+		if !live {
+			missing = append(missing, p.field)
+		}
+	}
+	if len(missing) > 0 {
+		return 0, apperr.Conflict(fmt.Sprintf("%s %v references deleted records (%s); restore them first", resource, id, strings.Join(missing, ", ")), nil)
+	}
+
+	var version int64
+	err = db.QueryRow("UPDATE "+table+" SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL AND "+versionMatches(2)+" RETURNING version",
+		id, pq.Array(ifMatch)).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, apperr.Stale(resource, id)
+	}
+	return version, err
+}
+
+// purgeDeleted permanently removes rows of table that were deleted before
+// cutoff. Rows that any other row still references, live or deleted, are
+// kept until those references are purged first.
+func purgeDeleted(db dbtx, table string, cutoff time.Time, refs []reference) (int64, error) {
+	query := "DELETE FROM " + table + " t WHERE t.deleted_at < $1"
+	for _, ref := range refs {
+		query += " AND NOT EXISTS (SELECT 1 FROM " + ref.table + " r WHERE r." + ref.column + " = t.id)"
+	}
+	res, err := db.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// termReferences lists the columns that point at api.term.
+var termReferences = []reference{
+	{resource: "course", table: "api.course", column: "term_id"},
+	{resource: "enrollment", table: "api.enrollment", column: "term_id"},
+}
+
+// termStatus computes the status of the term aliased t from today's date.
+const termStatus = "(CASE WHEN CURRENT_DATE < t.start_date THEN 'upcoming' WHEN CURRENT_DATE <= t.end_date THEN 'current' ELSE 'archived' END)"
+
+// termColumns lists the columns of api.term in the order rows are scanned.
+const termColumns = "t.id, t.name, t.semester_term, t.semester_year, to_char(t.start_date, 'YYYY-MM-DD'), to_char(t.end_date, 'YYYY-MM-DD'), to_char(t.registration_opens, 'YYYY-MM-DD'), to_char(t.registration_closes, 'YYYY-MM-DD'), " + termStatus + ", t.date_created, t.date_last_updated, t.version"
+
+type TermRepository struct {
+	db dbtx
+}
+
+func NewTermRepository(db *sql.DB) *TermRepository {
+	return &TermRepository{db: db}
+}
+
+// WithTx returns a copy of tr that runs its statements in tx.
+func (tr *TermRepository) WithTx(tx *sql.Tx) *TermRepository {
+	return &TermRepository{db: tx}
+}
+
+func scanTerm(row interface{ Scan(...any) error }, t *model.Term) error {
+	return row.Scan(&t.ID, &t.Name, &t.SemesterTerm, &t.SemesterYear, &t.StartDate, &t.EndDate, &t.RegistrationOpens, &t.RegistrationCloses, &t.Status, &t.DateCreated, &t.DateLastUpdated, &t.Version)
+}
+
+// GetAllTerms returns the live terms, optionally limited to one status, in
+// chronological order.
+func (tr *TermRepository) GetAllTerms(status string) ([]model.Term, error) {
+	rows, err := tr.db.Query("SELECT "+termColumns+" FROM api.term t WHERE t.deleted_at IS NULL AND ($1 = '' OR "+termStatus+" = $1) ORDER BY t.start_date", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []model.Term
+	for rows.Next() {
+		var term model.Term
+		if err := scanTerm(rows, &term); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+func (tr *TermRepository) GetTermByID(id uuid.UUID) (*model.Term, error) {
+	row := tr.db.QueryRow("SELECT "+termColumns+" FROM api.term t WHERE t.id = $1 AND t.deleted_at IS NULL", id)
+	var term model.Term
+	if err := scanTerm(row, &term); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("term", id)
+		}
+		return nil, err
+	}
+	return &term, nil
+}
+
+func (tr *TermRepository) CreateTerm(term *model.Term) error {
+	if term.ID == uuid.Nil {
+		term.ID = uuid.New()
+	}
+	_, err := tr.db.Exec("INSERT INTO api.term (id, name, semester_term, semester_year, start_date, end_date, registration_opens, registration_closes) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		term.ID, term.Name, term.SemesterTerm, term.SemesterYear, term.StartDate, term.EndDate, term.RegistrationOpens, term.RegistrationCloses)
+	term.Version = 1
+	return translateError(err, "term")
+}
+
+// UpdateTerm replaces the writable columns of term. A non-empty ifMatch
+// restricts the write to those row versions. On success term.Version holds
+// the new version.
+func (tr *TermRepository) UpdateTerm(id uuid.UUID, term *model.Term, ifMatch []int64) error {
+	err := tr.withCourses(id, func(tx dbtx) error {
+		err := tx.QueryRow("UPDATE api.term SET name = $1, semester_term = $2, semester_year = $3, start_date = $4, end_date = $5, registration_opens = $6, registration_closes = $7, date_last_updated = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $8 AND deleted_at IS NULL AND "+versionMatches(9)+" RETURNING version",
+			term.Name, term.SemesterTerm, term.SemesterYear, term.StartDate, term.EndDate, term.RegistrationOpens, term.RegistrationCloses, id, pq.Array(ifMatch)).Scan(&term.Version)
+		if err == sql.ErrNoRows {
+			return staleOrMissing(tx, "api.term", "term", id)
+		}
+		return err
+	})
+	return translateError(err, "term")
+}
+
+// PatchTerm writes the listed fields of term, leaving other columns
+// untouched. On success term.Version holds the new version.
+func (tr *TermRepository) PatchTerm(id uuid.UUID, term *model.Term, fields []string, ifMatch []int64) error {
+	err := tr.withCourses(id, func(tx dbtx) error {
+		version, err := patchByID(tx, "api.term", "term", id, fields, []column{
+			{field: "name", name: "name", value: term.Name},
+			{field: "semester_term", name: "semester_term", value: term.SemesterTerm},
+			{field: "semester_year", name: "semester_year", value: term.SemesterYear},
+			{field: "start_date", name: "start_date", value: term.StartDate},
+			{field: "end_date", name: "end_date", value: term.EndDate},
+			{field: "registration_opens", name: "registration_opens", value: term.RegistrationOpens},
+			{field: "registration_closes", name: "registration_closes", value: term.RegistrationCloses},
+		}, "date_last_updated", ifMatch)
+		term.Version = version
+		return err
+	})
+	return translateError(err, "term")
+}
+
+// withCourses runs write on the term with id in a transaction. Courses show
+// the semester of their term, so if write changes it their versions are
+// bumped too, changing their ETags.
+func (tr *TermRepository) withCourses(id uuid.UUID, write func(dbtx) error) error {
+	tx, err := begin(context.Background(), tr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		semesterTerm string
+		semesterYear int
+	)
+	err = tx.QueryRow("SELECT semester_term, semester_year FROM api.term WHERE id = $1 FOR UPDATE", id).Scan(&semesterTerm, &semesterYear)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE api.course SET version = version + 1
+		WHERE term_id = $1 AND deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM api.term t
+			WHERE t.id = $1 AND (t.semester_term, t.semester_year) IS DISTINCT FROM ($2::text, $3::int)
+		)`, id, semesterTerm, semesterYear)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteTerm soft-deletes the term; see RestoreTerm. Terms with live
+// courses or enrollments cannot be deleted.
+func (tr *TermRepository) DeleteTerm(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(tr.db, "api.term", "term", id, ifMatch, termReferences)
+}
+
+// RestoreTerm undeletes a soft-deleted term and returns its new version.
+func (tr *TermRepository) RestoreTerm(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(tr.db, "api.term", "term", id, ifMatch, nil)
+	return version, translateError(err, "term")
+}
+
+// PurgeDeletedTerms permanently removes terms deleted before cutoff and
+// returns how many were removed.
+func (tr *TermRepository) PurgeDeletedTerms(cutoff time.Time) (int64, error) {
+	return purgeDeleted(tr.db, "api.term", cutoff, termReferences)
+}
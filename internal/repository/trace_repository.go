@@ -1,27 +1,55 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
-	"fmt"
+	"encoding/json"
+	"strings"
+	"time"
 
+	"api-server/internal/apperr"
 	"api-server/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// TraceStatusChannel is the PostgreSQL notification channel on which every
+// change of a trace's status is announced as a JSON model.TraceStatusEvent.
+const TraceStatusChannel = "trace_status"
+
+// maxStatusError caps status_error so that announcements stay well under
+// PostgreSQL's 8000-byte notification limit.
+const maxStatusError = 1000
+
+// traceReferences lists the columns that point at api.trace.
+var traceReferences []reference
+
+// traceColumns lists the columns of api.trace in the order rows are scanned.
+const traceColumns = "id, user_id, file_name, date_created, bucket_path, status, status_error, version"
+
 type TraceRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewTraceRepository(db *sql.DB) *TraceRepository {
 	return &TraceRepository{db: db}
 }
 
+// WithTx returns a copy of tr that runs its statements in tx.
+func (tr *TraceRepository) WithTx(tx *sql.Tx) *TraceRepository {
+	return &TraceRepository{db: tx}
+}
+
+// CheckReferences reports the user ID of trace if it does not match an
+// existing row.
+func (tr *TraceRepository) CheckReferences(trace *model.Trace) ([]apperr.FieldViolation, error) {
+	return checkForeignKeys(tr.db, foreignKey{field: "user_id", table: "api.user", id: trace.UserID})
+}
+
 func (tr *TraceRepository) GetAllTraces() ([]model.Trace, error) {
-	rows, err := tr.db.Query(`SELECT * FROM "api"."trace"`)
+	rows, err := tr.db.Query("SELECT " + traceColumns + " FROM api.trace WHERE deleted_at IS NULL")
 	if err != nil {
-		fmt.Println("ERROR", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -29,7 +57,7 @@ func (tr *TraceRepository) GetAllTraces() ([]model.Trace, error) {
 	var traces []model.Trace
 	for rows.Next() {
 		var trace model.Trace
-		err = rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath)
+		err = rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -38,35 +66,277 @@ func (tr *TraceRepository) GetAllTraces() ([]model.Trace, error) {
 	return traces, nil
 }
 
+// StreamTraces calls fn for every live trace, oldest first, reading them
+// through a cursor; see streamRows.
+func (tr *TraceRepository) StreamTraces(ctx context.Context, fn func(*model.Trace) error) error {
+	return streamRows(ctx, tr.db, "SELECT "+traceColumns+" FROM api.trace WHERE deleted_at IS NULL ORDER BY date_created, id", nil, func(rows *sql.Rows) error {
+		var trace model.Trace
+		if err := rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version); err != nil {
+			return err
+		}
+		return fn(&trace)
+	})
+}
+
 func (tr *TraceRepository) GetTraceByID(id uuid.UUID) (*model.Trace, error) {
-	row := tr.db.QueryRow("SELECT * FROM api.trace WHERE id = $1", id)
+	row := tr.db.QueryRow("SELECT "+traceColumns+" FROM api.trace WHERE id = $1 AND deleted_at IS NULL", id)
 	var trace model.Trace
-	err := row.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath)
+	err := row.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("trace not found")
+			return nil, apperr.NotFound("trace", id)
 		}
 		return nil, err
 	}
 	return &trace, nil
 }
 
-func (tr *TraceRepository) CreateTrace(trace *model.Trace) error {
+// GetTracesByUserIDs returns the live traces of the users with userIDs,
+// each user's newest first.
+func (tr *TraceRepository) GetTracesByUserIDs(ctx context.Context, userIDs []uuid.UUID) ([]model.Trace, error) {
+	rows, err := tr.db.QueryContext(ctx, "SELECT "+traceColumns+" FROM api.trace WHERE user_id = ANY($1::uuid[]) AND deleted_at IS NULL ORDER BY user_id, date_created DESC, id", idArray(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traces []model.Trace
+	for rows.Next() {
+		var trace model.Trace
+		if err := rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version); err != nil {
+			return nil, err
+		}
+		traces = append(traces, trace)
+	}
+	return traces, rows.Err()
+}
+
+// CreateTrace inserts trace as uploaded, announces its status and writes
+// events about it to the outbox in the same transaction.
+func (tr *TraceRepository) CreateTrace(trace *model.Trace, events ...*model.CloudEvent) error {
 	if trace.ID == uuid.Nil {
 		trace.ID = uuid.New()
 	}
-	_, err := tr.db.Exec("INSERT INTO api.trace (id, user_id, file_name, date_created, bucket_path) VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4)",
-		trace.ID, trace.UserID, trace.FileName, trace.BucketPath)
-	return err
+	ctx := context.Background()
+	tx, err := begin(ctx, tr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status := model.TraceStatusEvent{TraceID: trace.ID, UserID: trace.UserID, Status: model.TraceUploaded}
+	err = tx.QueryRowContext(ctx, "INSERT INTO api.trace (id, user_id, file_name, date_created, bucket_path, status) VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4, $5) RETURNING status_updated_at",
+		trace.ID, trace.UserID, trace.FileName, trace.BucketPath, status.Status).Scan(&status.At)
+	if err != nil {
+		return translateError(err, "trace")
+	}
+	if err := notifyTraceStatus(ctx, tx, &status); err != nil {
+		return err
+	}
+	if err := appendEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	trace.Status = status.Status
+	trace.Version = 1
+	return tx.Commit()
+}
+
+// UpdateTrace replaces the writable columns of trace. A non-empty ifMatch
+// restricts the write to those row versions. On success trace.Version
+// holds the new version.
+func (tr *TraceRepository) UpdateTrace(id uuid.UUID, trace *model.Trace, ifMatch []int64) error {
+	err := tr.db.QueryRow("UPDATE api.trace SET user_id = $1, file_name = $2, bucket_path = $3, version = version + 1 WHERE id = $4 AND deleted_at IS NULL AND "+versionMatches(5)+" RETURNING version",
+		trace.UserID, trace.FileName, trace.BucketPath, id, pq.Array(ifMatch)).Scan(&trace.Version)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(tr.db, "api.trace", "trace", id)
+	}
+	return translateError(err, "trace")
 }
 
-func (tr *TraceRepository) UpdateTrace(id uuid.UUID, trace *model.Trace) error {
-	_, err := tr.db.Exec("UPDATE api.trace SET user_id = $1, file_name = $2, bucket_path = $3 WHERE id = $4",
-		trace.UserID, trace.FileName, trace.BucketPath, id)
+// PatchTrace writes the listed fields of trace, leaving other columns
+// untouched. On success trace.Version holds the new version.
+func (tr *TraceRepository) PatchTrace(id uuid.UUID, trace *model.Trace, fields []string, ifMatch []int64) error {
+	version, err := patchByID(tr.db, "api.trace", "trace", id, fields, []column{
+		{field: "user_id", name: "user_id", value: trace.UserID},
+		{field: "file_name", name: "file_name", value: trace.FileName},
+		{field: "bucket_path", name: "bucket_path", value: trace.BucketPath},
+	}, "", ifMatch)
+	if err != nil {
+		return translateError(err, "trace")
+	}
+	trace.Version = version
+	return nil
+}
+
+// DeleteTrace soft-deletes the trace; see RestoreTrace.
+func (tr *TraceRepository) DeleteTrace(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(tr.db, "api.trace", "trace", id, ifMatch, traceReferences)
+}
+
+// RestoreTrace undeletes a soft-deleted trace and returns its new version.
+func (tr *TraceRepository) RestoreTrace(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(tr.db, "api.trace", "trace", id, ifMatch, []parent{{field: "user_id", column: "user_id", table: "api.user"}})
+	return version, translateError(err, "trace")
+}
+
+// DeletedTracesBefore lists traces deleted before cutoff, so that their
+// objects can be removed from storage before the rows are purged.
+func (tr *TraceRepository) DeletedTracesBefore(cutoff time.Time) ([]model.Trace, error) {
+	rows, err := tr.db.Query("SELECT "+traceColumns+" FROM api.trace WHERE deleted_at < $1", cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traces []model.Trace
+	for rows.Next() {
+		var trace model.Trace
+		err = rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, trace)
+	}
+	return traces, rows.Err()
+}
+
+// PurgeTrace permanently removes a soft-deleted trace.
+func (tr *TraceRepository) PurgeTrace(id uuid.UUID) error {
+	_, err := tr.db.Exec("DELETE FROM api.trace WHERE id = $1 AND deleted_at IS NOT NULL", id)
 	return err
 }
 
-func (tr *TraceRepository) DeleteTrace(id uuid.UUID) error {
-	_, err := tr.db.Exec("DELETE FROM api.trace WHERE id = $1", id)
+// ClaimUploadedTraces moves up to limit uploaded traces, oldest first, to
+// scanning and returns them. Traces left scanning or parsing for longer than
+// lease, by a processor that died, are claimed again.
+func (tr *TraceRepository) ClaimUploadedTraces(ctx context.Context, limit int, lease time.Duration) ([]model.Trace, error) {
+	tx, err := begin(ctx, tr.db, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `UPDATE api.trace SET status = $3, status_error = '', status_updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id IN (
+			SELECT id FROM api.trace WHERE deleted_at IS NULL AND (status = 'uploaded'
+				OR (status IN ('scanning', 'parsing') AND status_updated_at < CURRENT_TIMESTAMP - make_interval(secs => $2)))
+			ORDER BY date_created LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING `+traceColumns+`, status_updated_at`,
+		limit, lease.Seconds(), model.TraceScanning)
+	if err != nil {
+		return nil, err
+	}
+	var traces []model.Trace
+	var statuses []model.TraceStatusEvent
+	for rows.Next() {
+		var trace model.Trace
+		var at time.Time
+		if err := rows.Scan(&trace.ID, &trace.UserID, &trace.FileName, &trace.DateCreated, &trace.BucketPath, &trace.Status, &trace.StatusError, &trace.Version, &at); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		traces = append(traces, trace)
+		statuses = append(statuses, model.TraceStatusEvent{TraceID: trace.ID, UserID: trace.UserID, Status: trace.Status, At: at})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range statuses {
+		if err := notifyTraceStatus(ctx, tx, &statuses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return traces, tx.Commit()
+}
+
+// AdvanceTrace moves a trace from status from to status to, recording
+// reason if it failed. It reports false, changing nothing, if the trace is
+// no longer in status from, such as when it was deleted or claimed again.
+func (tr *TraceRepository) AdvanceTrace(ctx context.Context, id uuid.UUID, from, to, reason string) (bool, error) {
+	return tr.moveTrace(ctx, id, from, to, reason, "", nil)
+}
+
+// CompleteTrace moves a parsed trace to ready with the text extracted from
+// its PDF and writes events about it to the outbox in the same transaction.
+// It reports false, changing nothing, if the trace is no longer parsing.
+func (tr *TraceRepository) CompleteTrace(ctx context.Context, id uuid.UUID, text string, events ...*model.CloudEvent) (bool, error) {
+	return tr.moveTrace(ctx, id, model.TraceParsing, model.TraceReady, "", text, events)
+}
+
+func (tr *TraceRepository) moveTrace(ctx context.Context, id uuid.UUID, from, to, reason, text string, events []*model.CloudEvent) (bool, error) {
+	if len(reason) > maxStatusError {
+		reason = strings.ToValidUTF8(reason[:maxStatusError], "")
+	}
+	tx, err := begin(ctx, tr.db, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	status := model.TraceStatusEvent{TraceID: id, Status: to, Error: reason}
+	err = tx.QueryRowContext(ctx, `UPDATE api.trace SET status = $3, status_error = $4, content_text = $5, status_updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND status = $2 AND deleted_at IS NULL RETURNING user_id, status_updated_at`,
+		id, from, to, reason, text).Scan(&status.UserID, &status.At)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := notifyTraceStatus(ctx, tx, &status); err != nil {
+		return false, err
+	}
+	if err := appendEvents(ctx, tx, events); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetTraceStatus returns the current status of a live trace.
+func (tr *TraceRepository) GetTraceStatus(ctx context.Context, id uuid.UUID) (*model.TraceStatusEvent, error) {
+	status := model.TraceStatusEvent{TraceID: id}
+	err := tr.db.QueryRowContext(ctx, "SELECT user_id, status, status_error, status_updated_at FROM api.trace WHERE id = $1 AND deleted_at IS NULL", id).
+		Scan(&status.UserID, &status.Status, &status.Error, &status.At)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("trace", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetUserTraceStatuses returns the status of the live traces of a user that
+// are still being processed or, if since is not zero, changed status after
+// since, oldest change first.
+func (tr *TraceRepository) GetUserTraceStatuses(ctx context.Context, userID uuid.UUID, since time.Time) ([]model.TraceStatusEvent, error) {
+	rows, err := tr.db.QueryContext(ctx, `SELECT id, user_id, status, status_error, status_updated_at FROM api.trace
+		WHERE user_id = $1 AND deleted_at IS NULL AND (status IN ('uploaded', 'scanning', 'parsing') OR status_updated_at > $2::timestamp)
+		ORDER BY status_updated_at, id`, userID, sql.NullTime{Time: since, Valid: !since.IsZero()})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []model.TraceStatusEvent
+	for rows.Next() {
+		var status model.TraceStatusEvent
+		if err := rows.Scan(&status.TraceID, &status.UserID, &status.Status, &status.Error, &status.At); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
+
+// notifyTraceStatus announces status on TraceStatusChannel through db. In a
+// transaction the announcement is delivered when it commits.
+func notifyTraceStatus(ctx context.Context, db execer, status *model.TraceStatusEvent) error {
+	payload, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "SELECT pg_notify($1, $2)", TraceStatusChannel, string(payload))
 	return err
 }
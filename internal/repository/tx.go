@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// dbtx is satisfied by *sql.DB and *sql.Tx. Repositories run their
+// statements on one, so that a caller can put several repository calls and
+// their audit record in a single transaction; see the WithTx methods.
+type dbtx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txn is a transaction begun by a repository method. Inside a caller's
+// transaction it is a savepoint instead, so the method still commits or
+// rolls back its own writes while the caller decides the fate of the whole.
+type txn struct {
+	*sql.Tx
+	nested bool
+	done   bool
+}
+
+// begin starts a transaction on db with opts, or a savepoint if db is
+// already a transaction. opts do not apply to a savepoint.
+func begin(ctx context.Context, db dbtx, opts *sql.TxOptions) (*txn, error) {
+	switch db := db.(type) {
+	case *sql.DB:
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &txn{Tx: tx}, nil
+	case *sql.Tx:
+		if _, err := db.ExecContext(ctx, "SAVEPOINT repository_txn"); err != nil {
+			return nil, err
+		}
+		return &txn{Tx: db, nested: true}, nil
+	default:
+		return nil, fmt.Errorf("cannot begin a transaction on %T", db)
+	}
+}
+
+// Commit commits the transaction or releases the savepoint.
+func (tx *txn) Commit() error {
+	if !tx.nested {
+		return tx.Tx.Commit()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	_, err := tx.Exec("RELEASE SAVEPOINT repository_txn")
+	return err
+}
+
+// Rollback rolls back the transaction or to the savepoint. Like
+// (*sql.Tx).Rollback it does nothing after Commit, so it can be deferred.
+func (tx *txn) Rollback() error {
+	if !tx.nested {
+		return tx.Tx.Rollback()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	if _, err := tx.Exec("ROLLBACK TO SAVEPOINT repository_txn"); err != nil {
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT repository_txn")
+	return err
+}
@@ -1,27 +1,45 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
+	"time"
 
+	"api-server/internal/apperr"
 	"api-server/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// userReferences lists the columns that point at api.user.
+var userReferences = []reference{
+	{resource: "course", table: "api.course", column: "owner_user_id"},
+	{resource: "instructor", table: "api.instructor", column: "user_id"},
+	{resource: "trace", table: "api.trace", column: "user_id"},
+	{resource: "enrollment", table: "api.enrollment", column: "user_id"},
+}
+
+// userColumns lists the columns of api.user in the order rows are scanned.
+const userColumns = "id, first_name, last_name, username, password, account_created, account_updated, version"
+
 type UserRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of ur that runs its statements in tx.
+func (ur *UserRepository) WithTx(tx *sql.Tx) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (ur *UserRepository) GetAllUsers() ([]model.User, error) {
-	rows, err := ur.db.Query(`SELECT * FROM "api"."user"`)
+	rows, err := ur.db.Query("SELECT " + userColumns + " FROM api.user WHERE deleted_at IS NULL")
 	if err != nil {
-		fmt.Println("ERROR", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -29,7 +47,7 @@ func (ur *UserRepository) GetAllUsers() ([]model.User, error) {
 	var users []model.User
 	for rows.Next() {
 		var user model.User
-		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Password, &user.AccountCreated, &user.AccountUpdated)
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Password, &user.AccountCreated, &user.AccountUpdated, &user.Version)
 		if err != nil {
 			return nil, err
 		}
@@ -39,34 +57,109 @@ func (ur *UserRepository) GetAllUsers() ([]model.User, error) {
 }
 
 func (ur *UserRepository) GetUserByID(id uuid.UUID) (*model.User, error) {
-	row := ur.db.QueryRow("SELECT * FROM api.user WHERE id = $1", id)
+	row := ur.db.QueryRow("SELECT "+userColumns+" FROM api.user WHERE id = $1 AND deleted_at IS NULL", id)
 	var user model.User
-	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Password, &user.AccountCreated, &user.AccountUpdated)
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Password, &user.AccountCreated, &user.AccountUpdated, &user.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
+			return nil, apperr.NotFound("user", id)
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// GetUsersByIDs returns the live users among ids, in no particular order.
+func (ur *UserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]model.User, error) {
+	rows, err := ur.db.QueryContext(ctx, "SELECT "+userColumns+" FROM api.user WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL", idArray(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Username, &user.Password, &user.AccountCreated, &user.AccountUpdated, &user.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetUserIDByUsername returns the ID of the live user with username.
+func (ur *UserRepository) GetUserIDByUsername(username string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := ur.db.QueryRow("SELECT id FROM api.user WHERE username = $1 AND deleted_at IS NULL", username).Scan(&id)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, apperr.NotFound("user", username)
+	}
+	return id, err
+}
+
 func (ur *UserRepository) CreateUser(user *model.User) error {
 	if user.ID == uuid.Nil {
 		user.ID = uuid.New()
 	}
 	_, err := ur.db.Exec("INSERT INTO api.user (id, first_name, last_name, username, password, account_created, account_updated) VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)",
 		user.ID, user.FirstName, user.LastName, user.Username, user.Password)
-	return err
+	user.Version = 1
+	return translateUserError(err)
 }
 
-func (ur *UserRepository) UpdateUser(id uuid.UUID, user *model.User) error {
-	_, err := ur.db.Exec("UPDATE api.user SET first_name = $1, last_name = $2, username = $3, password = $4, account_updated = CURRENT_TIMESTAMP WHERE id = $5",
-		user.FirstName, user.LastName, user.Username, user.Password, id)
-	return err
+// UpdateUser replaces the writable columns of user. A non-empty ifMatch
+// restricts the write to those row versions. On success user.Version
+// holds the new version.
+func (ur *UserRepository) UpdateUser(id uuid.UUID, user *model.User, ifMatch []int64) error {
+	err := ur.db.QueryRow("UPDATE api.user SET first_name = $1, last_name = $2, username = $3, password = $4, account_updated = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $5 AND deleted_at IS NULL AND "+versionMatches(6)+" RETURNING version",
+		user.FirstName, user.LastName, user.Username, user.Password, id, pq.Array(ifMatch)).Scan(&user.Version)
+	if err == sql.ErrNoRows {
+		return staleOrMissing(ur.db, "api.user", "user", id)
+	}
+	return translateUserError(err)
+}
+
+// PatchUser writes the listed fields of user, leaving other columns
+// untouched, so a patch without a password keeps the stored one. On success
+// user.Version holds the new version.
+func (ur *UserRepository) PatchUser(id uuid.UUID, user *model.User, fields []string, ifMatch []int64) error {
+	version, err := patchByID(ur.db, "api.user", "user", id, fields, []column{
+		{field: "first_name", name: "first_name", value: user.FirstName},
+		{field: "last_name", name: "last_name", value: user.LastName},
+		{field: "username", name: "username", value: user.Username},
+		{field: "password", name: "password", value: user.Password},
+	}, "account_updated", ifMatch)
+	if err != nil {
+		return translateUserError(err)
+	}
+	user.Version = version
+	return nil
+}
+
+// DeleteUser soft-deletes the user; see RestoreUser.
+func (ur *UserRepository) DeleteUser(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(ur.db, "api.user", "user", id, ifMatch, userReferences)
+}
+
+// RestoreUser undeletes a soft-deleted user and returns its new version.
+func (ur *UserRepository) RestoreUser(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(ur.db, "api.user", "user", id, ifMatch, nil)
+	return version, translateUserError(err)
+}
+
+// translateUserError reports the username unique constraint in terms clients
+// understand before falling back to the generic translation.
+func translateUserError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation {
+		return apperr.Conflict("username already exists", err)
+	}
+	return translateError(err, "user")
 }
 
-func (ur *UserRepository) DeleteUser(id uuid.UUID) error {
-	_, err := ur.db.Exec("DELETE FROM api.user WHERE id = $1", id)
-	return err
+// PurgeDeletedUsers permanently removes users deleted before cutoff and
+// returns how many were removed.
+func (ur *UserRepository) PurgeDeletedUsers(cutoff time.Time) (int64, error) {
+	return purgeDeleted(ur.db, "api.user", cutoff, userReferences)
 }
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// webhookColumns lists the columns of api.webhook_subscription in the order
+// rows are scanned. The secret is only read by the dispatcher.
+const webhookColumns = "w.id, w.url, w.description, w.event_types, w.active, w.date_created, w.date_last_updated, w.version"
+
+// deliveryColumns lists the columns of api.webhook_delivery in the order
+// rows are scanned. next_attempt_at only means something while pending.
+const deliveryColumns = "d.id, d.subscription_id, d.event_id, d.event_type, d.status, d.attempts, CASE WHEN d.status = 'pending' THEN d.next_attempt_at END, d.created_at, d.completed_at"
+
+type WebhookRepository struct {
+	db dbtx
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// WithTx returns a copy of wr that runs its statements in tx.
+func (wr *WebhookRepository) WithTx(tx *sql.Tx) *WebhookRepository {
+	return &WebhookRepository{db: tx}
+}
+
+func scanWebhook(row interface{ Scan(...any) error }, w *model.Webhook) error {
+	return row.Scan(&w.ID, &w.URL, &w.Description, pq.Array(&w.EventTypes), &w.Active, &w.DateCreated, &w.DateLastUpdated, &w.Version)
+}
+
+func scanDelivery(row interface{ Scan(...any) error }, d *model.WebhookDelivery) error {
+	return row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt, &d.CompletedAt)
+}
+
+// GetAllWebhooks returns the live webhooks in the order they were created.
+func (wr *WebhookRepository) GetAllWebhooks() ([]model.Webhook, error) {
+	rows, err := wr.db.Query("SELECT " + webhookColumns + " FROM api.webhook_subscription w WHERE w.deleted_at IS NULL ORDER BY w.date_created, w.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []model.Webhook
+	for rows.Next() {
+		var webhook model.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (wr *WebhookRepository) GetWebhookByID(id uuid.UUID) (*model.Webhook, error) {
+	row := wr.db.QueryRow("SELECT "+webhookColumns+" FROM api.webhook_subscription w WHERE w.id = $1 AND w.deleted_at IS NULL", id)
+	var webhook model.Webhook
+	if err := scanWebhook(row, &webhook); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.NotFound("webhook", id)
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (wr *WebhookRepository) CreateWebhook(webhook *model.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+	_, err := wr.db.Exec("INSERT INTO api.webhook_subscription (id, url, description, event_types, secret, active) VALUES ($1, $2, $3, $4, $5, $6)",
+		webhook.ID, webhook.URL, webhook.Description, pq.Array(webhook.EventTypes), webhook.Secret, webhook.Active)
+	webhook.Version = 1
+	return translateError(err, "webhook")
+}
+
+// PatchWebhook writes the listed fields of webhook, leaving other columns
+// untouched. Patching secret rotates it. On success webhook.Version holds
+// the new version.
+func (wr *WebhookRepository) PatchWebhook(id uuid.UUID, webhook *model.Webhook, fields []string, ifMatch []int64) error {
+	version, err := patchByID(wr.db, "api.webhook_subscription", "webhook", id, fields, []column{
+		{field: "url", name: "url", value: webhook.URL},
+		{field: "description", name: "description", value: webhook.Description},
+		{field: "event_types", name: "event_types", value: pq.Array(webhook.EventTypes)},
+		{field: "secret", name: "secret", value: webhook.Secret},
+		{field: "active", name: "active", value: webhook.Active},
+	}, "date_last_updated", ifMatch)
+	if err != nil {
+		return translateError(err, "webhook")
+	}
+	webhook.Version = version
+	return nil
+}
+
+// DeleteWebhook soft-deletes the webhook; see RestoreWebhook. Its pending
+// deliveries fail when they come due.
+func (wr *WebhookRepository) DeleteWebhook(id uuid.UUID, ifMatch []int64) error {
+	return deleteByID(wr.db, "api.webhook_subscription", "webhook", id, ifMatch, nil)
+}
+
+// RestoreWebhook undeletes a soft-deleted webhook and returns its new version.
+func (wr *WebhookRepository) RestoreWebhook(id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := restoreByID(wr.db, "api.webhook_subscription", "webhook", id, ifMatch, nil)
+	return version, translateError(err, "webhook")
+}
+
+// EnqueueWebhookEvent queues event for every active webhook subscribed to its
+// type and returns how many deliveries were queued.
+func (wr *WebhookRepository) EnqueueWebhookEvent(ctx context.Context, event *model.WebhookEvent) (int64, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	res, err := wr.db.ExecContext(ctx, `INSERT INTO api.webhook_delivery (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3 FROM api.webhook_subscription
+		WHERE active AND deleted_at IS NULL AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))`,
+		event.ID, event.Type, payload)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListWebhookDeliveries returns the deliveries of a live webhook matching
+// filter, newest first.
+func (wr *WebhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	if _, err := wr.GetWebhookByID(webhookID); err != nil {
+		return nil, err
+	}
+	rows, err := wr.db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM api.webhook_delivery d WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2) AND ($3::int8 = 0 OR d.id < $3) ORDER BY d.id DESC LIMIT $4",
+		webhookID, filter.Status, filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetWebhookDelivery returns a delivery of a live webhook with its payload
+// and the log of its attempts, oldest first.
+func (wr *WebhookRepository) GetWebhookDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	row := wr.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+", d.payload FROM api.webhook_delivery d JOIN api.webhook_subscription w ON w.id = d.subscription_id WHERE d.id = $1 AND d.subscription_id = $2 AND w.deleted_at IS NULL",
+		id, webhookID)
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.CompletedAt, &delivery.Payload)
+	if err == sql.ErrNoRows {
+		return nil, apperr.NotFound("webhook delivery", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := wr.db.QueryContext(ctx, "SELECT attempted_at, duration_ms, response_status, response_body, error FROM api.webhook_attempt WHERE delivery_id = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delivery.Log = []model.WebhookAttempt{}
+	for rows.Next() {
+		var attempt model.WebhookAttempt
+		if err := rows.Scan(&attempt.AttemptedAt, &attempt.DurationMS, &attempt.ResponseStatus, &attempt.ResponseBody, &attempt.Error); err != nil {
+			return nil, err
+		}
+		delivery.Log = append(delivery.Log, attempt)
+	}
+	return &delivery, rows.Err()
+}
+
+// RedeliverWebhookDelivery queues a completed delivery to be sent again
+// right away, with a fresh allowance of attempts. Its log is kept.
+func (wr *WebhookRepository) RedeliverWebhookDelivery(ctx context.Context, webhookID uuid.UUID, id int64) error {
+	delivery, err := wr.GetWebhookDelivery(ctx, webhookID, id)
+	if err != nil {
+		return err
+	}
+	res, err := wr.db.ExecContext(ctx, "UPDATE api.webhook_delivery SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, completed_at = NULL WHERE id = $1 AND status <> 'pending'", delivery.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+	return apperr.Conflict(fmt.Sprintf("webhook delivery %d is already pending", id), nil)
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are
+// due, and postpones them by lease so that no other dispatcher sends them
+// meanwhile. A delivery whose dispatcher dies is retried once the lease ends.
+func (wr *WebhookRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookJob, error) {
+	rows, err := wr.db.QueryContext(ctx, `UPDATE api.webhook_delivery d SET next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		FROM api.webhook_subscription w
+		WHERE w.id = d.subscription_id AND d.id IN (
+			SELECT id FROM api.webhook_delivery WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING d.id, d.event_type, d.payload, d.attempts, w.url, w.secret, w.active AND w.deleted_at IS NULL`,
+		limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []model.WebhookJob
+	for rows.Next() {
+		var job model.WebhookJob
+		if err := rows.Scan(&job.ID, &job.EventType, &job.Payload, &job.Attempts, &job.URL, &job.Secret, &job.Live); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// RecordWebhookAttempt logs an attempt to send a delivery and moves it to
+// status. A pending delivery is retried after retryIn.
+func (wr *WebhookRepository) RecordWebhookAttempt(ctx context.Context, id int64, attempt *model.WebhookAttempt, status string, retryIn time.Duration) error {
+	tx, err := begin(ctx, wr.db, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO api.webhook_attempt (delivery_id, attempted_at, duration_ms, response_status, response_body, error)
+		VALUES ($1, CURRENT_TIMESTAMP - make_interval(secs => $2::float8 / 1000), $2, $3, $4, $5) RETURNING attempted_at`,
+		id, attempt.DurationMS, attempt.ResponseStatus, attempt.ResponseBody, attempt.Error).Scan(&attempt.AttemptedAt)
+	if err != nil {
+		return err
+	}
+	if status == model.DeliveryPending {
+		_, err = tx.ExecContext(ctx, "UPDATE api.webhook_delivery SET attempts = attempts + 1, next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $1) WHERE id = $2", retryIn.Seconds(), id)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE api.webhook_delivery SET attempts = attempts + 1, status = $1, completed_at = CURRENT_TIMESTAMP WHERE id = $2", status, id)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeWebhookDeliveries permanently removes deliveries, and their logs,
+// that completed before cutoff and returns how many were removed.
+func (wr *WebhookRepository) PurgeWebhookDeliveries(cutoff time.Time) (int64, error) {
+	res, err := wr.db.Exec("DELETE FROM api.webhook_delivery WHERE completed_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PurgeDeletedWebhooks permanently removes webhooks deleted before cutoff,
+// with their deliveries, and returns how many were removed.
+func (wr *WebhookRepository) PurgeDeletedWebhooks(cutoff time.Time) (int64, error) {
+	return purgeDeleted(wr.db, "api.webhook_subscription", cutoff, nil)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CourseInstructorService struct {
+	cir    *repository.CourseInstructorRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewCourseInstructorService(db *sql.DB, logger *slog.Logger) *CourseInstructorService {
+	return &CourseInstructorService{cir: repository.NewCourseInstructorRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "course_instructor")}
+}
+
+// assignmentDates reports an assignment that ends before it starts. Open
+// ends and dates that do not parse are left to request validation.
+func assignmentDates(ci *model.CourseInstructor) []apperr.FieldViolation {
+	if ci.StartDate == nil || ci.EndDate == nil {
+		return nil
+	}
+	start, err := time.Parse(time.DateOnly, *ci.StartDate)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse(time.DateOnly, *ci.EndDate)
+	if err != nil || !end.Before(start) {
+		return nil
+	}
+	return []apperr.FieldViolation{{Field: "end_date", Message: "must not be before start_date"}}
+}
+
+// GetCourseInstructors lists the instructors assigned to a course.
+func (cis *CourseInstructorService) GetCourseInstructors(courseID uuid.UUID) ([]model.CourseInstructor, error) {
+	return cis.cir.GetCourseInstructors(courseID)
+}
+
+func (cis *CourseInstructorService) GetCourseInstructorByID(courseID, id uuid.UUID) (*model.CourseInstructor, error) {
+	return cis.cir.GetCourseInstructorByID(courseID, id)
+}
+
+// GetInstructorCourses lists the courses an instructor is assigned to and
+// their role in each.
+func (cis *CourseInstructorService) GetInstructorCourses(instructorID uuid.UUID) ([]model.InstructorCourse, error) {
+	return cis.cir.GetInstructorCourses(instructorID)
+}
+
+// CreateCourseInstructor validates and stores the assignment ci of an
+// instructor to its course, and returns it as stored.
+func (cis *CourseInstructorService) CreateCourseInstructor(ctx context.Context, ci *model.CourseInstructor) (*model.CourseInstructor, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) {
+		violations, err := cis.cir.CheckReferences(ci)
+		return append(violations, assignmentDates(ci)...), err
+	}
+	if err := Validate(ci, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, cis.uow, func(tx *uowTx) (*model.CourseInstructor, error) {
+		cir := cis.cir.WithTx(tx.Tx)
+		if err := cir.CreateCourseInstructor(ci); err != nil {
+			return nil, err
+		}
+		return record(tx, "course_instructor", ci.ID, audit.ActionCreate, nil, assignmentLoader(cir, ci.CourseID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	cis.logger.InfoContext(ctx, "instructor assigned", "course_id", ci.CourseID, "assignment_id", ci.ID, "role", ci.Role)
+	return created, nil
+}
+
+// PatchCourseInstructor stores the patched fields of ci, which holds the
+// assignment with id after a merge patch, and returns it as stored. Only
+// violations of fields are reported, but the dates are checked together.
+func (cis *CourseInstructorService) PatchCourseInstructor(ctx context.Context, courseID, id uuid.UUID, ci *model.CourseInstructor, fields []string, ifMatch []int64) (*model.CourseInstructor, error) {
+	if err := Validate(ci, fields, nil); err != nil {
+		return nil, err
+	}
+	if violations := assignmentDates(ci); len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+	after, err := inTx(ctx, cis.uow, func(tx *uowTx) (*model.CourseInstructor, error) {
+		cir := cis.cir.WithTx(tx.Tx)
+		before, err := cir.GetCourseInstructorByID(courseID, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := cir.PatchCourseInstructor(id, ci, fields, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "course_instructor", id, audit.ActionUpdate, before, assignmentLoader(cir, courseID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	cis.logger.InfoContext(ctx, "instructor assignment patched", "course_id", courseID, "assignment_id", id, "fields", fields)
+	return after, nil
+}
+
+func (cis *CourseInstructorService) DeleteCourseInstructor(ctx context.Context, courseID, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, cis.uow, func(tx *uowTx) (*model.CourseInstructor, error) {
+		cir := cis.cir.WithTx(tx.Tx)
+		before, err := cir.GetCourseInstructorByID(courseID, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := cir.DeleteCourseInstructor(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "course_instructor", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	cis.logger.InfoContext(ctx, "instructor unassigned", "course_id", courseID, "assignment_id", id)
+	return nil
+}
+
+// assignmentLoader reads the assignments of courseID through cir, for
+// record.
+func assignmentLoader(cir *repository.CourseInstructorRepository, courseID uuid.UUID) func(uuid.UUID) (*model.CourseInstructor, error) {
+	return func(id uuid.UUID) (*model.CourseInstructor, error) {
+		return cir.GetCourseInstructorByID(courseID, id)
+	}
+}
@@ -1,37 +1,194 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
 	"api-server/internal/model"
 	"api-server/internal/repository"
-	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type CourseService struct {
-	cr *repository.CourseRepository
+	cr     *repository.CourseRepository
+	er     *repository.EnrollmentRepository
+	uow    *unitOfWork
+	logger *slog.Logger
 }
 
-func NewCourseService(db *sql.DB) *CourseService {
-	return &CourseService{cr: repository.NewCourseRepository(db)}
+func NewCourseService(db *sql.DB, logger *slog.Logger) *CourseService {
+	return &CourseService{cr: repository.NewCourseRepository(db), er: repository.NewEnrollmentRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "course")}
 }
 
-func (cs *CourseService) GetAllCourses() ([]model.Course, error) {
-	return cs.cr.GetAllCourses()
+func (cs *CourseService) GetAllCourses(filter model.CourseFilter) ([]model.Course, error) {
+	return cs.cr.GetAllCourses(filter)
 }
 
 func (cs *CourseService) GetCourseByID(id uuid.UUID) (*model.Course, error) {
 	return cs.cr.GetCourseByID(id)
 }
 
-func (cs *CourseService) CreateCourse(course *model.Course) error {
-	return cs.cr.CreateCourse(course)
+// StreamCourses calls fn for each course matching filter, reading them from
+// one consistent snapshot.
+func (cs *CourseService) StreamCourses(ctx context.Context, filter model.CourseFilter, fn func(*model.Course) error) error {
+	return cs.cr.StreamCourses(ctx, filter, fn)
+}
+
+// CreateCourse validates and stores course, and returns it as stored.
+func (cs *CourseService) CreateCourse(ctx context.Context, course *model.Course) (*model.Course, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return cs.cr.CheckReferences(course) }
+	if err := Validate(course, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, cs.uow, func(tx *uowTx) (*model.Course, error) {
+		cr := cs.cr.WithTx(tx.Tx)
+		if err := cr.CreateCourse(course); err != nil {
+			return nil, err
+		}
+		return record(tx, "course", course.ID, audit.ActionCreate, nil, cr.GetCourseByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cs.logger.InfoContext(ctx, "course created", "course_id", course.ID)
+	return created, nil
+}
+
+// UpdateCourse replaces the course with id, fills any seats the change
+// opened from its waitlist, and returns it as stored.
+func (cs *CourseService) UpdateCourse(ctx context.Context, id uuid.UUID, course *model.Course, ifMatch []int64) (*model.Course, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return cs.cr.CheckReferences(course) }
+	if err := Validate(course, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := cs.update(ctx, id, func(cr *repository.CourseRepository) error {
+		return cr.UpdateCourse(id, course, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cs.logger.InfoContext(ctx, "course updated", "course_id", id)
+	return after, nil
+}
+
+// PatchCourse stores the patched fields of course, which holds the course
+// with id after a merge patch, fills any seats the change opened from its
+// waitlist, and returns it as stored. Only violations of fields are
+// reported.
+func (cs *CourseService) PatchCourse(ctx context.Context, id uuid.UUID, course *model.Course, fields []string, ifMatch []int64) (*model.Course, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return cs.cr.CheckReferences(course) }
+	if err := Validate(course, fields, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := cs.update(ctx, id, func(cr *repository.CourseRepository) error {
+		return cr.PatchCourse(id, course, fields, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cs.logger.InfoContext(ctx, "course patched", "course_id", id, "fields", fields)
+	return after, nil
+}
+
+// update runs write on the course with id, records the change and fills
+// any seats it opened from the course's waitlist, all in one transaction.
+func (cs *CourseService) update(ctx context.Context, id uuid.UUID, write func(*repository.CourseRepository) error) (*model.Course, error) {
+	return inTx(ctx, cs.uow, func(tx *uowTx) (*model.Course, error) {
+		cr := cs.cr.WithTx(tx.Tx)
+		before, err := cr.GetCourseByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(cr); err != nil {
+			return nil, err
+		}
+		after, err := record(tx, "course", id, audit.ActionUpdate, before, cr.GetCourseByID)
+		if err != nil {
+			return nil, err
+		}
+		return after, promoteWaitlisted(ctx, tx, cs.er, cs.logger, id)
+	})
+}
+
+func (cs *CourseService) DeleteCourse(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, cs.uow, func(tx *uowTx) (*model.Course, error) {
+		cr := cs.cr.WithTx(tx.Tx)
+		before, err := cr.GetCourseByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := cr.DeleteCourse(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "course", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	cs.logger.InfoContext(ctx, "course deleted", "course_id", id)
+	return nil
+}
+
+// RestoreCourse undeletes the course with id and returns its new version.
+func (cs *CourseService) RestoreCourse(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, cs.uow, func(tx *uowTx) (int64, error) {
+		cr := cs.cr.WithTx(tx.Tx)
+		version, err := cr.RestoreCourse(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "course", id, audit.ActionRestore, nil, cr.GetCourseByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	cs.logger.InfoContext(ctx, "course restored", "course_id", id)
+	return version, nil
 }
 
-func (cs *CourseService) UpdateCourse(id uuid.UUID, course *model.Course) error {
-	return cs.cr.UpdateCourse(id, course)
+// CheckReferences reports references of course that do not exist.
+func (cs *CourseService) CheckReferences(course *model.Course) ([]apperr.FieldViolation, error) {
+	return cs.cr.CheckReferences(course)
 }
 
-func (cs *CourseService) DeleteCourse(id uuid.UUID) error {
-	return cs.cr.DeleteCourse(id)
+// ImportCourses upserts courses, which must already be valid, as described
+// by opts. Each written course is audited and seats opened by an update are
+// filled from its waitlist in the transaction of the import.
+func (cs *CourseService) ImportCourses(ctx context.Context, courses []model.Course, opts model.ImportOptions) ([]repository.ImportResult[model.Course], bool, error) {
+	var applied bool
+	results, err := inTx(ctx, cs.uow, func(tx *uowTx) ([]repository.ImportResult[model.Course], error) {
+		cr := cs.cr.WithTx(tx.Tx)
+		results, ok, err := cr.ImportCourses(ctx, courses, opts)
+		if err != nil || !ok {
+			return results, err
+		}
+		for _, res := range results {
+			if res.Err != nil {
+				continue
+			}
+			if res.Before == nil {
+				if _, err := record(tx, "course", res.ID, audit.ActionCreate, nil, cr.GetCourseByID); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if _, err := record(tx, "course", res.ID, audit.ActionUpdate, res.Before, cr.GetCourseByID); err != nil {
+				return nil, err
+			}
+			if err := promoteWaitlisted(ctx, tx, cs.er, cs.logger, res.ID); err != nil {
+				return nil, err
+			}
+		}
+		applied = true
+		return results, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return results, applied, nil
 }
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type EnrollmentService struct {
+	er     *repository.EnrollmentRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewEnrollmentService(db *sql.DB, logger *slog.Logger) *EnrollmentService {
+	return &EnrollmentService{er: repository.NewEnrollmentRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "enrollment")}
+}
+
+// GetCourseEnrollments lists the enrollments of a course, only those with
+// status if it is not empty.
+func (es *EnrollmentService) GetCourseEnrollments(courseID uuid.UUID, status string) ([]model.Enrollment, error) {
+	return es.er.GetCourseEnrollments(courseID, status)
+}
+
+func (es *EnrollmentService) GetEnrollmentByID(courseID, id uuid.UUID) (*model.Enrollment, error) {
+	return es.er.GetEnrollmentByID(courseID, id)
+}
+
+// GetUserEnrollments is the student view: every course a user is enrolled
+// in, waitlisted for, has dropped or completed.
+func (es *EnrollmentService) GetUserEnrollments(userID uuid.UUID) ([]model.StudentEnrollment, error) {
+	return es.er.GetUserEnrollments(userID)
+}
+
+// GetInstructorRoster is the instructor view: the enrolled and waitlisted
+// students of each course the instructor teaches.
+func (es *EnrollmentService) GetInstructorRoster(instructorID uuid.UUID) ([]model.CourseRoster, error) {
+	return es.er.GetInstructorRoster(instructorID)
+}
+
+// Enroll enrolls the student of req in a course, placing them on the
+// waitlist when the course is at capacity, and returns the enrollment.
+func (es *EnrollmentService) Enroll(ctx context.Context, courseID uuid.UUID, req *model.EnrollmentRequest) (*model.Enrollment, error) {
+	if err := Validate(req, nil, nil); err != nil {
+		return nil, err
+	}
+	enrollment, err := inTx(ctx, es.uow, func(tx *uowTx) (*model.Enrollment, error) {
+		enrollment, err := es.er.WithTx(tx.Tx).Enroll(courseID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return enrollment, tx.Record("enrollment", enrollment.ID, audit.ActionCreate, nil, enrollment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	es.logger.InfoContext(ctx, "student enrolled", "course_id", courseID, "enrollment_id", enrollment.ID, "status", enrollment.Status)
+	return enrollment, nil
+}
+
+// PatchEnrollment stores the status of enrollment, which holds the
+// enrollment with id after a merge patch of fields, and returns it as
+// stored. Status is the only field clients may change; changing it may move
+// waitlisted students into the seat it frees.
+func (es *EnrollmentService) PatchEnrollment(ctx context.Context, courseID, id uuid.UUID, enrollment *model.Enrollment, fields []string, ifMatch []int64) (*model.Enrollment, error) {
+	var violations []apperr.FieldViolation
+	for _, f := range fields {
+		if f != "status" {
+			violations = append(violations, apperr.FieldViolation{Field: f, Message: "cannot be changed"})
+		}
+	}
+	if len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+	if err := Validate(enrollment, fields, nil); err != nil {
+		return nil, err
+	}
+	after, err := inTx(ctx, es.uow, func(tx *uowTx) (*model.Enrollment, error) {
+		er := es.er.WithTx(tx.Tx)
+		before, err := er.GetEnrollmentByID(courseID, id)
+		if err != nil {
+			return nil, err
+		}
+		promoted, err := er.ChangeEnrollmentStatus(courseID, id, enrollment.Status, ifMatch)
+		if err != nil {
+			return nil, err
+		}
+		after, err := record(tx, "enrollment", id, audit.ActionUpdate, before, func(id uuid.UUID) (*model.Enrollment, error) {
+			return er.GetEnrollmentByID(courseID, id)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return after, recordPromotions(ctx, tx, er, es.logger, courseID, promoted)
+	})
+	if err != nil {
+		return nil, err
+	}
+	es.logger.InfoContext(ctx, "enrollment status changed", "course_id", courseID, "enrollment_id", id, "status", enrollment.Status)
+	return after, nil
+}
+
+// DeleteEnrollment removes an enrollment, moving waitlisted students into
+// the seat it frees.
+func (es *EnrollmentService) DeleteEnrollment(ctx context.Context, courseID, id uuid.UUID, ifMatch []int64) error {
+	err := es.uow.run(ctx, func(tx *uowTx) error {
+		er := es.er.WithTx(tx.Tx)
+		before, err := er.GetEnrollmentByID(courseID, id)
+		if err != nil {
+			return err
+		}
+		promoted, err := er.DeleteEnrollment(courseID, id, ifMatch)
+		if err != nil {
+			return err
+		}
+		if err := tx.Record("enrollment", id, audit.ActionDelete, before, nil); err != nil {
+			return err
+		}
+		return recordPromotions(ctx, tx, er, es.logger, courseID, promoted)
+	})
+	if err != nil {
+		return err
+	}
+	es.logger.InfoContext(ctx, "enrollment deleted", "course_id", courseID, "enrollment_id", id)
+	return nil
+}
+
+// promoteWaitlisted fills any seats a course change opened in tx, for
+// example a raised capacity, from the course's waitlist.
+func promoteWaitlisted(ctx context.Context, tx *uowTx, er *repository.EnrollmentRepository, logger *slog.Logger, courseID uuid.UUID) error {
+	er = er.WithTx(tx.Tx)
+	promoted, err := er.PromoteWaitlisted(courseID)
+	if err != nil {
+		return err
+	}
+	return recordPromotions(ctx, tx, er, logger, courseID, promoted)
+}
+
+// recordPromotions logs and audits students moved off the waitlist of a
+// course in tx, reading them through er. Only the status change is
+// recorded.
+func recordPromotions(ctx context.Context, tx *uowTx, er *repository.EnrollmentRepository, logger *slog.Logger, courseID uuid.UUID, promoted []uuid.UUID) error {
+	for _, id := range promoted {
+		after, err := er.GetEnrollmentByID(courseID, id)
+		if err != nil {
+			return err
+		}
+		before := *after
+		before.Status, before.WaitlistPosition = model.EnrollmentWaitlisted, 0
+		if err := tx.Record("enrollment", id, audit.ActionUpdate, &before, after); err != nil {
+			return err
+		}
+		logger.InfoContext(ctx, "waitlisted student promoted", "course_id", courseID, "enrollment_id", id)
+	}
+	return nil
+}
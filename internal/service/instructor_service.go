@@ -1,6 +1,12 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
 	"api-server/internal/model"
 	"api-server/internal/repository"
 
@@ -8,11 +14,13 @@ import (
 )
 
 type InstructorService struct {
-	ir *repository.InstructorRepository
+	ir     *repository.InstructorRepository
+	uow    *unitOfWork
+	logger *slog.Logger
 }
 
-func NewInstructorService(ir *repository.InstructorRepository) *InstructorService {
-	return &InstructorService{ir: ir}
+func NewInstructorService(db *sql.DB, logger *slog.Logger) *InstructorService {
+	return &InstructorService{ir: repository.NewInstructorRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "instructor")}
 }
 
 func (is *InstructorService) GetAllInstructors() ([]model.Instructor, error) {
@@ -23,14 +31,153 @@ func (is *InstructorService) GetInstructorByID(id uuid.UUID) (*model.Instructor,
 	return is.ir.GetInstructorByID(id)
 }
 
-func (is *InstructorService) CreateInstructor(instructor *model.Instructor) error {
-	return is.ir.CreateInstructor(instructor)
+// StreamInstructors calls fn for each instructor, reading them from one
+// consistent snapshot.
+func (is *InstructorService) StreamInstructors(ctx context.Context, fn func(*model.Instructor) error) error {
+	return is.ir.StreamInstructors(ctx, fn)
+}
+
+// CreateInstructor validates and stores instructor, and returns it as
+// stored.
+func (is *InstructorService) CreateInstructor(ctx context.Context, instructor *model.Instructor) (*model.Instructor, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return is.ir.CheckReferences(instructor) }
+	if err := Validate(instructor, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, is.uow, func(tx *uowTx) (*model.Instructor, error) {
+		ir := is.ir.WithTx(tx.Tx)
+		if err := ir.CreateInstructor(instructor); err != nil {
+			return nil, err
+		}
+		return record(tx, "instructor", instructor.ID, audit.ActionCreate, nil, ir.GetInstructorByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	is.logger.InfoContext(ctx, "instructor created", "instructor_id", instructor.ID)
+	return created, nil
+}
+
+// UpdateInstructor replaces the instructor with id and returns it as stored.
+func (is *InstructorService) UpdateInstructor(ctx context.Context, id uuid.UUID, instructor *model.Instructor, ifMatch []int64) (*model.Instructor, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return is.ir.CheckReferences(instructor) }
+	if err := Validate(instructor, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := is.update(ctx, id, func(ir *repository.InstructorRepository) error {
+		return ir.UpdateInstructor(id, instructor, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	is.logger.InfoContext(ctx, "instructor updated", "instructor_id", id)
+	return after, nil
+}
+
+// PatchInstructor stores the patched fields of instructor, which holds the instructor with
+// id after a merge patch, and returns it as stored. Only violations of
+// fields are reported.
+func (is *InstructorService) PatchInstructor(ctx context.Context, id uuid.UUID, instructor *model.Instructor, fields []string, ifMatch []int64) (*model.Instructor, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return is.ir.CheckReferences(instructor) }
+	if err := Validate(instructor, fields, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := is.update(ctx, id, func(ir *repository.InstructorRepository) error {
+		return ir.PatchInstructor(id, instructor, fields, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	is.logger.InfoContext(ctx, "instructor patched", "instructor_id", id, "fields", fields)
+	return after, nil
+}
+
+// update runs write on the instructor with id and records the change in one
+// transaction.
+func (is *InstructorService) update(ctx context.Context, id uuid.UUID, write func(*repository.InstructorRepository) error) (*model.Instructor, error) {
+	return inTx(ctx, is.uow, func(tx *uowTx) (*model.Instructor, error) {
+		ir := is.ir.WithTx(tx.Tx)
+		before, err := ir.GetInstructorByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(ir); err != nil {
+			return nil, err
+		}
+		return record(tx, "instructor", id, audit.ActionUpdate, before, ir.GetInstructorByID)
+	})
+}
+
+func (is *InstructorService) DeleteInstructor(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, is.uow, func(tx *uowTx) (*model.Instructor, error) {
+		ir := is.ir.WithTx(tx.Tx)
+		before, err := ir.GetInstructorByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := ir.DeleteInstructor(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "instructor", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	is.logger.InfoContext(ctx, "instructor deleted", "instructor_id", id)
+	return nil
+}
+
+// RestoreInstructor undeletes the instructor with id and returns its new version.
+func (is *InstructorService) RestoreInstructor(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, is.uow, func(tx *uowTx) (int64, error) {
+		ir := is.ir.WithTx(tx.Tx)
+		version, err := ir.RestoreInstructor(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "instructor", id, audit.ActionRestore, nil, ir.GetInstructorByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	is.logger.InfoContext(ctx, "instructor restored", "instructor_id", id)
+	return version, nil
 }
 
-func (is *InstructorService) UpdateInstructor(id uuid.UUID, instructor *model.Instructor) error {
-	return is.ir.UpdateInstructor(id, instructor)
+// CheckReferences reports references of instructor that do not exist.
+func (is *InstructorService) CheckReferences(instructor *model.Instructor) ([]apperr.FieldViolation, error) {
+	return is.ir.CheckReferences(instructor)
 }
 
-func (is *InstructorService) DeleteInstructor(id uuid.UUID) error {
-	return is.ir.DeleteInstructor(id)
+// ImportInstructors upserts instructors, which must already be valid, as
+// described by opts, matching the existing instructor of each user. Each
+// written instructor is audited in the transaction of the import.
+func (is *InstructorService) ImportInstructors(ctx context.Context, instructors []model.Instructor, opts model.ImportOptions) ([]repository.ImportResult[model.Instructor], bool, error) {
+	var applied bool
+	results, err := inTx(ctx, is.uow, func(tx *uowTx) ([]repository.ImportResult[model.Instructor], error) {
+		ir := is.ir.WithTx(tx.Tx)
+		results, ok, err := ir.ImportInstructors(ctx, instructors, opts)
+		if err != nil || !ok {
+			return results, err
+		}
+		for _, res := range results {
+			if res.Err != nil {
+				continue
+			}
+			action := audit.ActionUpdate
+			if res.Before == nil {
+				action = audit.ActionCreate
+			}
+			if _, err := record(tx, "instructor", res.ID, action, res.Before, ir.GetInstructorByID); err != nil {
+				return nil, err
+			}
+		}
+		applied = true
+		return results, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return results, applied, nil
 }
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type PrerequisiteService struct {
+	pr     *repository.PrerequisiteRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewPrerequisiteService(db *sql.DB, logger *slog.Logger) *PrerequisiteService {
+	return &PrerequisiteService{pr: repository.NewPrerequisiteRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "prerequisite")}
+}
+
+// GetPrerequisites lists the direct prerequisites and corequisites of a
+// course.
+func (ps *PrerequisiteService) GetPrerequisites(courseID uuid.UUID) ([]model.Requisite, error) {
+	return ps.pr.GetPrerequisites(courseID)
+}
+
+// GetTransitivePrerequisites lists every course the prerequisites of a
+// course lead back to.
+func (ps *PrerequisiteService) GetTransitivePrerequisites(courseID uuid.UUID) ([]model.TransitivePrerequisite, error) {
+	return ps.pr.GetTransitivePrerequisites(courseID)
+}
+
+// CheckEligibility tells whether a user has met a course's requisites.
+func (ps *PrerequisiteService) CheckEligibility(courseID, userID uuid.UUID) (*model.Eligibility, error) {
+	return ps.pr.CheckEligibility(courseID, userID)
+}
+
+// AddPrerequisite validates req and adds it to the requisites of a course.
+// Prerequisites that would close a cycle are rejected as conflicts.
+func (ps *PrerequisiteService) AddPrerequisite(ctx context.Context, courseID uuid.UUID, req *model.Requisite) error {
+	if err := Validate(req, nil, nil); err != nil {
+		return err
+	}
+	_, err := inTx(ctx, ps.uow, func(tx *uowTx) (*model.Requisite, error) {
+		if err := ps.pr.WithTx(tx.Tx).AddPrerequisite(courseID, req); err != nil {
+			return nil, err
+		}
+		return req, tx.Record("course_prerequisite", req.ID, audit.ActionCreate, nil, req)
+	})
+	if err != nil {
+		return err
+	}
+	ps.logger.InfoContext(ctx, "requisite added", "course_id", courseID, "code", req.Code, "kind", req.Kind)
+	return nil
+}
+
+// RemovePrerequisite removes the requisite with code from a course.
+func (ps *PrerequisiteService) RemovePrerequisite(ctx context.Context, courseID uuid.UUID, code string) error {
+	_, err := inTx(ctx, ps.uow, func(tx *uowTx) (*model.Requisite, error) {
+		req, err := ps.pr.WithTx(tx.Tx).RemovePrerequisite(courseID, code)
+		if err != nil {
+			return nil, err
+		}
+		return nil, tx.Record("course_prerequisite", req.ID, audit.ActionDelete, req, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ps.logger.InfoContext(ctx, "requisite removed", "course_id", courseID, "code", code)
+	return nil
+}
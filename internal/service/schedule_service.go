@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ScheduleService struct {
+	sr     *repository.ScheduleRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewScheduleService(db *sql.DB, logger *slog.Logger) *ScheduleService {
+	return &ScheduleService{sr: repository.NewScheduleRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "schedule")}
+}
+
+// meetingRules reports a meeting that ends before it starts, an in-person
+// or hybrid meeting without a room, and an online meeting with one. Times
+// that do not parse are left to request validation.
+func meetingRules(m *model.Meeting) []apperr.FieldViolation {
+	var violations []apperr.FieldViolation
+	start, errStart := time.Parse("15:04", m.StartTime)
+	end, errEnd := time.Parse("15:04", m.EndTime)
+	if errStart == nil && errEnd == nil && !end.After(start) {
+		violations = append(violations, apperr.FieldViolation{Field: "end_time", Message: "must be after start_time"})
+	}
+	switch {
+	case m.Modality == model.ModalityOnline && m.Room != nil:
+		violations = append(violations, apperr.FieldViolation{Field: "room", Message: "must be empty for online meetings"})
+	case m.Modality != model.ModalityOnline && m.Room == nil:
+		violations = append(violations, apperr.FieldViolation{Field: "room", Message: "is required unless modality is online"})
+	}
+	return violations
+}
+
+func (ss *ScheduleService) GetCourseMeetings(courseID uuid.UUID) ([]model.Meeting, error) {
+	return ss.sr.GetCourseMeetings(courseID)
+}
+
+func (ss *ScheduleService) GetMeetingByID(courseID, id uuid.UUID) (*model.Meeting, error) {
+	return ss.sr.GetMeetingByID(courseID, id)
+}
+
+// GetCourseSchedule returns the meetings of a course with their term dates.
+func (ss *ScheduleService) GetCourseSchedule(courseID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	return ss.sr.GetCourseSchedule(courseID)
+}
+
+// GetInstructorSchedule returns the meetings of every course an instructor
+// teaches.
+func (ss *ScheduleService) GetInstructorSchedule(instructorID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	return ss.sr.GetInstructorSchedule(instructorID)
+}
+
+// GetStudentSchedule returns the meetings of every course a user is
+// enrolled in.
+func (ss *ScheduleService) GetStudentSchedule(userID uuid.UUID) ([]model.ScheduledMeeting, error) {
+	return ss.sr.GetStudentSchedule(userID)
+}
+
+// CreateMeeting validates and stores a weekly meeting of its course, and
+// returns it as stored. Meetings that double-book a room or instructor are
+// rejected as conflicts.
+func (ss *ScheduleService) CreateMeeting(ctx context.Context, meeting *model.Meeting) (*model.Meeting, error) {
+	checkRules := func() ([]apperr.FieldViolation, error) { return meetingRules(meeting), nil }
+	if err := Validate(meeting, nil, checkRules); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, ss.uow, func(tx *uowTx) (*model.Meeting, error) {
+		sr := ss.sr.WithTx(tx.Tx)
+		if err := sr.CreateMeeting(meeting); err != nil {
+			return nil, err
+		}
+		return record(tx, "meeting", meeting.ID, audit.ActionCreate, nil, meetingLoader(sr, meeting.CourseID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	ss.logger.InfoContext(ctx, "meeting created", "course_id", meeting.CourseID, "meeting_id", meeting.ID)
+	return created, nil
+}
+
+// PatchMeeting stores the patched fields of meeting, which holds the
+// meeting with id after a merge patch, and returns it as stored. Only
+// violations of fields are reported, but since changing the modality can
+// make an unpatched room invalid, the meeting rules are checked across the
+// whole meeting.
+func (ss *ScheduleService) PatchMeeting(ctx context.Context, courseID, id uuid.UUID, meeting *model.Meeting, fields []string, ifMatch []int64) (*model.Meeting, error) {
+	if err := Validate(meeting, fields, nil); err != nil {
+		return nil, err
+	}
+	if violations := meetingRules(meeting); len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+	after, err := inTx(ctx, ss.uow, func(tx *uowTx) (*model.Meeting, error) {
+		sr := ss.sr.WithTx(tx.Tx)
+		before, err := sr.GetMeetingByID(courseID, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := sr.PatchMeeting(id, meeting, fields, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "meeting", id, audit.ActionUpdate, before, meetingLoader(sr, courseID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	ss.logger.InfoContext(ctx, "meeting patched", "course_id", courseID, "meeting_id", id, "fields", fields)
+	return after, nil
+}
+
+func (ss *ScheduleService) DeleteMeeting(ctx context.Context, courseID, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, ss.uow, func(tx *uowTx) (*model.Meeting, error) {
+		sr := ss.sr.WithTx(tx.Tx)
+		before, err := sr.GetMeetingByID(courseID, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := sr.DeleteMeeting(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "meeting", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ss.logger.InfoContext(ctx, "meeting deleted", "course_id", courseID, "meeting_id", id)
+	return nil
+}
+
+// meetingLoader reads the meetings of courseID through sr, for record.
+func meetingLoader(sr *repository.ScheduleRepository, courseID uuid.UUID) func(uuid.UUID) (*model.Meeting, error) {
+	return func(id uuid.UUID) (*model.Meeting, error) {
+		return sr.GetMeetingByID(courseID, id)
+	}
+}
@@ -0,0 +1,70 @@
+// Package service holds the operations on the API's resources shared by the
+// REST handlers and the gRPC API: validation, and writes committed in one
+// transaction with their audit records, webhook deliveries and domain
+// events. Errors are apperr errors, which each API maps to its own status
+// codes. The actor and source address of a change are read from the
+// context; see actor.NewContext and audit.WithSourceIP.
+package service
+
+import (
+	"context"
+	"slices"
+
+	"api-server/internal/apperr"
+	"api-server/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// Validate checks v against its validate tags and collects the violations
+// reported by checkRefs, so that clients see every problem at once. If
+// fields is not nil only violations of those fields are reported, so that
+// existing rows that predate a rule can still be patched.
+func Validate(v any, fields []string, checkRefs func() ([]apperr.FieldViolation, error)) error {
+	violations := validation.Struct(v)
+	if checkRefs != nil {
+		refViolations, err := checkRefs()
+		if err != nil {
+			return err
+		}
+		violations = append(violations, refViolations...)
+	}
+	if fields != nil {
+		violations = slices.DeleteFunc(violations, func(fv apperr.FieldViolation) bool {
+			return !slices.Contains(fields, fv.Field)
+		})
+	}
+	if len(violations) > 0 {
+		return apperr.Invalid(violations)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction of uow and returns its result once the
+// transaction has committed.
+func inTx[T any](ctx context.Context, uow *unitOfWork, fn func(*uowTx) (T, error)) (T, error) {
+	var v T
+	err := uow.run(ctx, func(tx *uowTx) error {
+		var err error
+		v, err = fn(tx)
+		return err
+	})
+	return v, err
+}
+
+// record records a mutation in tx and returns the resource after it. The
+// resource is re-read with load, which must read through tx, to capture
+// server-set fields; pass a nil load for deletes.
+func record[T any](tx *uowTx, resource string, id uuid.UUID, action string, before *T, load func(uuid.UUID) (*T, error)) (*T, error) {
+	var after *T
+	if load != nil {
+		var err error
+		if after, err = load(id); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Record(resource, id, action, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
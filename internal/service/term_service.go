@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type TermService struct {
+	tr     *repository.TermRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewTermService(db *sql.DB, logger *slog.Logger) *TermService {
+	return &TermService{tr: repository.NewTermRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "term")}
+}
+
+// termDates reports dates of term that are out of order: a term must end on
+// or after its start, and registration must close after it opens and before
+// the term ends. Dates that do not parse are left to request validation.
+func termDates(term *model.Term) []apperr.FieldViolation {
+	parse := func(s string) (time.Time, bool) {
+		t, err := time.Parse(time.DateOnly, s)
+		return t, err == nil
+	}
+	start, okStart := parse(term.StartDate)
+	end, okEnd := parse(term.EndDate)
+	opens, okOpens := parse(term.RegistrationOpens)
+	closes, okCloses := parse(term.RegistrationCloses)
+
+	var violations []apperr.FieldViolation
+	if okStart && okEnd && end.Before(start) {
+		violations = append(violations, apperr.FieldViolation{Field: "end_date", Message: "must not be before start_date"})
+	}
+	if okOpens && okCloses && closes.Before(opens) {
+		violations = append(violations, apperr.FieldViolation{Field: "registration_closes", Message: "must not be before registration_opens"})
+	}
+	if okEnd && okCloses && closes.After(end) {
+		violations = append(violations, apperr.FieldViolation{Field: "registration_closes", Message: "must not be after end_date"})
+	}
+	return violations
+}
+
+// GetAllTerms lists the terms, only those with status if it is not empty.
+func (ts *TermService) GetAllTerms(status string) ([]model.Term, error) {
+	return ts.tr.GetAllTerms(status)
+}
+
+func (ts *TermService) GetTermByID(id uuid.UUID) (*model.Term, error) {
+	return ts.tr.GetTermByID(id)
+}
+
+// CreateTerm validates and stores term, and returns it as stored.
+func (ts *TermService) CreateTerm(ctx context.Context, term *model.Term) (*model.Term, error) {
+	checkDates := func() ([]apperr.FieldViolation, error) { return termDates(term), nil }
+	if err := Validate(term, nil, checkDates); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, ts.uow, func(tx *uowTx) (*model.Term, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		if err := tr.CreateTerm(term); err != nil {
+			return nil, err
+		}
+		return record(tx, "term", term.ID, audit.ActionCreate, nil, tr.GetTermByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.logger.InfoContext(ctx, "term created", "term_id", term.ID)
+	return created, nil
+}
+
+// UpdateTerm replaces the term with id and returns it as stored.
+func (ts *TermService) UpdateTerm(ctx context.Context, id uuid.UUID, term *model.Term, ifMatch []int64) (*model.Term, error) {
+	checkDates := func() ([]apperr.FieldViolation, error) { return termDates(term), nil }
+	if err := Validate(term, nil, checkDates); err != nil {
+		return nil, err
+	}
+	after, err := ts.update(ctx, id, func(tr *repository.TermRepository) error {
+		return tr.UpdateTerm(id, term, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.logger.InfoContext(ctx, "term updated", "term_id", id)
+	return after, nil
+}
+
+// PatchTerm stores the patched fields of term, which holds the term with id
+// after a merge patch, and returns it as stored. Only violations of fields
+// are reported, except that the order of the dates is checked across all of
+// them, since moving one date can put an unpatched one out of order.
+func (ts *TermService) PatchTerm(ctx context.Context, id uuid.UUID, term *model.Term, fields []string, ifMatch []int64) (*model.Term, error) {
+	if err := Validate(term, fields, nil); err != nil {
+		return nil, err
+	}
+	if violations := termDates(term); len(violations) > 0 {
+		return nil, apperr.Invalid(violations)
+	}
+	after, err := ts.update(ctx, id, func(tr *repository.TermRepository) error {
+		return tr.PatchTerm(id, term, fields, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.logger.InfoContext(ctx, "term patched", "term_id", id, "fields", fields)
+	return after, nil
+}
+
+// update runs write on the term with id and records the change in one
+// transaction.
+func (ts *TermService) update(ctx context.Context, id uuid.UUID, write func(*repository.TermRepository) error) (*model.Term, error) {
+	return inTx(ctx, ts.uow, func(tx *uowTx) (*model.Term, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		before, err := tr.GetTermByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(tr); err != nil {
+			return nil, err
+		}
+		return record(tx, "term", id, audit.ActionUpdate, before, tr.GetTermByID)
+	})
+}
+
+func (ts *TermService) DeleteTerm(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, ts.uow, func(tx *uowTx) (*model.Term, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		before, err := tr.GetTermByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := tr.DeleteTerm(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "term", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ts.logger.InfoContext(ctx, "term deleted", "term_id", id)
+	return nil
+}
+
+// RestoreTerm undeletes the term with id and returns its new version.
+func (ts *TermService) RestoreTerm(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, ts.uow, func(tx *uowTx) (int64, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		version, err := tr.RestoreTerm(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "term", id, audit.ActionRestore, nil, tr.GetTermByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	ts.logger.InfoContext(ctx, "term restored", "term_id", id)
+	return version, nil
+}
@@ -1,19 +1,44 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/events"
+	"api-server/internal/metrics"
 	"api-server/internal/model"
 	"api-server/internal/repository"
-	"database/sql"
 
+	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// ErrStorage reports that an uploaded file could not be stored in the
+// bucket.
+var ErrStorage = errors.New("failed to store the uploaded file")
+
 type TraceService struct {
-	tr *repository.TraceRepository
+	tr     *repository.TraceRepository
+	client *storage.Client
+	bucket string
+	uow    *unitOfWork
+	logger *slog.Logger
 }
 
-func NewTraceService(db *sql.DB) *TraceService {
-	return &TraceService{tr: repository.NewTraceRepository(db)}
+// NewTraceService returns a trace service storing uploaded files in bucket
+// with client.
+func NewTraceService(db *sql.DB, client *storage.Client, bucket string, logger *slog.Logger) *TraceService {
+	return &TraceService{tr: repository.NewTraceRepository(db), client: client, bucket: bucket, uow: newUnitOfWork(db, logger), logger: logger.With("service", "trace")}
 }
 
 func (ts *TraceService) GetAllTraces() ([]model.Trace, error) {
@@ -24,14 +49,190 @@ func (ts *TraceService) GetTraceByID(id uuid.UUID) (*model.Trace, error) {
 	return ts.tr.GetTraceByID(id)
 }
 
-func (ts *TraceService) CreateTrace(trace *model.Trace) error {
-	return ts.tr.CreateTrace(trace)
+// StreamTraces calls fn for each trace, reading them from one consistent
+// snapshot.
+func (ts *TraceService) StreamTraces(ctx context.Context, fn func(*model.Trace) error) error {
+	return ts.tr.StreamTraces(ctx, fn)
+}
+
+// UploadTrace stores the PDF read from file in the bucket and records it as
+// a trace of trace.UserID named trace.FileName, waiting to be processed. On
+// success trace holds the stored record.
+func (ts *TraceService) UploadTrace(ctx context.Context, trace *model.Trace, contentType string, file io.Reader) error {
+	ctx, span := otel.Tracer("api-server").Start(ctx, "UploadTrace")
+	defer span.End()
+	span.SetAttributes(attribute.String("file.name", trace.FileName))
+
+	err := ts.uploadTrace(ctx, trace, contentType, file)
+	switch {
+	case errors.Is(err, apperr.ErrValidation):
+		metrics.TraceUploads.WithLabelValues(metrics.UploadRejected).Inc()
+	case err != nil:
+		metrics.TraceUploads.WithLabelValues(metrics.UploadFailed).Inc()
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (ts *TraceService) uploadTrace(ctx context.Context, trace *model.Trace, contentType string, file io.Reader) error {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return ts.tr.CheckReferences(trace) }
+	if err := Validate(trace, []string{"user_id", "file_name"}, checkRefs); err != nil {
+		return err
+	}
+
+	objectName := uuid.New().String() + filepath.Ext(trace.FileName)
+	ts.logger.InfoContext(ctx, "uploading trace to GCS", "bucket", ts.bucket, "object", objectName)
+	attrs, err := ts.store(ctx, objectName, contentType, file)
+	if err != nil {
+		ts.logger.ErrorContext(ctx, "failed to upload file to GCS", "bucket", ts.bucket, "object", objectName, "error", err)
+		return fmt.Errorf("%w: %w", ErrStorage, err)
+	}
+	ts.logger.DebugContext(ctx, "completed upload to GCS", "bucket", ts.bucket, "object", objectName, "size", attrs.Size, "content_type", attrs.ContentType)
+
+	trace.ID = uuid.New()
+	trace.BucketPath = fmt.Sprintf("gs://%s/%s", ts.bucket, objectName)
+	uploaded, err := events.New(ctx, "trace.uploaded", trace.ID.String(), map[string]any{
+		"id":           trace.ID,
+		"user_id":      trace.UserID,
+		"file_name":    trace.FileName,
+		"bucket_path":  trace.BucketPath,
+		"size":         attrs.Size,
+		"content_type": attrs.ContentType,
+	})
+	if err == nil {
+		dbCtx, dbSpan := otel.Tracer("api-server").Start(ctx, "CreateTraceDB")
+		err = ts.uow.run(dbCtx, func(tx *uowTx) error {
+			tr := ts.tr.WithTx(tx.Tx)
+			if err := tr.CreateTrace(trace, uploaded); err != nil {
+				return err
+			}
+			_, err := record(tx, "trace", trace.ID, audit.ActionCreate, nil, tr.GetTraceByID)
+			return err
+		})
+		dbSpan.End()
+	}
+	if err != nil {
+		// Do not leave an object no trace points at.
+		if derr := ts.client.Bucket(ts.bucket).Object(objectName).Delete(context.WithoutCancel(ctx)); derr != nil {
+			ts.logger.WarnContext(ctx, "failed to remove orphaned trace object", "bucket", ts.bucket, "object", objectName, "error", derr)
+		}
+		return err
+	}
+
+	metrics.TraceUploads.WithLabelValues(metrics.UploadSuccess).Inc()
+	metrics.TraceUploadBytes.Observe(float64(attrs.Size))
+	ts.logger.InfoContext(ctx, "trace created", "trace_record_id", trace.ID, "user_id", trace.UserID)
+	return nil
+}
+
+// store copies file to the object named objectName and returns its
+// attributes. The object is discarded if ctx is cancelled first.
+func (ts *TraceService) store(ctx context.Context, objectName, contentType string, file io.Reader) (*storage.ObjectAttrs, error) {
+	ctx, span := otel.Tracer("api-server").Start(ctx, "UploadToGCS")
+	defer span.End()
+
+	wc := ts.client.Bucket(ts.bucket).Object(objectName).NewWriter(ctx)
+	wc.ContentType = contentType
+	if _, err := io.Copy(wc, file); err != nil {
+		wc.CloseWithError(err)
+		span.RecordError(err)
+		return nil, err
+	}
+	if err := wc.Close(); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("file.size", wc.Attrs().Size))
+	return wc.Attrs(), nil
+}
+
+// UpdateTrace replaces the trace with id and returns it as stored.
+func (ts *TraceService) UpdateTrace(ctx context.Context, id uuid.UUID, trace *model.Trace, ifMatch []int64) (*model.Trace, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return ts.tr.CheckReferences(trace) }
+	if err := Validate(trace, nil, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := ts.update(ctx, id, func(tr *repository.TraceRepository) error {
+		return tr.UpdateTrace(id, trace, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.logger.InfoContext(ctx, "trace updated", "trace_record_id", id)
+	return after, nil
+}
+
+// PatchTrace stores the patched fields of trace, which holds the trace with
+// id after a merge patch, and returns it as stored. Only violations of
+// fields are reported.
+func (ts *TraceService) PatchTrace(ctx context.Context, id uuid.UUID, trace *model.Trace, fields []string, ifMatch []int64) (*model.Trace, error) {
+	checkRefs := func() ([]apperr.FieldViolation, error) { return ts.tr.CheckReferences(trace) }
+	if err := Validate(trace, fields, checkRefs); err != nil {
+		return nil, err
+	}
+	after, err := ts.update(ctx, id, func(tr *repository.TraceRepository) error {
+		return tr.PatchTrace(id, trace, fields, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts.logger.InfoContext(ctx, "trace patched", "trace_record_id", id, "fields", fields)
+	return after, nil
+}
+
+// update runs write on the trace with id and records the change in one
+// transaction.
+func (ts *TraceService) update(ctx context.Context, id uuid.UUID, write func(*repository.TraceRepository) error) (*model.Trace, error) {
+	return inTx(ctx, ts.uow, func(tx *uowTx) (*model.Trace, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		before, err := tr.GetTraceByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(tr); err != nil {
+			return nil, err
+		}
+		return record(tx, "trace", id, audit.ActionUpdate, before, tr.GetTraceByID)
+	})
 }
 
-func (ts *TraceService) UpdateTrace(id uuid.UUID, trace *model.Trace) error {
-	return ts.tr.UpdateTrace(id, trace)
+func (ts *TraceService) DeleteTrace(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, ts.uow, func(tx *uowTx) (*model.Trace, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		before, err := tr.GetTraceByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := tr.DeleteTrace(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "trace", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ts.logger.InfoContext(ctx, "trace deleted", "trace_record_id", id)
+	return nil
 }
 
-func (ts *TraceService) DeleteTrace(id uuid.UUID) error {
-	return ts.tr.DeleteTrace(id)
+// RestoreTrace undeletes the trace with id and returns its new version.
+func (ts *TraceService) RestoreTrace(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, ts.uow, func(tx *uowTx) (int64, error) {
+		tr := ts.tr.WithTx(tx.Tx)
+		version, err := tr.RestoreTrace(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "trace", id, audit.ActionRestore, nil, tr.GetTraceByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	ts.logger.InfoContext(ctx, "trace restored", "trace_record_id", id)
+	return version, nil
 }
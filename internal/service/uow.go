@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"api-server/internal/audit"
+	"api-server/internal/events"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// webhookResources are the resources whose mutations are delivered to
+// webhooks. Mutations of every resource are published as domain events.
+var webhookResources = []string{"course", "instructor", "user", "trace"}
+
+// eventSuffixes maps audit actions to the suffix of their event types.
+var eventSuffixes = map[string]string{
+	audit.ActionCreate:  "created",
+	audit.ActionUpdate:  "updated",
+	audit.ActionDelete:  "deleted",
+	audit.ActionRestore: "restored",
+}
+
+// eventType returns the event type of action on resource, such as
+// course.created.
+func eventType(resource, action string) string {
+	return resource + "." + eventSuffixes[action]
+}
+
+// webhookEventTypes lists the event types delivered to webhooks.
+func webhookEventTypes() []string {
+	var types []string
+	for _, resource := range webhookResources {
+		for _, action := range []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete, audit.ActionRestore} {
+			types = append(types, eventType(resource, action))
+		}
+	}
+	return types
+}
+
+// unitOfWork runs mutations in transactions that also carry their side
+// effects: the audit event, the deliveries to the webhooks subscribed to it
+// and the domain event in the outbox. None of them is committed without the
+// others.
+type unitOfWork struct {
+	db     *sql.DB
+	audit  *audit.Recorder
+	wr     *repository.WebhookRepository
+	or     *repository.OutboxRepository
+	logger *slog.Logger
+}
+
+func newUnitOfWork(db *sql.DB, logger *slog.Logger) *unitOfWork {
+	return &unitOfWork{db: db, audit: audit.NewRecorder(db), wr: repository.NewWebhookRepository(db), or: repository.NewOutboxRepository(db), logger: logger}
+}
+
+// uowTx is the transaction of a unit of work. Repositories join it through
+// their WithTx methods.
+type uowTx struct {
+	*sql.Tx
+	ctx context.Context
+	uow *unitOfWork
+}
+
+// run runs fn in a new transaction and commits it if fn succeeds. Events are
+// recorded on behalf of the actor of ctx. The transaction is not cancelled
+// with ctx, so that a client going away cannot interrupt a mutation halfway.
+func (uow *unitOfWork) run(ctx context.Context, fn func(*uowTx) error) error {
+	sqlTx, err := uow.db.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	if err := fn(&uowTx{Tx: sqlTx, ctx: ctx, uow: uow}); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// Record stores the audit event for a mutation of resource id in the
+// transaction, with its webhook deliveries and domain event. before and
+// after are the resource as returned by the API, nil where it did not
+// exist. An error means the events could not be stored, and the transaction
+// must not be committed.
+func (tx *uowTx) Record(resource string, id uuid.UUID, action string, before, after any) error {
+	ctx := context.WithoutCancel(tx.ctx)
+	event, err := tx.uow.audit.WithTx(tx.Tx).Record(ctx, resource, id, action, before, after)
+	if err != nil {
+		return fmt.Errorf("write audit event: %w", err)
+	}
+	return tx.publish(ctx, event, before, after)
+}
+
+// publish queues event for the webhooks subscribed to its type and writes
+// it to the event outbox, under the same event ID.
+func (tx *uowTx) publish(ctx context.Context, event *model.AuditEvent, before, after any) error {
+	if _, ok := eventSuffixes[event.Action]; !ok {
+		return nil
+	}
+	typ := eventType(event.Resource, event.Action)
+	data := audit.Fields(after)
+	if data == nil {
+		data = audit.Fields(before)
+	}
+	id := uuid.New()
+
+	if slices.Contains(webhookResources, event.Resource) {
+		webhookEvent := &model.WebhookEvent{
+			ID:         id,
+			Type:       typ,
+			OccurredAt: event.OccurredAt.UTC(),
+			Resource:   event.Resource,
+			ResourceID: event.ResourceID,
+			Actor:      event.Actor,
+			RequestID:  event.RequestID,
+			Data:       data,
+			Changes:    event.Changes,
+		}
+		n, err := tx.uow.wr.WithTx(tx.Tx).EnqueueWebhookEvent(ctx, webhookEvent)
+		if err != nil {
+			return fmt.Errorf("queue webhook event: %w", err)
+		}
+		if n > 0 {
+			tx.uow.logger.DebugContext(ctx, "queued webhook event", "event_type", typ, "event_id", id, "deliveries", n)
+		}
+	}
+
+	domainEvent, err := events.NewWithID(ctx, id, typ, event.ResourceID.String(), data)
+	if err != nil {
+		return fmt.Errorf("build domain event: %w", err)
+	}
+	if err := tx.uow.or.WithTx(tx.Tx).AppendEvents(ctx, domainEvent); err != nil {
+		return fmt.Errorf("write domain event: %w", err)
+	}
+	return nil
+}
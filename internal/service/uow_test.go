@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"api-server/internal/actor"
+	"api-server/internal/audit"
+	"api-server/internal/logging"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+type term struct {
+	Name string `json:"name"`
+}
+
+func TestRunRecordsInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	id := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE api.term").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO api.audit_log").
+		WithArgs("registrar", "term", id, audit.ActionUpdate, sqlmock.AnyArg(), "", "203.0.113.7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "occurred_at"}).AddRow(1, time.Now()))
+	mock.ExpectExec("INSERT INTO api.event_outbox").
+		WithArgs(sqlmock.AnyArg(), "term.updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	uow := newUnitOfWork(db, logging.Discard())
+	ctx := audit.WithSourceIP(actor.NewContext(context.Background(), "registrar"), "203.0.113.7")
+	err = uow.run(ctx, func(tx *uowTx) error {
+		if _, err := tx.Exec("UPDATE api.term SET name = 'Fall 2025'"); err != nil {
+			return err
+		}
+		return tx.Record("term", id, audit.ActionUpdate, &term{Name: "Fall"}, &term{Name: "Fall 2025"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunRollsBackWhenAuditFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE api.term").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO api.audit_log").WillReturnError(errors.New("audit_log is full"))
+	mock.ExpectRollback()
+
+	uow := newUnitOfWork(db, logging.Discard())
+	err = uow.run(context.Background(), func(tx *uowTx) error {
+		if _, err := tx.Exec("UPDATE api.term SET name = 'Fall 2025'"); err != nil {
+			return err
+		}
+		return tx.Record("term", uuid.New(), audit.ActionUpdate, &term{Name: "Fall"}, &term{Name: "Fall 2025"})
+	})
+	if err == nil {
+		t.Fatal("run succeeded although the audit event could not be written")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunPublishesInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO api.audit_log").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "occurred_at"}).AddRow(1, time.Now()))
+	mock.ExpectExec("INSERT INTO api.webhook_delivery").
+		WithArgs(sqlmock.AnyArg(), "course.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO api.event_outbox").
+		WithArgs(sqlmock.AnyArg(), "course.created", sqlmock.AnyArg()).
+		WillReturnError(errors.New("event_outbox is full"))
+	mock.ExpectRollback()
+
+	uow := newUnitOfWork(db, logging.Discard())
+	err = uow.run(context.Background(), func(tx *uowTx) error {
+		return tx.Record("course", uuid.New(), audit.ActionCreate, nil, &term{Name: "CSYE 7125"})
+	})
+	if err == nil {
+		t.Fatal("run succeeded although the domain event could not be written")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,19 +1,25 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"api-server/internal/audit"
 	"api-server/internal/model"
 	"api-server/internal/repository"
-	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
-	ur *repository.UserRepository
+	ur     *repository.UserRepository
+	uow    *unitOfWork
+	logger *slog.Logger
 }
 
-func NewUserService(db *sql.DB) *UserService {
-	return &UserService{ur: repository.NewUserRepository(db)}
+func NewUserService(db *sql.DB, logger *slog.Logger) *UserService {
+	return &UserService{ur: repository.NewUserRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "user")}
 }
 
 func (us *UserService) GetAllUsers() ([]model.User, error) {
@@ -24,14 +30,106 @@ func (us *UserService) GetUserByID(id uuid.UUID) (*model.User, error) {
 	return us.ur.GetUserByID(id)
 }
 
-func (us *UserService) CreateUser(user *model.User) error {
-	return us.ur.CreateUser(user)
+// CreateUser validates and stores user, and returns it as stored.
+func (us *UserService) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	if err := Validate(user, nil, nil); err != nil {
+		return nil, err
+	}
+	created, err := inTx(ctx, us.uow, func(tx *uowTx) (*model.User, error) {
+		ur := us.ur.WithTx(tx.Tx)
+		if err := ur.CreateUser(user); err != nil {
+			return nil, err
+		}
+		return record(tx, "user", user.ID, audit.ActionCreate, nil, ur.GetUserByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	us.logger.InfoContext(ctx, "user created", "user_id", user.ID)
+	return created, nil
+}
+
+// UpdateUser replaces the user with id and returns it as stored.
+func (us *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user *model.User, ifMatch []int64) (*model.User, error) {
+	if err := Validate(user, nil, nil); err != nil {
+		return nil, err
+	}
+	after, err := us.update(ctx, id, func(ur *repository.UserRepository) error {
+		return ur.UpdateUser(id, user, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	us.logger.InfoContext(ctx, "user updated", "user_id", id)
+	return after, nil
+}
+
+// PatchUser stores the patched fields of user, which holds the user with
+// id after a merge patch, and returns it as stored. Only violations of
+// fields are reported.
+func (us *UserService) PatchUser(ctx context.Context, id uuid.UUID, user *model.User, fields []string, ifMatch []int64) (*model.User, error) {
+	if err := Validate(user, fields, nil); err != nil {
+		return nil, err
+	}
+	after, err := us.update(ctx, id, func(ur *repository.UserRepository) error {
+		return ur.PatchUser(id, user, fields, ifMatch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	us.logger.InfoContext(ctx, "user patched", "user_id", id, "fields", fields)
+	return after, nil
+}
+
+// update runs write on the user with id and records the change in one
+// transaction.
+func (us *UserService) update(ctx context.Context, id uuid.UUID, write func(*repository.UserRepository) error) (*model.User, error) {
+	return inTx(ctx, us.uow, func(tx *uowTx) (*model.User, error) {
+		ur := us.ur.WithTx(tx.Tx)
+		before, err := ur.GetUserByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := write(ur); err != nil {
+			return nil, err
+		}
+		return record(tx, "user", id, audit.ActionUpdate, before, ur.GetUserByID)
+	})
 }
 
-func (us *UserService) UpdateUser(id uuid.UUID, user *model.User) error {
-	return us.ur.UpdateUser(id, user)
+func (us *UserService) DeleteUser(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, us.uow, func(tx *uowTx) (*model.User, error) {
+		ur := us.ur.WithTx(tx.Tx)
+		before, err := ur.GetUserByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := ur.DeleteUser(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "user", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	us.logger.InfoContext(ctx, "user deleted", "user_id", id)
+	return nil
 }
 
-func (us *UserService) DeleteUser(id uuid.UUID) error {
-	return us.ur.DeleteUser(id)
+// RestoreUser undeletes the user with id and returns its new version.
+func (us *UserService) RestoreUser(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, us.uow, func(tx *uowTx) (int64, error) {
+		ur := us.ur.WithTx(tx.Tx)
+		version, err := ur.RestoreUser(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "user", id, audit.ActionRestore, nil, ur.GetUserByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	us.logger.InfoContext(ctx, "user restored", "user_id", id)
+	return version, nil
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"api-server/internal/apperr"
+	"api-server/internal/audit"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+	"api-server/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+type WebhookService struct {
+	wr     *repository.WebhookRepository
+	uow    *unitOfWork
+	logger *slog.Logger
+}
+
+func NewWebhookService(db *sql.DB, logger *slog.Logger) *WebhookService {
+	return &WebhookService{wr: repository.NewWebhookRepository(db), uow: newUnitOfWork(db, logger), logger: logger.With("service", "webhook")}
+}
+
+// webhookRules reports unknown event types and an emptied secret.
+func webhookRules(wh *model.Webhook, fields []string) []apperr.FieldViolation {
+	var violations []apperr.FieldViolation
+	types := webhookEventTypes()
+	for _, t := range wh.EventTypes {
+		if !slices.Contains(types, t) {
+			violations = append(violations, apperr.FieldViolation{Field: "event_types", Message: "must only contain " + strings.Join(types, ", ")})
+			break
+		}
+	}
+	if wh.Secret == "" && slices.Contains(fields, "secret") {
+		violations = append(violations, apperr.FieldViolation{Field: "secret", Message: "is required"})
+	}
+	return violations
+}
+
+func (ws *WebhookService) GetAllWebhooks() ([]model.Webhook, error) {
+	return ws.wr.GetAllWebhooks()
+}
+
+func (ws *WebhookService) GetWebhookByID(id uuid.UUID) (*model.Webhook, error) {
+	return ws.wr.GetWebhookByID(id)
+}
+
+// CreateWebhook validates and stores hook, generating a secret unless it
+// has one, and returns it as stored with its secret.
+func (ws *WebhookService) CreateWebhook(ctx context.Context, hook *model.Webhook) (*model.Webhook, error) {
+	checkRules := func() ([]apperr.FieldViolation, error) { return webhookRules(hook, nil), nil }
+	if err := Validate(hook, nil, checkRules); err != nil {
+		return nil, err
+	}
+	if hook.EventTypes == nil {
+		hook.EventTypes = []string{}
+	}
+	if hook.Secret == "" {
+		hook.Secret = webhook.NewSecret()
+	}
+	created, err := inTx(ctx, ws.uow, func(tx *uowTx) (*model.Webhook, error) {
+		wr := ws.wr.WithTx(tx.Tx)
+		if err := wr.CreateWebhook(hook); err != nil {
+			return nil, err
+		}
+		return record(tx, "webhook", hook.ID, audit.ActionCreate, nil, wr.GetWebhookByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ws.logger.InfoContext(ctx, "webhook created", "webhook_id", hook.ID)
+	created.Secret = hook.Secret
+	return created, nil
+}
+
+// PatchWebhook stores the patched fields of hook, which holds the webhook
+// with id after a merge patch, and returns it as stored. Only violations of
+// fields are reported.
+func (ws *WebhookService) PatchWebhook(ctx context.Context, id uuid.UUID, hook *model.Webhook, fields []string, ifMatch []int64) (*model.Webhook, error) {
+	checkRules := func() ([]apperr.FieldViolation, error) { return webhookRules(hook, fields), nil }
+	if err := Validate(hook, fields, checkRules); err != nil {
+		return nil, err
+	}
+	if hook.EventTypes == nil {
+		hook.EventTypes = []string{}
+	}
+	after, err := inTx(ctx, ws.uow, func(tx *uowTx) (*model.Webhook, error) {
+		wr := ws.wr.WithTx(tx.Tx)
+		before, err := wr.GetWebhookByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := wr.PatchWebhook(id, hook, fields, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "webhook", id, audit.ActionUpdate, before, wr.GetWebhookByID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ws.logger.InfoContext(ctx, "webhook patched", "webhook_id", id, "fields", fields)
+	return after, nil
+}
+
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID, ifMatch []int64) error {
+	_, err := inTx(ctx, ws.uow, func(tx *uowTx) (*model.Webhook, error) {
+		wr := ws.wr.WithTx(tx.Tx)
+		before, err := wr.GetWebhookByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := wr.DeleteWebhook(id, ifMatch); err != nil {
+			return nil, err
+		}
+		return record(tx, "webhook", id, audit.ActionDelete, before, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ws.logger.InfoContext(ctx, "webhook deleted", "webhook_id", id)
+	return nil
+}
+
+// RestoreWebhook undeletes the webhook with id and returns its new version.
+func (ws *WebhookService) RestoreWebhook(ctx context.Context, id uuid.UUID, ifMatch []int64) (int64, error) {
+	version, err := inTx(ctx, ws.uow, func(tx *uowTx) (int64, error) {
+		wr := ws.wr.WithTx(tx.Tx)
+		version, err := wr.RestoreWebhook(id, ifMatch)
+		if err != nil {
+			return 0, err
+		}
+		_, err = record(tx, "webhook", id, audit.ActionRestore, nil, wr.GetWebhookByID)
+		return version, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	ws.logger.InfoContext(ctx, "webhook restored", "webhook_id", id)
+	return version, nil
+}
+
+// ListWebhookDeliveries lists the deliveries of a webhook matching filter,
+// newest first.
+func (ws *WebhookService) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter model.WebhookDeliveryFilter) ([]model.WebhookDelivery, error) {
+	return ws.wr.ListWebhookDeliveries(ctx, webhookID, filter)
+}
+
+// GetWebhookDelivery returns a delivery with the log of its attempts.
+func (ws *WebhookService) GetWebhookDelivery(ctx context.Context, webhookID uuid.UUID, id int64) (*model.WebhookDelivery, error) {
+	return ws.wr.GetWebhookDelivery(ctx, webhookID, id)
+}
+
+// RedeliverWebhookDelivery queues a succeeded or failed delivery to be sent
+// again with its original payload and delivery ID.
+func (ws *WebhookService) RedeliverWebhookDelivery(ctx context.Context, webhookID uuid.UUID, id int64) error {
+	if err := ws.wr.RedeliverWebhookDelivery(ctx, webhookID, id); err != nil {
+		return err
+	}
+	ws.logger.InfoContext(ctx, "webhook delivery queued for redelivery", "webhook_id", webhookID, "delivery_id", id)
+	return nil
+}
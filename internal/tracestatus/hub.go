@@ -0,0 +1,145 @@
+// Package tracestatus follows the processing status of traces across
+// replicas.
+//
+// Every status change is announced with PostgreSQL NOTIFY on
+// repository.TraceStatusChannel in the transaction that makes it. Each
+// replica runs a Hub that LISTENs on the channel and passes announcements to
+// the streams it serves, so a client may follow a trace from any replica.
+// NOTIFY does not queue announcements for a disconnected listener: when the
+// Hub reconnects it ends every subscription, and clients reconnect and
+// resynchronise from the database.
+package tracestatus
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"api-server/internal/model"
+	"api-server/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// pingInterval is how often an idle listener connection is checked.
+	pingInterval = 90 * time.Second
+	// bufferSize is how many announcements a subscriber may fall behind
+	// before it is dropped.
+	bufferSize = 16
+)
+
+// Hub fans trace status announcements out to subscribers.
+type Hub struct {
+	listener *pq.Listener
+	logger   *slog.Logger
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	c     chan model.TraceStatusEvent
+	match func(*model.TraceStatusEvent) bool
+}
+
+// NewHub returns a hub listening through its own connection to the
+// database at connStr. It starts listening when Run is called.
+func NewHub(connStr string, logger *slog.Logger) *Hub {
+	logger = logger.With("component", "trace_status")
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logger.Warn("trace status listener disconnected", "error", err)
+		case pq.ListenerEventConnectionAttemptFailed:
+			logger.Warn("trace status listener failed to connect", "error", err)
+		case pq.ListenerEventReconnected:
+			logger.Info("trace status listener reconnected")
+		}
+	})
+	return &Hub{listener: listener, logger: logger, subs: map[*subscription]struct{}{}}
+}
+
+// Run passes announcements to subscribers until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) {
+	go func() {
+		if err := h.listener.Listen(repository.TraceStatusChannel); err != nil {
+			h.logger.Error("failed to listen for trace status changes", "error", err)
+		}
+	}()
+	defer h.listener.Close()
+	defer h.dropAll()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-h.listener.Notify:
+			if n == nil {
+				// Announcements made while disconnected are lost.
+				h.dropAll()
+				continue
+			}
+			var status model.TraceStatusEvent
+			if err := json.Unmarshal([]byte(n.Extra), &status); err != nil {
+				h.logger.Error("invalid trace status announcement", "payload", n.Extra, "error", err)
+				continue
+			}
+			h.broadcast(&status)
+		case <-ticker.C:
+			go h.listener.Ping()
+		}
+	}
+}
+
+// Subscribe returns a channel of the announcements that match accepts and a
+// function that ends the subscription. The channel is closed when the
+// subscription ends, including when the hub drops it because the subscriber
+// fell behind or announcements may have been lost.
+func (h *Hub) Subscribe(match func(*model.TraceStatusEvent) bool) (<-chan model.TraceStatusEvent, func()) {
+	sub := &subscription{c: make(chan model.TraceStatusEvent, bufferSize), match: match}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub.c, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.drop(sub)
+	}
+}
+
+func (h *Hub) broadcast(status *model.TraceStatusEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.match(status) {
+			continue
+		}
+		select {
+		case sub.c <- *status:
+		default:
+			h.logger.Warn("dropping slow trace status subscriber", "trace_record_id", status.TraceID)
+			h.drop(sub)
+		}
+	}
+}
+
+func (h *Hub) dropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+// drop ends sub if it has not ended yet. h.mu must be held.
+func (h *Hub) drop(sub *subscription) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.c)
+	}
+}
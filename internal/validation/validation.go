@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"api-server/internal/apperr"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// SemesterTerms are the values accepted for a course semester term.
+var SemesterTerms = []string{"Spring", "Summer", "Fall", "Winter"}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON names so clients can match them to the body.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterValidation("semester_term", func(fl validator.FieldLevel) bool {
+		for _, term := range SemesterTerms {
+			if fl.Field().String() == term {
+				return true
+			}
+		}
+		return false
+	})
+	return v
+}
+
+// Struct checks v against its validate tags and returns one violation per
+// failing field, in field order. It returns nil if v is valid.
+func Struct(v any) []apperr.FieldViolation {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		// Only returned for programming errors such as passing a non-struct.
+		panic(err)
+	}
+
+	violations := make([]apperr.FieldViolation, 0, len(errs))
+	for _, fe := range errs {
+		violations = append(violations, apperr.FieldViolation{Field: fe.Field(), Message: message(fe)})
+	}
+	return violations
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "semester_term":
+		return "must be one of " + strings.Join(SemesterTerms, ", ")
+	case "min", "gte":
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		case reflect.Slice:
+			return fmt.Sprintf("must have at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		switch fe.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		case reflect.Slice:
+			return fmt.Sprintf("must have at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "unique":
+		return "must not contain duplicates"
+	case "endswith":
+		return fmt.Sprintf("must end with %q", fe.Param())
+	case "startswith":
+		return fmt.Sprintf("must start with %q", fe.Param())
+	case "datetime":
+		switch fe.Param() {
+		case "2006-01-02":
+			return "must be a date in YYYY-MM-DD format"
+		case "15:04":
+			return "must be a time in HH:MM format"
+		}
+		return fmt.Sprintf("must be a time in the layout %q", fe.Param())
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,266 @@
+// Package webhook delivers resource lifecycle events to webhook
+// subscriptions.
+//
+// Events are queued in api.webhook_delivery by the service layer, in the
+// transaction of the change they describe, and sent by a Dispatcher as a
+// JSON POST. Each request carries these headers:
+//
+//	X-Webhook-ID         the delivery ID, stable across retries
+//	X-Webhook-Event      the event type, such as course.updated
+//	X-Webhook-Timestamp  Unix seconds when the request was signed
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The HMAC key is the subscription's secret. Receivers should recompute the
+// signature, compare it in constant time and reject stale timestamps. Any
+// 2xx response acknowledges a delivery; anything else is retried with
+// exponential backoff until MaxAttempts have failed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	mathrand "math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"api-server/internal/metrics"
+	"api-server/internal/model"
+	"api-server/internal/repository"
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Retry schedule: the nth failed attempt is retried after baseDelay * 2^(n-1),
+// at most maxDelay, plus up to 10% jitter.
+const (
+	baseDelay = 30 * time.Second
+	maxDelay  = 6 * time.Hour
+)
+
+const (
+	// batchSize is the number of deliveries claimed at a time.
+	batchSize = 20
+	// maxLoggedBody is how much of a response body the delivery log keeps.
+	maxLoggedBody = 1024
+)
+
+// Config controls how often the dispatcher looks for due deliveries, how long
+// it waits for a receiver and how often it tries a delivery. An Interval of
+// zero disables the dispatcher.
+type Config struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+}
+
+// LoadConfig reads WEBHOOK_POLL_INTERVAL (default 5s) and WEBHOOK_TIMEOUT
+// (default 10s) as Go durations and WEBHOOK_MAX_ATTEMPTS (default 10).
+func LoadConfig() (Config, error) {
+	cfg := Config{Interval: 5 * time.Second, Timeout: 10 * time.Second, MaxAttempts: 10}
+	for key, dst := range map[string]*time.Duration{
+		"WEBHOOK_POLL_INTERVAL": &cfg.Interval,
+		"WEBHOOK_TIMEOUT":       &cfg.Timeout,
+	} {
+		v := strings.TrimSpace(os.Getenv(key))
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("invalid %s %q: must be a non-negative duration such as 5s", key, v)
+		}
+		*dst = d
+	}
+	if cfg.Timeout == 0 {
+		return Config{}, fmt.Errorf("invalid WEBHOOK_TIMEOUT: must be positive")
+	}
+	if v := strings.TrimSpace(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS %q: must be a positive integer", v)
+		}
+		cfg.MaxAttempts = n
+	}
+	return cfg, nil
+}
+
+// NewSecret returns a random signing secret for a subscription.
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sign returns the X-Webhook-Signature of body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher sends queued deliveries. Replicas may run one each; a delivery
+// is claimed by one of them at a time.
+type Dispatcher struct {
+	wr     *repository.WebhookRepository
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+}
+
+// New returns a dispatcher of the deliveries queued in db.
+func New(db *sql.DB, cfg Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		wr:  repository.NewWebhookRepository(db),
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// A redirect is reported as the receiver's response, not followed.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger.With("job", "webhook"),
+	}
+}
+
+// Run sends due deliveries every Interval until ctx is cancelled. It returns
+// immediately if the dispatcher is disabled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.cfg.Interval == 0 {
+		return
+	}
+	d.logger.Info("webhook dispatcher started", "interval", d.cfg.Interval.String(), "max_attempts", d.cfg.MaxAttempts)
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			d.logger.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends every delivery that is due, a batch at a time.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	// A claimed delivery is left alone until every attempt in its batch
+	// could have timed out.
+	lease := 2 * batchSize * d.cfg.Timeout
+	for ctx.Err() == nil {
+		jobs, err := d.wr.ClaimWebhookDeliveries(ctx, batchSize, lease)
+		if err != nil {
+			return err
+		}
+		for i := range jobs {
+			d.deliver(ctx, &jobs[i])
+		}
+		if len(jobs) < batchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deliver makes one attempt to send job and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, job *model.WebhookJob) {
+	attempt := &model.WebhookAttempt{}
+	if job.Live {
+		d.send(ctx, job, attempt)
+	} else {
+		attempt.Error = "webhook is disabled or deleted"
+	}
+
+	status := model.DeliveryFailed
+	var retryIn time.Duration
+	switch {
+	case attempt.ResponseStatus != nil && *attempt.ResponseStatus/100 == 2:
+		status = model.DeliverySucceeded
+	case job.Live && job.Attempts+1 < d.cfg.MaxAttempts:
+		status = model.DeliveryPending
+		retryIn = backoff(job.Attempts + 1)
+	}
+	metrics.WebhookDeliveries.WithLabelValues(attemptResult(status)).Inc()
+
+	logger := d.logger.With("delivery_id", job.ID, "event_type", job.EventType, "attempt", job.Attempts+1)
+	switch status {
+	case model.DeliverySucceeded:
+		logger.DebugContext(ctx, "webhook delivered", "duration_ms", attempt.DurationMS)
+	case model.DeliveryPending:
+		logger.WarnContext(ctx, "webhook delivery failed, will retry", "retry_in", retryIn.String(), "response_status", attempt.ResponseStatus, "error", attempt.Error)
+	default:
+		logger.ErrorContext(ctx, "webhook delivery failed permanently", "response_status", attempt.ResponseStatus, "error", attempt.Error)
+	}
+
+	// Record the outcome even during shutdown, or the delivery is sent again.
+	if err := d.wr.RecordWebhookAttempt(context.WithoutCancel(ctx), job.ID, attempt, status, retryIn); err != nil {
+		logger.ErrorContext(ctx, "failed to record webhook attempt", "error", err)
+	}
+}
+
+// send POSTs the payload of job and fills in attempt.
+func (d *Dispatcher) send(ctx context.Context, job *model.WebhookJob, attempt *model.WebhookAttempt) {
+	start := time.Now()
+	defer func() { attempt.DurationMS = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-server-webhooks")
+	req.Header.Set(HeaderID, strconv.FormatInt(job.ID, 10))
+	req.Header.Set(HeaderEvent, job.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, job.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	attempt.ResponseStatus = &resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	// PostgreSQL text holds neither invalid UTF-8 nor NUL bytes.
+	attempt.ResponseBody = strings.ReplaceAll(strings.ToValidUTF8(string(body), string(utf8.RuneError)), "\x00", "")
+}
+
+// backoff returns the delay before retrying a delivery that has failed n
+// times.
+func backoff(n int) time.Duration {
+	delay := maxDelay
+	if n <= 20 {
+		delay = min(baseDelay<<(n-1), maxDelay)
+	}
+	return delay + mathrand.N(delay/10+1)
+}
+
+func attemptResult(status string) string {
+	if status == model.DeliveryPending {
+		return "retrying"
+	}
+	return status
+}
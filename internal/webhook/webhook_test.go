@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"api-server/internal/logging"
+	"api-server/internal/model"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// verify checks a delivery the way the package documentation tells
+// receivers to.
+func verify(secret string, header http.Header, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil || now.Sub(time.Unix(ts, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+	got, ok := strings.CutPrefix(header.Get(HeaderSignature), "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header.Get(HeaderTimestamp) + "."))
+	mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+func TestSign(t *testing.T) {
+	// Computed independently as
+	// HMAC-SHA256("whsec_test", `1735689600.{"type":"course.created"}`).
+	want := "sha256=ea9841f9c650188f3cf8ace39a9f00ad2d1430500db34a73b079adc7ccb7f5ad"
+	if got := Sign("whsec_test", 1735689600, []byte(`{"type":"course.created"}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxDelay},
+		{20, maxDelay},
+		{21, maxDelay},
+		{1000, maxDelay},
+	}
+	for _, tt := range tests {
+		for range 100 {
+			got := backoff(tt.failures)
+			if got < tt.delay || got > tt.delay+tt.delay/10 {
+				t.Fatalf("backoff(%d) = %v, want %v plus at most 10%%", tt.failures, got, tt.delay)
+			}
+		}
+	}
+}
+
+// expectAttempt expects the outcome of an attempt of delivery 7 that got
+// status from the receiver to be recorded as a retry or, if it is not
+// pending, as the delivery's final state.
+func expectAttempt(mock sqlmock.Sqlmock, status int, state string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO api.webhook_attempt").
+		WithArgs(int64(7), sqlmock.AnyArg(), status, sqlmock.AnyArg(), "").
+		WillReturnRows(sqlmock.NewRows([]string{"attempted_at"}).AddRow(time.Now()))
+	if state == model.DeliveryPending {
+		mock.ExpectExec("UPDATE api.webhook_delivery SET attempts = attempts \\+ 1, next_attempt_at").
+			WithArgs(sqlmock.AnyArg(), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	} else {
+		mock.ExpectExec("UPDATE api.webhook_delivery SET attempts = attempts \\+ 1, status = \\$1").
+			WithArgs(state, int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	payload := []byte(`{"type":"course.created"}`)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !verify("whsec_test", r.Header, body, time.Now()) {
+			t.Errorf("receiver rejected the signature %s", r.Header.Get(HeaderSignature))
+		}
+		if r.Header.Get(HeaderID) != "7" || r.Header.Get(HeaderEvent) != "course.created" {
+			t.Errorf("delivery headers are %s and %s", r.Header.Get(HeaderID), r.Header.Get(HeaderEvent))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	expectAttempt(mock, http.StatusNoContent, model.DeliverySucceeded)
+
+	d := New(db, Config{Timeout: time.Second, MaxAttempts: 3}, logging.Discard())
+	d.deliver(context.Background(), &model.WebhookJob{ID: 7, EventType: "course.created", Payload: payload, URL: receiver.URL, Secret: "whsec_test", Live: true})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeliverDoesNotFollowRedirect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect was followed")
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer receiver.Close()
+	expectAttempt(mock, http.StatusFound, model.DeliveryPending)
+
+	d := New(db, Config{Timeout: time.Second, MaxAttempts: 3}, logging.Discard())
+	d.deliver(context.Background(), &model.WebhookJob{ID: 7, EventType: "course.created", Payload: []byte(`{}`), URL: receiver.URL, Secret: "whsec_test", Live: true})
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name     string
+		attempts int
+		state    string
+	}{
+		{"retries", 1, model.DeliveryPending},
+		{"last attempt", 2, model.DeliveryFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			expectAttempt(mock, http.StatusServiceUnavailable, tt.state)
+
+			// The delivery has failed tt.attempts times before.
+			d := New(db, Config{Timeout: time.Second, MaxAttempts: 3}, logging.Discard())
+			d.deliver(context.Background(), &model.WebhookJob{ID: 7, EventType: "course.created", Payload: []byte(`{}`), URL: receiver.URL, Secret: "whsec_test", Attempts: tt.attempts, Live: true})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
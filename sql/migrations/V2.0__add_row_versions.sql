@@ -0,0 +1,6 @@
+-- Row versions back the ETag, If-Match and If-None-Match headers. Every write
+-- increments version; conditional writes compare it to the client's ETag.
+ALTER TABLE api.user ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE api.instructor ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
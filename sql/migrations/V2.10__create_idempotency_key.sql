@@ -0,0 +1,22 @@
+-- Responses to POST requests sent with an Idempotency-Key header, so that a
+-- retry replays the original response instead of repeating the request.
+-- Keys are scoped to the authenticated user. fingerprint hashes the method,
+-- path and body of the first request; status is NULL while it is still
+-- being processed. The process handling it holds the key as locked_by until
+-- locked_until and keeps extending that lease; once it lapses, for example
+-- because the process crashed, a retry takes the key over.
+CREATE TABLE IF NOT EXISTS api.idempotency_key (
+    actor VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status INTEGER,
+    headers JSONB,
+    body BYTEA,
+    locked_by UUID,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (actor, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_key_expires_at_idx ON api.idempotency_key (expires_at);
@@ -0,0 +1,52 @@
+-- Webhook subscriptions. event_types limits the events delivered to the
+-- subscription; an empty array subscribes to every event. secret signs the
+-- payloads with HMAC-SHA256.
+CREATE TABLE IF NOT EXISTS api.webhook_subscription (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    event_types VARCHAR(50)[] NOT NULL DEFAULT '{}',
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_last_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscription_deleted_at_idx ON api.webhook_subscription (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- The delivery queue: one row per event and subscription. Pending rows are
+-- sent once next_attempt_at has passed; the dispatcher pushes it forward
+-- while a row is being sent and after each failed attempt.
+CREATE TABLE IF NOT EXISTS api.webhook_delivery (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES api.webhook_subscription (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON api.webhook_delivery (next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON api.webhook_delivery (subscription_id, id);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_completed_at_idx ON api.webhook_delivery (completed_at) WHERE completed_at IS NOT NULL;
+
+-- The delivery log: one row per attempt to send a delivery.
+CREATE TABLE IF NOT EXISTS api.webhook_attempt (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES api.webhook_delivery (id) ON DELETE CASCADE,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    duration_ms INTEGER NOT NULL,
+    response_status INTEGER,
+    response_body TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS webhook_attempt_delivery_idx ON api.webhook_attempt (delivery_id, id);
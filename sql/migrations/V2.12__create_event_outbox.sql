@@ -0,0 +1,17 @@
+-- Domain events waiting to be published to the message broker. Rows are
+-- written alongside the changes they describe and published in id order by
+-- the relay, which sets published_at once the broker has acknowledged them.
+CREATE TABLE IF NOT EXISTS api.event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS event_outbox_pending_idx ON api.event_outbox (id) WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS event_outbox_created_at_idx ON api.event_outbox (created_at);
@@ -0,0 +1,16 @@
+-- Processing status of uploaded traces. A trace is stored as uploaded and
+-- moved by the trace processor through scanning and parsing to ready, or to
+-- failed with the reason in status_error. Each change is announced on the
+-- trace_status notification channel. Traces uploaded before this migration
+-- were processed during upload and are ready.
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'ready'
+    CHECK (status IN ('uploaded', 'scanning', 'parsing', 'ready', 'failed'));
+
+ALTER TABLE api.trace ALTER COLUMN status SET DEFAULT 'uploaded';
+
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS status_error TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS trace_processing_idx ON api.trace (date_created)
+    WHERE status IN ('uploaded', 'scanning', 'parsing') AND deleted_at IS NULL;
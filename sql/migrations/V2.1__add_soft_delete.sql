@@ -0,0 +1,16 @@
+-- Soft delete: rows are hidden by setting deleted_at and permanently removed
+-- by the purge job once PURGE_RETENTION has passed.
+ALTER TABLE api.user ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE api.instructor ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- A deleted user must not block a new account with the same username.
+ALTER TABLE api.user DROP CONSTRAINT IF EXISTS user_username_key;
+CREATE UNIQUE INDEX IF NOT EXISTS user_username_live_idx ON api.user (username) WHERE deleted_at IS NULL;
+
+-- The purge job scans for expired rows.
+CREATE INDEX IF NOT EXISTS user_deleted_at_idx ON api.user (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS instructor_deleted_at_idx ON api.instructor (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS course_deleted_at_idx ON api.course (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS trace_deleted_at_idx ON api.trace (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,27 @@
+-- Append-only record of every mutation made through the API.
+CREATE TABLE IF NOT EXISTS api.audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(255) NOT NULL,
+    resource VARCHAR(64) NOT NULL,
+    resource_id UUID NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR(128),
+    source_ip VARCHAR(64)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON api.audit_log (actor, id);
+CREATE INDEX IF NOT EXISTS audit_log_resource_idx ON api.audit_log (resource, resource_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_occurred_at_idx ON api.audit_log (occurred_at);
+
+CREATE OR REPLACE FUNCTION api.audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'api.audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON api.audit_log;
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON api.audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION api.audit_log_append_only();
@@ -0,0 +1,27 @@
+-- Course capacity; NULL means unlimited.
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS capacity INTEGER CHECK (capacity > 0);
+
+CREATE TABLE IF NOT EXISTS api.enrollment (
+    id UUID PRIMARY KEY,
+    course_id UUID NOT NULL REFERENCES api.course (id),
+    user_id UUID NOT NULL REFERENCES api.user (id),
+    semester_term VARCHAR(20) NOT NULL,
+    semester_year INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('enrolled', 'waitlisted', 'dropped', 'completed')),
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status_changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP
+);
+
+-- One enrollment per student per course and term.
+CREATE UNIQUE INDEX IF NOT EXISTS enrollment_user_course_term_idx
+    ON api.enrollment (user_id, course_id, semester_term, semester_year)
+    WHERE deleted_at IS NULL;
+
+-- Capacity checks and waitlist promotion scan a course's live enrollments.
+CREATE INDEX IF NOT EXISTS enrollment_course_status_idx
+    ON api.enrollment (course_id, status, status_changed_at)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS enrollment_deleted_at_idx ON api.enrollment (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,114 @@
+-- Academic terms replace the free-form semester columns of api.course.
+CREATE TABLE IF NOT EXISTS api.term (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    semester_term VARCHAR(20) NOT NULL,
+    semester_year INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    registration_opens DATE NOT NULL,
+    registration_closes DATE NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_last_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    CHECK (end_date >= start_date),
+    CHECK (registration_closes >= registration_opens),
+    CHECK (registration_closes <= end_date)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS term_semester_idx
+    ON api.term (semester_term, semester_year)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS term_dates_idx ON api.term (start_date, end_date) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS term_deleted_at_idx ON api.term (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Every course and enrollment must map to a term. Course semesters were never
+-- validated, so stop with the offending values rather than guess a term for
+-- them; fix the rows and run the migration again.
+DO $$
+DECLARE
+    missing INTEGER;
+    unknown TEXT;
+BEGIN
+    SELECT count(*) INTO missing FROM api.course
+    WHERE nullif(trim(semesterterm), '') IS NULL OR semesteryear IS NULL;
+    IF missing > 0 THEN
+        RAISE EXCEPTION '% course(s) have no semesterterm or semesteryear', missing
+            USING HINT = 'Set both columns, e.g. find the rows with SELECT id, name FROM api.course WHERE semesterterm IS NULL OR semesteryear IS NULL.';
+    END IF;
+
+    SELECT string_agg(DISTINCT quote_literal(s.semester_term), ', ') INTO unknown
+    FROM (
+        SELECT semesterterm AS semester_term FROM api.course
+        UNION ALL
+        SELECT semester_term FROM api.enrollment
+    ) s
+    WHERE initcap(trim(s.semester_term)) NOT IN ('Winter', 'Spring', 'Summer', 'Fall');
+    IF unknown IS NOT NULL THEN
+        RAISE EXCEPTION 'unknown semester terms %', unknown
+            USING HINT = 'Semester terms must be Winter, Spring, Summer or Fall, in any case.';
+    END IF;
+END;
+$$;
+
+-- Backfill one term per semester already used by a course or enrollment,
+-- with spellings normalized. The dates are typical defaults; adjust them
+-- through the API after migrating.
+INSERT INTO api.term (id, name, semester_term, semester_year, start_date, end_date, registration_opens, registration_closes)
+SELECT uuid_generate_v4(), s.semester_term || ' ' || s.semester_year, s.semester_term, s.semester_year,
+       d.start_date, d.end_date, d.start_date - 90, d.start_date + 14
+FROM (
+    SELECT initcap(trim(semesterterm)) AS semester_term, semesteryear AS semester_year FROM api.course
+    UNION
+    SELECT initcap(trim(semester_term)), semester_year FROM api.enrollment
+) s
+CROSS JOIN LATERAL (
+    SELECT CASE s.semester_term
+               WHEN 'Winter' THEN make_date(s.semester_year, 1, 2)
+               WHEN 'Spring' THEN make_date(s.semester_year, 1, 20)
+               WHEN 'Summer' THEN make_date(s.semester_year, 6, 1)
+               WHEN 'Fall' THEN make_date(s.semester_year, 8, 25)
+           END AS start_date,
+           CASE s.semester_term
+               WHEN 'Winter' THEN make_date(s.semester_year, 1, 18)
+               WHEN 'Spring' THEN make_date(s.semester_year, 5, 15)
+               WHEN 'Summer' THEN make_date(s.semester_year, 8, 15)
+               WHEN 'Fall' THEN make_date(s.semester_year, 12, 20)
+           END AS end_date
+) d
+WHERE NOT EXISTS (
+    SELECT 1 FROM api.term t
+    WHERE t.semester_term = s.semester_term AND t.semester_year = s.semester_year AND t.deleted_at IS NULL
+);
+
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS term_id UUID REFERENCES api.term (id);
+
+UPDATE api.course c SET term_id = t.id
+FROM api.term t
+WHERE c.term_id IS NULL AND t.semester_term = initcap(trim(c.semesterterm)) AND t.semester_year = c.semesteryear AND t.deleted_at IS NULL;
+
+ALTER TABLE api.course ALTER COLUMN term_id SET NOT NULL;
+ALTER TABLE api.course DROP COLUMN IF EXISTS semesterterm;
+ALTER TABLE api.course DROP COLUMN IF EXISTS semesteryear;
+
+CREATE INDEX IF NOT EXISTS course_term_idx ON api.course (term_id) WHERE deleted_at IS NULL;
+
+-- Enrollments record the term they were made for.
+ALTER TABLE api.enrollment ADD COLUMN IF NOT EXISTS term_id UUID REFERENCES api.term (id);
+
+UPDATE api.enrollment e SET term_id = t.id
+FROM api.term t
+WHERE e.term_id IS NULL AND t.semester_term = initcap(trim(e.semester_term)) AND t.semester_year = e.semester_year AND t.deleted_at IS NULL;
+
+ALTER TABLE api.enrollment ALTER COLUMN term_id SET NOT NULL;
+
+DROP INDEX IF EXISTS api.enrollment_user_course_term_idx;
+ALTER TABLE api.enrollment DROP COLUMN IF EXISTS semester_term;
+ALTER TABLE api.enrollment DROP COLUMN IF EXISTS semester_year;
+
+CREATE UNIQUE INDEX IF NOT EXISTS enrollment_user_course_term_idx
+    ON api.enrollment (user_id, course_id, term_id)
+    WHERE deleted_at IS NULL;
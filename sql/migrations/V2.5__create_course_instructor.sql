@@ -0,0 +1,36 @@
+-- Courses can have several instructors, each with a role and optional
+-- effective dates. This replaces the single api.course.instructorid column.
+CREATE TABLE IF NOT EXISTS api.course_instructor (
+    id UUID PRIMARY KEY,
+    course_id UUID NOT NULL REFERENCES api.course (id),
+    instructor_id UUID NOT NULL REFERENCES api.instructor (id),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('lead', 'co-instructor', 'ta')),
+    start_date DATE,
+    end_date DATE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_last_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    CHECK (end_date >= start_date)
+);
+
+-- An instructor holds each role on a course at most once.
+CREATE UNIQUE INDEX IF NOT EXISTS course_instructor_role_idx
+    ON api.course_instructor (course_id, instructor_id, role)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS course_instructor_instructor_idx
+    ON api.course_instructor (instructor_id)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS course_instructor_deleted_at_idx ON api.course_instructor (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Existing instructors become the lead of their course. Assignments of
+-- deleted courses are deleted with them so they do not block the purge.
+INSERT INTO api.course_instructor (id, course_id, instructor_id, role, deleted_at)
+SELECT uuid_generate_v4(), c.id, c.instructorid, 'lead', c.deleted_at
+FROM api.course c
+WHERE c.instructorid IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM api.course_instructor ci WHERE ci.course_id = c.id AND ci.instructor_id = c.instructorid);
+
+ALTER TABLE api.course DROP COLUMN IF EXISTS instructorid;
@@ -0,0 +1,18 @@
+-- Requisites link course codes rather than course rows, so they apply to
+-- every offering of a course. Prerequisites must form an acyclic graph;
+-- corequisites may be mutual.
+CREATE TABLE IF NOT EXISTS api.course_prerequisite (
+    id UUID PRIMARY KEY,
+    course_code VARCHAR(20) NOT NULL,
+    prerequisite_code VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('prerequisite', 'corequisite')),
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (course_code <> prerequisite_code),
+    UNIQUE (course_code, prerequisite_code)
+);
+
+-- Finds the courses that require a given course.
+CREATE INDEX IF NOT EXISTS course_prerequisite_prerequisite_idx ON api.course_prerequisite (prerequisite_code);
+
+-- Requisites are looked up by code.
+CREATE INDEX IF NOT EXISTS course_code_idx ON api.course (code) WHERE deleted_at IS NULL;
@@ -0,0 +1,24 @@
+-- Weekly meetings of a course. Days hold iCalendar day codes (MO..SU); times
+-- are local to the campus. Meetings recur for the dates of the course's term.
+CREATE TABLE IF NOT EXISTS api.course_meeting (
+    id UUID PRIMARY KEY,
+    course_id UUID NOT NULL REFERENCES api.course (id),
+    days VARCHAR(2)[] NOT NULL CHECK (cardinality(days) > 0 AND days <@ ARRAY['MO', 'TU', 'WE', 'TH', 'FR', 'SA', 'SU']::VARCHAR(2)[]),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    room VARCHAR(100),
+    modality VARCHAR(20) NOT NULL CHECK (modality IN ('in-person', 'online', 'hybrid')),
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_last_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    CHECK (end_time > start_time),
+    CHECK (modality = 'online' OR room IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS course_meeting_course_idx ON api.course_meeting (course_id) WHERE deleted_at IS NULL;
+
+-- Room conflict checks look up live meetings by room.
+CREATE INDEX IF NOT EXISTS course_meeting_room_idx ON api.course_meeting (lower(room)) WHERE deleted_at IS NULL AND modality <> 'online';
+
+CREATE INDEX IF NOT EXISTS course_meeting_deleted_at_idx ON api.course_meeting (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Full-text search over courses, instructors and trace content. Each table
+-- keeps a generated tsvector so the index stays current without triggers.
+-- Weight A marks identifying fields (codes, names, file names); B marks
+-- longer text.
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(code, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS course_search_idx ON api.course USING GIN (search) WHERE deleted_at IS NULL;
+
+ALTER TABLE api.instructor ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(name, '')), 'A')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS instructor_search_idx ON api.instructor USING GIN (search) WHERE deleted_at IS NULL;
+
+-- Text extracted from the uploaded PDF. Traces uploaded before this
+-- migration have none and are found by file name only.
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS content_text TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE api.trace ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(file_name, '')), 'A') ||
+    setweight(to_tsvector('english', content_text), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS trace_search_idx ON api.trace USING GIN (search) WHERE deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- A course code can be offered in several sections per term. Code, term and
+-- section identify an offering, which bulk imports use as their upsert key.
+ALTER TABLE api.course ADD COLUMN IF NOT EXISTS section VARCHAR(10);
+
+-- Number existing offerings of a code within a term in the order they were
+-- added, so that duplicates left by earlier imports become 01, 02, ...
+UPDATE api.course c SET section = lpad(s.n::text, 2, '0')
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY code, term_id ORDER BY date_added, id) AS n
+    FROM api.course WHERE section IS NULL
+) s
+WHERE c.id = s.id;
+
+ALTER TABLE api.course ALTER COLUMN section SET DEFAULT '01';
+ALTER TABLE api.course ALTER COLUMN section SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS course_offering_idx ON api.course (code, term_id, section) WHERE deleted_at IS NULL;